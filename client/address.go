@@ -0,0 +1,28 @@
+package client
+
+import (
+	"crypto/tls"
+	"strings"
+)
+
+// splitAddress strips the optional scheme and trailing slash
+// off the given server address returning the host address
+// and whether the connection must be secured by TLS.
+// Addresses without a scheme are secured only if a TLS configuration
+// is defined
+func splitAddress(address string, tlsConfig *tls.Config) (string, bool) {
+	secure := tlsConfig != nil
+	for scheme, schemeSecure := range map[string]bool{
+		"wss://":   true,
+		"https://": true,
+		"ws://":    false,
+		"http://":  false,
+	} {
+		if strings.HasPrefix(address, scheme) {
+			address = address[len(scheme):]
+			secure = schemeSecure
+			break
+		}
+	}
+	return strings.TrimSuffix(address, "/"), secure
+}
@@ -2,10 +2,10 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
 	"sync/atomic"
 
 	"fmt"
-	"log"
 	"sync"
 	"time"
 
@@ -15,7 +15,12 @@ import (
 	reqman "github.com/qbeon/webwire-go/requestManager"
 )
 
-const supportedProtocolVersion = "1.4"
+const supportedProtocolVersion = "1.5"
+
+// legacyProtocolVersion represents the previous protocol version
+// which is still supported by this client
+// though without the cancelation of requests
+const legacyProtocolVersion = "1.4"
 
 // Status represents the status of a client instance
 type Status = int32
@@ -47,7 +52,10 @@ const (
 
 // client represents an instance of one of the servers clients
 type client struct {
+	serverAddrLock    sync.RWMutex
 	serverAddr        string
+	secure            bool
+	tlsConfig         *tls.Config
 	impl              Implementation
 	sessionInfoParser webwire.SessionInfoParser
 	status            Status
@@ -58,6 +66,11 @@ type client struct {
 	sessionLock sync.RWMutex
 	session     *webwire.Session
 
+	// subscriptions maps the topics the client is subscribed to
+	// to their publication handlers
+	subscriptionsLock sync.RWMutex
+	subscriptions     map[string]PublicationHandler
+
 	// The API lock synchronizes concurrent access to the public client interface.
 	// Request, and Signal methods are locked with a shared lock
 	// because performing multiple requests and/or signals simultaneously is fine.
@@ -73,15 +86,33 @@ type client struct {
 	// connectingLock protects the connecting flag from concurrent access
 	connectingLock sync.RWMutex
 
-	connectLock   sync.Mutex
-	conn          webwire.Socket
+	connectLock sync.Mutex
+	conn        webwire.Socket
+	// legacyServer is set to 1 if the server runs the legacy protocol version
+	legacyServer  int32
 	readerClosing chan bool
 
+	// goingAway is the reconnection advice of the server if it's going away
+	goingAwayLock sync.Mutex
+	goingAway     *goingAway
+
 	requestManager reqman.RequestManager
+	propagator     webwire.Propagator
+	metrics        Metrics
+
+	logger webwire.Logger
+}
 
-	// Loggers
-	warningLog *log.Logger
-	errorLog   *log.Logger
+// logFields returns the logging fields identifying the client
+// followed by the given additional fields
+func (clt *client) logFields(fields ...interface{}) []interface{} {
+	base := []interface{}{"server", clt.address()}
+	clt.sessionLock.RLock()
+	if clt.session != nil {
+		base = append(base, "session", clt.session.Key)
+	}
+	clt.sessionLock.RUnlock()
+	return append(base, fields...)
 }
 
 // Status returns the current client status
@@ -112,6 +143,19 @@ func (clt *client) Request(
 	ctx context.Context,
 	name string,
 	payload webwire.Payload,
+) (webwire.Payload, error) {
+	return clt.RequestWithHeaders(ctx, name, nil, payload)
+}
+
+// RequestWithHeaders sends a request containing the given headers
+// and payload to the server and asynchronously returns the servers response
+// blocking the calling goroutine.
+// Returns an error if the request failed for some reason
+func (clt *client) RequestWithHeaders(
+	ctx context.Context,
+	name string,
+	headers map[string]string,
+	payload webwire.Payload,
 ) (webwire.Payload, error) {
 	if ctx == nil {
 		ctx = context.Background()
@@ -128,6 +172,7 @@ func (clt *client) Request(
 		ctx,
 		scanPayloadEncoding(payload),
 		name,
+		headers,
 		payload,
 		clt.defaultReqTimeout,
 	)
@@ -135,13 +180,25 @@ func (clt *client) Request(
 
 // Signal sends a signal containing the given payload to the server
 func (clt *client) Signal(name string, payload webwire.Payload) error {
+	return clt.SignalWithHeaders(context.Background(), name, nil, payload)
+}
+
+// SignalWithHeaders sends a signal containing the given headers
+// and payload to the server
+func (clt *client) SignalWithHeaders(
+	ctx context.Context,
+	name string,
+	headers map[string]string,
+	payload webwire.Payload,
+) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
 	clt.apiLock.RLock()
 	defer clt.apiLock.RUnlock()
 
-	if err := clt.tryAutoconnect(
-		context.Background(),
-		clt.defaultReqTimeout,
-	); err != nil {
+	if err := clt.tryAutoconnect(ctx, clt.defaultReqTimeout); err != nil {
 		return err
 	}
 
@@ -162,11 +219,96 @@ func (clt *client) Signal(name string, payload webwire.Payload) error {
 		data = payload.Data()
 	}
 
-	return clt.conn.Write(msg.NewSignalMessage(
-		name,
-		encoding,
-		data,
-	))
+	headers, err := clt.messageHeaders(ctx, headers)
+	if err != nil {
+		return err
+	}
+
+	if err := clt.conn.Write(msg.NewHeadersMessage(
+		headers,
+		msg.NewSignalMessage(name, encoding, data),
+	)); err != nil {
+		return err
+	}
+	clt.metrics.SignalSent(name)
+	return nil
+}
+
+// Subscribe subscribes the client to the given topic on the server
+// and blocks until the subscription is confirmed
+func (clt *client) Subscribe(topic string, handler PublicationHandler) error {
+	if handler == nil {
+		return fmt.Errorf("Missing publication handler")
+	}
+
+	clt.apiLock.RLock()
+	defer clt.apiLock.RUnlock()
+
+	if err := clt.tryAutoconnect(
+		context.Background(),
+		clt.defaultReqTimeout,
+	); err != nil {
+		return err
+	}
+
+	if atomic.LoadInt32(&clt.legacyServer) == 1 {
+		return webwire.NewProtocolErr(fmt.Errorf(
+			"Topics are not supported by protocol version %s",
+			legacyProtocolVersion,
+		))
+	}
+
+	// Register the handler before subscribing
+	// to not miss publications arriving right after the confirmation
+	clt.subscriptionsLock.Lock()
+	previous, subscribed := clt.subscriptions[topic]
+	clt.subscriptions[topic] = handler
+	clt.subscriptionsLock.Unlock()
+
+	if err := clt.requestSubscription(topic); err != nil {
+		// Restore the previous state
+		clt.subscriptionsLock.Lock()
+		if subscribed {
+			clt.subscriptions[topic] = previous
+		} else {
+			delete(clt.subscriptions, topic)
+		}
+		clt.subscriptionsLock.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Unsubscribe removes the subscription to the given topic
+// and acknowledges the server if connected
+func (clt *client) Unsubscribe(topic string) error {
+	clt.apiLock.RLock()
+	defer clt.apiLock.RUnlock()
+
+	clt.subscriptionsLock.Lock()
+	if _, subscribed := clt.subscriptions[topic]; !subscribed {
+		clt.subscriptionsLock.Unlock()
+		return nil
+	}
+	delete(clt.subscriptions, topic)
+	clt.subscriptionsLock.Unlock()
+
+	// Synchronize the unsubscription to the server if connected,
+	// otherwise the server already dropped the subscription
+	if atomic.LoadInt32(&clt.status) == Connected {
+		if _, err := clt.sendNamelessRequest(
+			context.Background(),
+			msg.MsgUnsubscribe,
+			pld.Payload{
+				Encoding: webwire.EncodingBinary,
+				Data:     []byte(topic),
+			},
+			clt.defaultReqTimeout,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Session returns an exact copy of the session object or nil if there's no
@@ -178,8 +320,9 @@ func (clt *client) Session() *webwire.Session {
 		return nil
 	}
 	clone := &webwire.Session{
-		Key:      clt.session.Key,
-		Creation: clt.session.Creation,
+		Key:        clt.session.Key,
+		Creation:   clt.session.Creation,
+		Expiration: clt.session.Expiration,
 	}
 	if clt.session.Info != nil {
 		clone.Info = clt.session.Info.Copy()
@@ -289,7 +432,10 @@ func (clt *client) Close() {
 	atomic.StoreInt32(&clt.status, Disabled)
 
 	if err := clt.conn.Close(); err != nil {
-		clt.errorLog.Printf("Failed closing connection: %s", err)
+		clt.logger.Error(
+			"Couldn't close connection",
+			clt.logFields("error", err)...,
+		)
 	}
 
 	// Wait for the reader goroutine to die before returning
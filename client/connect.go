@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"sync/atomic"
+	"time"
 )
 
 // connect will try to establish a connection to the configured webwire server
@@ -23,7 +24,7 @@ func (clt *client) connect() error {
 		return err
 	}
 
-	if err := clt.conn.Dial(clt.serverAddr); err != nil {
+	if err := clt.conn.Dial(clt.address()); err != nil {
 		return err
 	}
 
@@ -40,12 +41,19 @@ func (clt *client) connect() error {
 		for {
 			message, err := clt.conn.Read()
 			if err != nil {
-				if err.IsAbnormalCloseErr() {
+				// Don't consider the closure abnormal
+				// if the server announced its shutdown
+				notice := clt.takeGoingAway()
+				if notice == nil && err.IsAbnormalCloseErr() {
 					// Error while reading message
-					clt.errorLog.Print("Abnormal closure error:", err)
+					clt.logger.Error(
+						"Abnormal closure",
+						clt.logFields("error", err)...,
+					)
 				}
 
 				atomic.StoreInt32(&clt.status, Disconnected)
+				clt.metrics.Disconnected()
 
 				// Call hook
 				clt.impl.OnDisconnected()
@@ -55,11 +63,18 @@ func (clt *client) connect() error {
 				// and free up the socket
 				if atomic.LoadInt32(&clt.autoconnect) == autoconnectEnabled {
 					go func() {
+						// Wait as advised by the server if it went away
+						if notice != nil && notice.delay > 0 {
+							time.Sleep(notice.delay)
+						}
 						if err := clt.tryAutoconnect(
 							context.Background(),
 							0,
 						); err != nil {
-							clt.errorLog.Printf("Auto-reconnect failed after connection loss: %s", err)
+							clt.logger.Error(
+								"Auto-reconnect failed after connection loss",
+								clt.logFields("error", err)...,
+							)
 							return
 						}
 					}()
@@ -68,18 +83,30 @@ func (clt *client) connect() error {
 			}
 			// Try to handle the message
 			if err := clt.handleMessage(message); err != nil {
-				clt.warningLog.Print("Failed handling message:", err)
+				clt.logger.Warn(
+					"Couldn't handle message",
+					clt.logFields("error", err)...,
+				)
 			}
 		}
 	}()
 
 	atomic.StoreInt32(&clt.status, Connected)
+	clt.metrics.Connected()
+
+	clt.tryRestoreSession()
+	clt.restoreSubscriptions()
+	return nil
+}
 
+// tryRestoreSession tries to restore the current session if there is any.
+// The session is reset if its restoration fails
+func (clt *client) tryRestoreSession() {
 	// Read the current sessions key if there is any
 	clt.sessionLock.RLock()
 	if clt.session == nil {
 		clt.sessionLock.RUnlock()
-		return nil
+		return
 	}
 	sessionKey := clt.session.Key
 	clt.sessionLock.RUnlock()
@@ -87,19 +114,21 @@ func (clt *client) connect() error {
 	// Try to restore session if necessary
 	restoredSession, err := clt.requestSessionRestoration([]byte(sessionKey))
 	if err != nil {
-		// Just log a warning and still return nil, even if session restoration failed,
-		// because we only care about the connection establishment in this method
-		clt.warningLog.Printf("Couldn't restore session on reconnection: %s", err)
+		// Just log a warning, even if session restoration failed,
+		// because we only care about the connection establishment in connect
+		clt.logger.Warn(
+			"Couldn't restore session on reconnection",
+			clt.logFields("error", err)...,
+		)
 
 		// Reset the session
 		clt.sessionLock.Lock()
 		clt.session = nil
 		clt.sessionLock.Unlock()
-		return nil
+		return
 	}
 
 	clt.sessionLock.Lock()
 	clt.session = restoredSession
 	clt.sessionLock.Unlock()
-	return nil
 }
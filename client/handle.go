@@ -12,7 +12,10 @@ import (
 func (clt *client) handleSessionCreated(msgPayload pld.Payload) {
 	var encoded webwire.JSONEncodedSession
 	if err := json.Unmarshal(msgPayload.Data, &encoded); err != nil {
-		clt.errorLog.Printf("Failed unmarshalling session object: %s", err)
+		clt.logger.Error(
+			"Couldn't unmarshal session object",
+			clt.logFields("error", err)...,
+		)
 		return
 	}
 
@@ -24,21 +27,83 @@ func (clt *client) handleSessionCreated(msgPayload pld.Payload) {
 
 	clt.sessionLock.Lock()
 	clt.session = &webwire.Session{
-		Key:      encoded.Key,
-		Creation: encoded.Creation,
-		Info:     parsedSessInfo,
+		Key:        encoded.Key,
+		Creation:   encoded.Creation,
+		Expiration: encoded.Expiration,
+		Info:       parsedSessInfo,
 	}
 	clt.sessionLock.Unlock()
 	clt.impl.OnSessionCreated(clt.session)
 }
 
-func (clt *client) handleSessionClosed() {
+func (clt *client) handleSessionUpdated(msgPayload pld.Payload) {
+	var encoded webwire.JSONEncodedSession
+	if err := json.Unmarshal(msgPayload.Data, &encoded); err != nil {
+		clt.logger.Error(
+			"Couldn't unmarshal session object",
+			clt.logFields("error", err)...,
+		)
+		return
+	}
+
+	// parse attached session info
+	var parsedSessInfo webwire.SessionInfo
+	if encoded.Info != nil && clt.sessionInfoParser != nil {
+		parsedSessInfo = clt.sessionInfoParser(encoded.Info)
+	}
+
+	clt.sessionLock.Lock()
+	if clt.session == nil || clt.session.Key != encoded.Key {
+		// Ignore updates of sessions that are no longer active
+		clt.sessionLock.Unlock()
+		return
+	}
+	clt.session = &webwire.Session{
+		Key:        encoded.Key,
+		Creation:   encoded.Creation,
+		Expiration: encoded.Expiration,
+		Info:       parsedSessInfo,
+	}
+	updated := clt.session
+	clt.sessionLock.Unlock()
+	clt.impl.OnSessionUpdated(updated)
+}
+
+func (clt *client) handleSessionKeyRotated(newKey string) {
+	clt.sessionLock.Lock()
+	if clt.session == nil {
+		// Ignore rotations of sessions that are no longer active
+		clt.sessionLock.Unlock()
+		return
+	}
+	rotated := clt.session.Clone()
+	rotated.Key = newKey
+	clt.session = rotated
+	clt.sessionLock.Unlock()
+	clt.impl.OnSessionUpdated(rotated)
+}
+
+func (clt *client) handleSessionClosed(reason webwire.SessionCloseReason) {
 	// Destroy local session
 	clt.sessionLock.Lock()
 	clt.session = nil
 	clt.sessionLock.Unlock()
 
-	clt.impl.OnSessionClosed()
+	clt.impl.OnSessionClosed(reason)
+}
+
+func (clt *client) handlePublication(topic string, payload pld.Payload) {
+	clt.subscriptionsLock.RLock()
+	handler, subscribed := clt.subscriptions[topic]
+	clt.subscriptionsLock.RUnlock()
+
+	if !subscribed {
+		// Drop publications of topics the client unsubscribed from
+		return
+	}
+	handler(&webwire.EncodedPayload{
+		Payload: payload,
+	})
 }
 
 func (clt *client) handleFailure(
@@ -87,7 +152,9 @@ func (clt *client) handleMessage(message []byte) error {
 	typeDetermined, err := parsedMsg.Parse(message)
 	if !typeDetermined {
 		return fmt.Errorf("Couldn't determine message type")
-	} else if err != nil {
+	}
+	clt.metrics.MessageReceived(parsedMsg.Type, len(message))
+	if err != nil {
 		return err
 	}
 
@@ -127,14 +194,38 @@ func (clt *client) handleMessage(message []byte) error {
 			Payload: parsedMsg.Payload,
 		})
 
+	case msg.MsgPublicationBinary:
+		fallthrough
+	case msg.MsgPublicationUtf8:
+		fallthrough
+	case msg.MsgPublicationUtf16:
+		clt.handlePublication(parsedMsg.Name, parsedMsg.Payload)
+
+	case msg.MsgRequestBinary:
+		fallthrough
+	case msg.MsgRequestUtf8:
+		fallthrough
+	case msg.MsgRequestUtf16:
+		// Handle requests in a separate goroutine
+		// to not block the reader goroutine
+		go clt.handleRequest(&parsedMsg)
+
 	case msg.MsgSessionCreated:
 		clt.handleSessionCreated(parsedMsg.Payload)
+	case msg.MsgSessionUpdated:
+		clt.handleSessionUpdated(parsedMsg.Payload)
+	case msg.MsgSessionKeyRotated:
+		clt.handleSessionKeyRotated(parsedMsg.Name)
 	case msg.MsgSessionClosed:
-		clt.handleSessionClosed()
+		clt.handleSessionClosed(webwire.SessionCloseReason(
+			msg.SessionClosedReason(&parsedMsg),
+		))
+	case msg.MsgGoingAway:
+		clt.handleGoingAway(&parsedMsg)
 	default:
-		clt.warningLog.Printf(
-			"Strange message type received: '%d'\n",
-			parsedMsg.Type,
+		clt.logger.Warn(
+			"Unexpected message type received",
+			clt.logFields("messageType", parsedMsg.Type)...,
 		)
 	}
 	return nil
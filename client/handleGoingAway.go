@@ -0,0 +1,66 @@
+package client
+
+import (
+	"time"
+
+	msg "github.com/qbeon/webwire-go/message"
+)
+
+// goingAway represents the advice of a shutting down server
+// to reconnect after a delay, optionally to an alternative address
+type goingAway struct {
+	delay   time.Duration
+	address string
+}
+
+// handleGoingAway records the reconnection advice of the server
+// and switches to the alternative server address if any
+func (clt *client) handleGoingAway(message *msg.Message) {
+	notice := &goingAway{
+		delay: time.Duration(
+			msg.GoingAwayReconnectDelay(message),
+		) * time.Millisecond,
+		address: message.Name,
+	}
+
+	// The alternative address is always dialed over
+	// the same transport as the original one
+	if notice.address != "" {
+		notice.address, _ = splitAddress(notice.address, nil)
+	}
+
+	clt.goingAwayLock.Lock()
+	clt.goingAway = notice
+	clt.goingAwayLock.Unlock()
+
+	if notice.address != "" {
+		clt.serverAddrLock.Lock()
+		clt.serverAddr = notice.address
+		clt.serverAddrLock.Unlock()
+	}
+
+	clt.logger.Info(
+		"Server is going away",
+		clt.logFields(
+			"reconnectDelay", notice.delay,
+			"reconnectAddress", notice.address,
+		)...,
+	)
+}
+
+// takeGoingAway returns and resets the reconnection advice
+// of the server if any
+func (clt *client) takeGoingAway() *goingAway {
+	clt.goingAwayLock.Lock()
+	defer clt.goingAwayLock.Unlock()
+	notice := clt.goingAway
+	clt.goingAway = nil
+	return notice
+}
+
+// address returns the current server address
+func (clt *client) address() string {
+	clt.serverAddrLock.RLock()
+	defer clt.serverAddrLock.RUnlock()
+	return clt.serverAddr
+}
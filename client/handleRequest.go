@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+
+	webwire "github.com/qbeon/webwire-go"
+	msg "github.com/qbeon/webwire-go/message"
+)
+
+// handleRequest handles requests sent by the server
+// and replies with either the returned payload or error
+func (clt *client) handleRequest(message *msg.Message) {
+	replyPayload, returnedErr := clt.impl.OnRequest(
+		context.Background(),
+		webwire.NewMessageWrapper(message),
+	)
+
+	var reply []byte
+	switch err := returnedErr.(type) {
+	case nil:
+		// Initialize payload encoding & data
+		var encoding webwire.PayloadEncoding
+		var data []byte
+		if replyPayload != nil {
+			encoding = replyPayload.Encoding()
+			data = replyPayload.Data()
+		}
+		reply = msg.NewReplyMessage(message.Identifier, encoding, data)
+	case webwire.ReqErr:
+		reply = msg.NewErrorReplyMessage(
+			message.Identifier,
+			err.Code,
+			err.Message,
+		)
+	case *webwire.ReqErr:
+		reply = msg.NewErrorReplyMessage(
+			message.Identifier,
+			err.Code,
+			err.Message,
+		)
+	default:
+		clt.logger.Error(
+			"Internal error during request handling",
+			clt.logFields("request", message.Name, "error", returnedErr)...,
+		)
+		reply = msg.NewSpecialRequestReplyMessage(
+			msg.MsgInternalError,
+			message.Identifier,
+		)
+	}
+
+	// Send reply
+	if err := clt.conn.Write(reply); err != nil {
+		clt.logger.Error(
+			"Couldn't send reply",
+			clt.logFields("request", message.Name, "error", err)...,
+		)
+	}
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	webwire "github.com/qbeon/webwire-go"
+)
+
+// messageHeaders returns the given headers complemented by the values
+// the propagator injects from the given context.
+// Returns an error if any of the headers is invalid
+// or if the server doesn't support headers while headers are given
+func (clt *client) messageHeaders(
+	ctx context.Context,
+	headers map[string]string,
+) (map[string]string, error) {
+	if err := verifyHeaders(headers); err != nil {
+		return nil, webwire.NewProtocolErr(err)
+	}
+
+	// Legacy servers don't support headers
+	if atomic.LoadInt32(&clt.legacyServer) == 1 {
+		if len(headers) > 0 {
+			return nil, webwire.NewProtocolErr(fmt.Errorf(
+				"Headers are not supported by the server",
+			))
+		}
+		return nil, nil
+	}
+
+	if clt.propagator == nil {
+		return headers, nil
+	}
+
+	// Copy the headers to not modify the map of the caller
+	combined := make(map[string]string, len(headers)+2)
+	for key, value := range headers {
+		combined[key] = value
+	}
+	clt.propagator.Inject(ctx, combined)
+	return combined, nil
+}
+
+// verifyHeaders returns an error if the given headers
+// can't be transmitted
+func verifyHeaders(headers map[string]string) error {
+	if len(headers) > 255 {
+		return fmt.Errorf("Too many headers: %d", len(headers))
+	}
+	for key, value := range headers {
+		if len(key) < 1 || len(key) > 255 {
+			return fmt.Errorf("Invalid header key length: %d", len(key))
+		}
+		for i := 0; i < len(key); i++ {
+			if key[i] < 33 || key[i] > 126 {
+				return fmt.Errorf(
+					"Unsupported character in header key: %q",
+					key[i],
+				)
+			}
+		}
+		if len(value) > 65535 {
+			return fmt.Errorf(
+				"Header value of %q too long: %d",
+				key,
+				len(value),
+			)
+		}
+	}
+	return nil
+}
@@ -34,9 +34,42 @@ type Client interface {
 		payload webwire.Payload,
 	) (webwire.Payload, error)
 
+	// RequestWithHeaders is equivalent to Request
+	// but additionally transmits the given headers,
+	// which are accessible by the server through Message.Headers
+	RequestWithHeaders(
+		ctx context.Context,
+		name string,
+		headers map[string]string,
+		payload webwire.Payload,
+	) (webwire.Payload, error)
+
 	// Signal sends a signal containing the given payload to the server
 	Signal(name string, payload webwire.Payload) error
 
+	// SignalWithHeaders is equivalent to Signal
+	// but additionally transmits the given headers.
+	// The context is respected during autoconnection
+	// and passed to the propagator
+	SignalWithHeaders(
+		ctx context.Context,
+		name string,
+		headers map[string]string,
+		payload webwire.Payload,
+	) error
+
+	// Subscribe subscribes the client to the given topic on the server
+	// and blocks until the subscription is confirmed.
+	// The given handler is invoked for every payload published to the topic,
+	// subscribing to an already subscribed topic replaces its handler.
+	// Subscriptions are automatically restored after reconnection
+	Subscribe(topic string, handler PublicationHandler) error
+
+	// Unsubscribe removes the subscription to the given topic
+	// and acknowledges the server if connected.
+	// Unsubscribe does nothing if there's no such subscription
+	Unsubscribe(topic string) error
+
 	// Session returns an exact copy of the session object,
 	// otherwise returns nil if there's currently no session
 	Session() *webwire.Session
@@ -64,6 +97,12 @@ type Client interface {
 	Close()
 }
 
+// PublicationHandler represents the type of a function handling
+// the payloads published to a topic the client is subscribed to.
+// It's invoked by the goroutine reading incoming messages and will block
+// the processing of other incoming messages while executing
+type PublicationHandler func(payload webwire.Payload)
+
 // Implementation defines a webwire client implementation interface
 type Implementation interface {
 	// OnDisconnected is invoked when the client is disconnected
@@ -74,10 +113,29 @@ type Implementation interface {
 	// from the server
 	OnSignal(payload webwire.Payload)
 
+	// OnRequest is invoked when the client receives a request
+	// from the server.
+	// It must return either a reply payload or an error.
+	// A webwire.ReqErr error can be returned to reply with an error code
+	// and an error message, any other error type is replied
+	// as an internal error and logged.
+	//
+	// This hook is invoked in a separate goroutine
+	// and doesn't block the processing of other incoming messages
+	OnRequest(
+		ctx context.Context,
+		message webwire.Message,
+	) (response webwire.Payload, err error)
+
 	// OnSessionCreated is invoked when the client was assigned a new session
 	OnSessionCreated(*webwire.Session)
 
+	// OnSessionUpdated is invoked when either the info or the key
+	// of the client's session was updated by the server
+	OnSessionUpdated(*webwire.Session)
+
 	// OnSessionClosed is invoked when the client's session was closed
-	// either by the server or the client itself
-	OnSessionClosed()
+	// either by the server or the client itself.
+	// The reason is SessionCloseUnspecified if the server didn't report it
+	OnSessionClosed(reason webwire.SessionCloseReason)
 }
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"time"
+)
+
+// Metrics defines the interface of a client metrics observer.
+// All methods are called synchronously and must therefore be thread safe
+// and return quickly
+type Metrics interface {
+	// Connected is called when the client established a connection
+	Connected()
+
+	// Disconnected is called when the client lost its connection
+	Disconnected()
+
+	// RequestCompleted is called when a request either received a reply
+	// or failed given the request name, the round-trip duration
+	// and the error if any
+	RequestCompleted(name string, duration time.Duration, err error)
+
+	// SignalSent is called when a signal was successfully sent
+	SignalSent(name string)
+
+	// MessageReceived is called for each received message
+	// of a known type given its type and size in bytes
+	MessageReceived(messageType byte, size int)
+}
+
+// NopMetrics implements the Metrics interface ignoring all observations.
+// It can be embedded to implement only a subset of the Metrics interface
+type NopMetrics struct{}
+
+// Connected implements the Metrics interface
+func (NopMetrics) Connected() {}
+
+// Disconnected implements the Metrics interface
+func (NopMetrics) Disconnected() {}
+
+// RequestCompleted implements the Metrics interface
+func (NopMetrics) RequestCompleted(string, time.Duration, error) {}
+
+// SignalSent implements the Metrics interface
+func (NopMetrics) SignalSent(string) {}
+
+// MessageReceived implements the Metrics interface
+func (NopMetrics) MessageReceived(byte, int) {}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"compress/flate"
 	"context"
 	"fmt"
 	"sync"
@@ -31,9 +32,31 @@ func NewClient(
 		autoconnect = autoconnectDisabled
 	}
 
+	if opts.CompressionLevel < flate.HuffmanOnly ||
+		opts.CompressionLevel > flate.BestCompression {
+		panic(fmt.Errorf(
+			"Invalid compression level: %d",
+			opts.CompressionLevel,
+		))
+	}
+
+	// Determine whether to connect over TLS
+	serverAddress, secure := splitAddress(serverAddress, opts.TLSConfig)
+	socket := opts.SocketFactory(webwire.SocketOptions{
+		TLS:                  secure,
+		TLSConfig:            opts.TLSConfig,
+		Compression:          opts.Compression,
+		CompressionLevel:     opts.CompressionLevel,
+		CompressionThreshold: opts.CompressionThreshold,
+		PollingFallback:      opts.PollingFallback,
+	})
+
 	// Initialize new client
 	newClt := &client{
+		serverAddrLock:    sync.RWMutex{},
 		serverAddr:        serverAddress,
+		secure:            secure,
+		tlsConfig:         opts.TLSConfig,
 		impl:              implementation,
 		sessionInfoParser: opts.SessionInfoParser,
 		status:            Disconnected,
@@ -42,16 +65,19 @@ func NewClient(
 		autoconnect:       autoconnect,
 		sessionLock:       sync.RWMutex{},
 		session:           nil,
+		subscriptionsLock: sync.RWMutex{},
+		subscriptions:     make(map[string]PublicationHandler),
 		apiLock:           sync.RWMutex{},
 		backReconn:        newDam(),
 		connecting:        false,
 		connectingLock:    sync.RWMutex{},
 		connectLock:       sync.Mutex{},
-		conn:              webwire.NewSocket(),
+		conn:              socket,
 		readerClosing:     make(chan bool, 1),
 		requestManager:    reqman.NewRequestManager(),
-		warningLog:        opts.WarnLog,
-		errorLog:          opts.ErrorLog,
+		propagator:        opts.Propagator,
+		metrics:           opts.Metrics,
+		logger:            opts.Logger,
 	}
 
 	if autoconnect == autoconnectEnabled {
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"crypto/tls"
 	"log"
 	"os"
 	"time"
@@ -33,11 +34,58 @@ type Options struct {
 	// If undefined then the default value of 2 seconds is applied
 	ReconnectionInterval time.Duration
 
+	// TLSConfig defines the optional TLS configuration used to connect
+	// to the server over TLS. It allows specifying custom root CAs
+	// and client certificates. Server addresses without a scheme
+	// are dialed over TLS if it's defined, otherwise the "wss://"
+	// scheme must be used to enable TLS with the default configuration
+	TLSConfig *tls.Config
+
+	// SocketFactory defines the optional factory of the socket
+	// used to connect to the server replacing the default
+	// WebSocket transport
+	SocketFactory webwire.SocketFactory
+
+	// PollingFallback defines whether the default socket falls back
+	// to HTTP long polling if the WebSocket connection can't be
+	// established, for example because a proxy blocks the upgrade.
+	// Long polling must be enabled on the server.
+	// Enabled by default
+	PollingFallback webwire.OptionValue
+
+	// Compression enables the negotiation of permessage-deflate
+	// compression with the server. Disabled by default
+	Compression webwire.OptionValue
+
+	// CompressionLevel defines the flate compression level
+	// ranging from -2 (flate.HuffmanOnly) to 9 (flate.BestCompression).
+	// Zero is treated as unset and applies the default level 1
+	// (flate.BestSpeed) rather than flate.NoCompression,
+	// compression is disabled by the Compression option instead
+	CompressionLevel int
+
+	// CompressionThreshold defines the minimum size in bytes
+	// of compressed messages, smaller messages are sent uncompressed
+	CompressionThreshold int
+
+	// Propagator defines the optional propagator injecting
+	// the values carried by the request and signal contexts
+	// into the message headers
+	Propagator webwire.Propagator
+
+	// Metrics defines the observer of the client metrics.
+	// Metrics aren't collected if it's nil
+	Metrics Metrics
+
 	// WarnLog defines the warn logging output target
 	WarnLog *log.Logger
 
 	// ErrorLog defines the error logging output target
 	ErrorLog *log.Logger
+
+	// Logger defines the structured logger used by the client.
+	// If undefined then WarnLog and ErrorLog are used through a StdLogger
+	Logger webwire.Logger
 }
 
 // SetDefaults sets default values for undefined required options
@@ -58,6 +106,18 @@ func (opts *Options) SetDefaults() {
 		opts.ReconnectionInterval = 2 * time.Second
 	}
 
+	if opts.PollingFallback == webwire.OptionUnset {
+		opts.PollingFallback = webwire.Enabled
+	}
+
+	if opts.SocketFactory == nil {
+		opts.SocketFactory = webwire.NewSocketWithOptions
+	}
+
+	if opts.Metrics == nil {
+		opts.Metrics = NopMetrics{}
+	}
+
 	// Create default loggers to std-out/err when no loggers are specified
 	if opts.WarnLog == nil {
 		opts.WarnLog = log.New(
@@ -73,4 +133,7 @@ func (opts *Options) SetDefaults() {
 			log.Ldate|log.Ltime|log.Lshortfile,
 		)
 	}
+	if opts.Logger == nil {
+		opts.Logger = webwire.NewStdLogger(opts.WarnLog, opts.ErrorLog)
+	}
 }
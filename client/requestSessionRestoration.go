@@ -47,8 +47,9 @@ func (clt *client) requestSessionRestoration(sessionKey []byte) (
 	}
 
 	return &webwire.Session{
-		Key:      encodedSessionObj.Key,
-		Creation: encodedSessionObj.Creation,
-		Info:     decodedInfo,
+		Key:        encodedSessionObj.Key,
+		Creation:   encodedSessionObj.Creation,
+		Expiration: encodedSessionObj.Expiration,
+		Info:       decodedInfo,
 	}, nil
 }
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"sync/atomic"
+
+	webwire "github.com/qbeon/webwire-go"
+	msg "github.com/qbeon/webwire-go/message"
+	pld "github.com/qbeon/webwire-go/payload"
+)
+
+// requestSubscription sends a topic subscription request
+// and awaits its confirmation.
+// Expects the client to be connected beforehand
+func (clt *client) requestSubscription(topic string) error {
+	_, err := clt.sendNamelessRequest(
+		context.Background(),
+		msg.MsgSubscribe,
+		pld.Payload{
+			Encoding: webwire.EncodingBinary,
+			Data:     []byte(topic),
+		},
+		clt.defaultReqTimeout,
+	)
+	return err
+}
+
+// restoreSubscriptions renews all topic subscriptions after reconnection.
+// Subscriptions rejected by the server are dropped.
+// Expects the client to be connected beforehand
+func (clt *client) restoreSubscriptions() {
+	if atomic.LoadInt32(&clt.legacyServer) == 1 {
+		return
+	}
+
+	clt.subscriptionsLock.RLock()
+	topics := make([]string, 0, len(clt.subscriptions))
+	for topic := range clt.subscriptions {
+		topics = append(topics, topic)
+	}
+	clt.subscriptionsLock.RUnlock()
+
+	for _, topic := range topics {
+		err := clt.requestSubscription(topic)
+		if err == nil {
+			continue
+		}
+		clt.logger.Warn(
+			"Couldn't restore subscription",
+			clt.logFields("topic", topic, "error", err)...,
+		)
+
+		// Drop rejected subscriptions, keep the others
+		// to retry on the next reconnection
+		if _, rejected := err.(webwire.ReqErr); rejected {
+			clt.subscriptionsLock.Lock()
+			delete(clt.subscriptions, topic)
+			clt.subscriptionsLock.Unlock()
+		}
+	}
+}
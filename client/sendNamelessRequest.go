@@ -30,5 +30,5 @@ func (clt *client) sendNamelessRequest(
 	}
 
 	// Block until request either times out or a response is received
-	return request.AwaitReply(ctx)
+	return webwire.TranslateReply(request.AwaitReply(ctx))
 }
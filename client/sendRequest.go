@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	webwire "github.com/qbeon/webwire-go"
@@ -13,6 +14,7 @@ func (clt *client) sendRequest(
 	ctx context.Context,
 	messageType byte,
 	name string,
+	headers map[string]string,
 	payload webwire.Payload,
 	timeout time.Duration,
 ) (webwire.Payload, error) {
@@ -33,6 +35,11 @@ func (clt *client) sendRequest(
 	default:
 	}
 
+	headers, err := clt.messageHeaders(ctx, headers)
+	if err != nil {
+		return nil, err
+	}
+
 	payloadEncoding := webwire.EncodingBinary
 	var payloadData []byte
 	if payload != nil {
@@ -40,21 +47,46 @@ func (clt *client) sendRequest(
 		payloadData = payload.Data()
 	}
 
+	start := time.Now()
+
 	// Compose a message and register it
 	request := clt.requestManager.Create(timeout)
 	reqIdentifier := request.Identifier()
-	msg := msg.NewRequestMessage(
+	msg := msg.NewHeadersMessage(headers, msg.NewRequestMessage(
 		reqIdentifier,
 		name,
 		payloadEncoding,
 		payloadData,
-	)
+	))
 
 	// Send request
 	if err := clt.conn.Write(msg); err != nil {
-		return nil, webwire.NewReqTransErr(err)
+		err := webwire.NewReqTransErr(err)
+		clt.metrics.RequestCompleted(name, time.Since(start), err)
+		return nil, err
 	}
 
 	// Block until request either times out or a response is received
-	return request.AwaitReply(ctx)
+	reply, err := webwire.TranslateReply(request.AwaitReply(ctx))
+	if webwire.IsTimeoutErr(err) || webwire.IsCanceledErr(err) {
+		clt.cancelRequest(reqIdentifier)
+	}
+	clt.metrics.RequestCompleted(name, time.Since(start), err)
+	return reply, err
+}
+
+// cancelRequest notifies the server about the cancelation
+// of an abandoned request unless the server runs the legacy protocol
+func (clt *client) cancelRequest(reqIdentifier [8]byte) {
+	if atomic.LoadInt32(&clt.legacyServer) == 1 {
+		return
+	}
+	if err := clt.conn.Write(
+		msg.NewCancelRequestMessage(reqIdentifier),
+	); err != nil {
+		clt.logger.Warn(
+			"Couldn't send request cancelation",
+			clt.logFields("error", err)...,
+		)
+	}
 }
@@ -5,28 +5,68 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/qbeon/webwire-go"
 )
 
-// verifyProtocolVersion requests the endpoint metadata
+// verifyProtocolVersion reads the endpoint metadata
 // to verify the server is running a supported protocol version
 func (clt *client) verifyProtocolVersion() error {
+	var metadata webwire.EndpointMetadata
+	var err error
+	if reader, ok := clt.conn.(webwire.MetadataReader); ok {
+		metadata, err = reader.ReadMetadata(clt.address())
+	} else {
+		metadata, err = clt.requestMetadata()
+	}
+	if err != nil {
+		return err
+	}
+
+	// Verify metadata
+	switch metadata.ProtocolVersion {
+	case supportedProtocolVersion:
+		atomic.StoreInt32(&clt.legacyServer, 0)
+	case legacyProtocolVersion:
+		// Fall back to the legacy protocol version
+		atomic.StoreInt32(&clt.legacyServer, 1)
+	default:
+		return webwire.NewConnIncompErr(metadata.ProtocolVersion, supportedProtocolVersion)
+	}
+
+	return nil
+}
+
+// requestMetadata requests the endpoint metadata over HTTP
+func (clt *client) requestMetadata() (webwire.EndpointMetadata, error) {
+	var metadata webwire.EndpointMetadata
+
 	// Initialize HTTP client
 	var httpClient = &http.Client{
 		Timeout: time.Second * 10,
 	}
+	scheme := "http://"
+	if clt.secure {
+		scheme = "https://"
+		httpClient.Transport = &http.Transport{
+			Proxy:             http.ProxyFromEnvironment,
+			TLSClientConfig:   clt.tlsConfig,
+			DisableKeepAlives: true,
+		}
+	}
 
 	request, err := http.NewRequest(
-		"WEBWIRE", "http://"+clt.serverAddr+"/", nil,
+		"WEBWIRE", scheme+clt.address()+"/", nil,
 	)
 	if err != nil {
 		panic(fmt.Errorf("Couldn't create HTTP metadata request: %s", err))
 	}
+	request.Header.Set(webwire.ProtocolVersionHeader, supportedProtocolVersion)
 	response, err := httpClient.Do(request)
 	if err != nil {
-		return webwire.NewDisconnectedErr(fmt.Errorf(
+		return metadata, webwire.NewDisconnectedErr(fmt.Errorf(
 			"Endpoint metadata request failed: %s", err,
 		))
 	}
@@ -35,29 +75,21 @@ func (clt *client) verifyProtocolVersion() error {
 	defer response.Body.Close()
 	encodedData, err := ioutil.ReadAll(response.Body)
 	if err != nil {
-		return webwire.NewProtocolErr(fmt.Errorf("Couldn't read metadata response body: %s", err))
+		return metadata, webwire.NewProtocolErr(fmt.Errorf("Couldn't read metadata response body: %s", err))
 	}
 
 	if response.StatusCode == http.StatusServiceUnavailable {
-		return webwire.NewDisconnectedErr(fmt.Errorf("Endpoint unavailable: %s", response.Status))
+		return metadata, webwire.NewDisconnectedErr(fmt.Errorf("Endpoint unavailable: %s", response.Status))
 	}
 
 	// Unmarshal response
-	var metadata struct {
-		ProtocolVersion string `json:"protocol-version"`
-	}
 	if err := json.Unmarshal(encodedData, &metadata); err != nil {
-		return webwire.NewProtocolErr(fmt.Errorf(
+		return metadata, webwire.NewProtocolErr(fmt.Errorf(
 			"Couldn't parse HTTP metadata response ('%s'): %s",
 			string(encodedData),
 			err,
 		))
 	}
 
-	// Verify metadata
-	if metadata.ProtocolVersion != supportedProtocolVersion {
-		return webwire.NewConnIncompErr(metadata.ProtocolVersion, supportedProtocolVersion)
-	}
-
-	return nil
+	return metadata, nil
 }
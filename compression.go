@@ -0,0 +1,35 @@
+package webwire
+
+import (
+	"net/http"
+	"strings"
+)
+
+// compressedSocket defines the interface of sockets
+// capable of reporting whether compression is active
+type compressedSocket interface {
+	isCompressed() bool
+}
+
+// isSocketCompressed returns true if compression is active on the socket.
+// Returns false if the socket implementation doesn't report compression
+func isSocketCompressed(sock Socket) bool {
+	if reporter, ok := sock.(compressedSocket); ok {
+		return reporter.isCompressed()
+	}
+	return false
+}
+
+// offersCompression returns true if the given handshake request headers
+// offer the permessage-deflate extension
+func offersCompression(header http.Header) bool {
+	for _, value := range header["Sec-Websocket-Extensions"] {
+		for _, extension := range strings.Split(value, ",") {
+			name := strings.SplitN(extension, ";", 2)[0]
+			if strings.TrimSpace(name) == "permessage-deflate" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -1,6 +1,9 @@
 package webwire
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -8,6 +11,7 @@ import (
 	"time"
 
 	msg "github.com/qbeon/webwire-go/message"
+	reqman "github.com/qbeon/webwire-go/requestManager"
 )
 
 type connectionStatus = int32
@@ -22,10 +26,32 @@ type ClientInfo struct {
 	ConnectionTime time.Time
 	UserAgent      string
 	RemoteAddr     net.Addr
+
+	// TLS holds the state of the TLS connection
+	// if the client connected over TLS, otherwise it's nil
+	TLS *tls.ConnectionState
+
+	// PeerCertificate is the certificate the client authenticated with
+	// during the TLS handshake, it's nil if the client didn't present one
+	PeerCertificate *x509.Certificate
+
+	// Compressed is true if permessage-deflate compression
+	// was negotiated on the connection
+	Compressed bool
+}
+
+// setTLS sets the TLS connection state
+// and the client certificate if any
+func (info *ClientInfo) setTLS(state *tls.ConnectionState) {
+	info.TLS = state
+	if state != nil && len(state.PeerCertificates) > 0 {
+		info.PeerCertificate = state.PeerCertificates[0]
+	}
 }
 
 // connection represents a connected client connected to the server
 type connection struct {
+	id          uint64
 	statLock    sync.RWMutex
 	stat        connectionStatus
 	tasks       int32
@@ -34,6 +60,25 @@ type connection struct {
 	sessionLock sync.RWMutex
 	session     *Session
 	info        ClientInfo
+
+	// ctx is the parent context of all handlers executed on behalf of this
+	// connection, it's canceled when the connection is closed
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// requests maps the identifiers of the requests currently either queued
+	// or being handled to their cancelable handler contexts
+	requestsLock sync.Mutex
+	requests     map[[8]byte]pendingRequest
+
+	// requestManager keeps track of the requests sent to the client
+	requestManager reqman.RequestManager
+
+	// queue keeps the messages awaiting ordered processing
+	queue *messageQueue
+
+	// legacy is true if the client runs the legacy protocol version
+	legacy bool
 }
 
 // newConnection creates and returns a new client connection instance
@@ -44,13 +89,23 @@ func newConnection(
 ) *connection {
 	var remoteAddr net.Addr
 	stat := statInactive
+	compressed := false
 
 	if socket != nil {
 		stat = statActive
 		remoteAddr = socket.RemoteAddr()
+		compressed = isSocketCompressed(socket)
+	}
+
+	// Derive the connection context from the server context
+	// to cancel it when the server begins shutting down
+	parentCtx := context.Background()
+	if srv != nil && srv.ctx != nil {
+		parentCtx = srv.ctx
 	}
+	ctx, cancel := context.WithCancel(parentCtx)
 
-	return &connection{
+	newCon := &connection{
 		statLock:    sync.RWMutex{},
 		stat:        stat,
 		tasks:       0,
@@ -59,11 +114,24 @@ func newConnection(
 		sessionLock: sync.RWMutex{},
 		session:     nil,
 		info: ClientInfo{
-			time.Now(),
-			userAgent,
-			remoteAddr,
+			ConnectionTime: time.Now(),
+			UserAgent:      userAgent,
+			RemoteAddr:     remoteAddr,
+			Compressed:     compressed,
 		},
+		cancel:         cancel,
+		requests:       make(map[[8]byte]pendingRequest),
+		requestManager: reqman.NewRequestManager(),
+		queue:          newMessageQueue(),
 	}
+	newCon.ctx = context.WithValue(ctx, ctxKeyConnection, Connection(newCon))
+
+	return newCon
+}
+
+// ID implements the Connection interface
+func (con *connection) ID() uint64 {
+	return con.id
 }
 
 // IsActive implements the Connection interface
@@ -108,12 +176,20 @@ func (con *connection) setSession(newSess *Session) {
 // unlink resets the connection and marks it as disconnected
 // preparing it for garbage collection
 func (con *connection) unlink() {
+	// Cancel the contexts of all handlers still running
+	con.cancel()
+
 	// Deregister session from active sessions registry
+	con.srv.sessionOpsLock.Lock()
 	con.srv.sessionRegistry.deregister(con)
+	con.setSession(nil)
+	con.srv.sessionOpsLock.Unlock()
 
-	con.sessionLock.Lock()
-	con.session = nil
-	con.sessionLock.Unlock()
+	// Remove all topic subscriptions
+	con.srv.topicRegistry.unsubscribeAll(con)
+
+	// Deregister the connection from the connection registry
+	con.srv.connectionRegistry.deregister(con)
 
 	con.statLock.Lock()
 	con.stat = statInactive
@@ -137,8 +213,92 @@ func (con *connection) Signal(name string, payload Payload) error {
 	))
 }
 
+// Request implements the Connection interface
+func (con *connection) Request(
+	ctx context.Context,
+	name string,
+	payload Payload,
+) (Payload, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// Require either a name or a payload or both
+	if len(name) < 1 && (payload == nil || len(payload.Data()) < 1) {
+		return nil, NewProtocolErr(
+			fmt.Errorf("Invalid request, request message requires " +
+				"either a name, a payload or both but is missing both",
+			),
+		)
+	}
+
+	if con.legacy {
+		return nil, NewProtocolErr(fmt.Errorf(
+			"Requests are not supported by protocol version %s",
+			legacyProtocolVersion,
+		))
+	}
+
+	if !con.IsActive() {
+		return nil, NewDisconnectedErr(
+			fmt.Errorf("Can't send request on closed connection"),
+		)
+	}
+
+	payloadEncoding := EncodingBinary
+	var payloadData []byte
+	if payload != nil {
+		payloadEncoding = payload.Encoding()
+		payloadData = payload.Data()
+	}
+
+	// Compose a message and register it
+	request := con.requestManager.Create(
+		con.srv.options.DefaultRequestTimeout,
+	)
+	if err := con.sock.Write(msg.NewRequestMessage(
+		request.Identifier(),
+		name,
+		payloadEncoding,
+		payloadData,
+	)); err != nil {
+		return nil, NewReqTransErr(err)
+	}
+
+	// Stop awaiting the reply when the connection is closed
+	awaitCtx, cancelAwait := context.WithCancel(ctx)
+	defer cancelAwait()
+	go func() {
+		select {
+		case <-con.ctx.Done():
+			cancelAwait()
+		case <-awaitCtx.Done():
+		}
+	}()
+
+	// Block until request either times out or a response is received
+	reply, err := request.AwaitReply(awaitCtx)
+	if err != nil && ctx.Err() == nil && con.ctx.Err() != nil {
+		return nil, NewDisconnectedErr(fmt.Errorf(
+			"Connection closed before the reply was received",
+		))
+	}
+	return TranslateReply(reply, err)
+}
+
 // CreateSession implements the Connection interface
 func (con *connection) CreateSession(attachment SessionInfo) error {
+	return con.CreateSessionWithExpiration(
+		attachment,
+		con.srv.options.SessionExpiration,
+	)
+}
+
+// CreateSessionWithExpiration implements the Connection interface
+func (con *connection) CreateSessionWithExpiration(
+	attachment SessionInfo,
+	expiration SessionExpiration,
+) error {
 	if !con.srv.sessionsEnabled {
 		return SessionsDisabledErr{}
 	}
@@ -151,6 +311,9 @@ func (con *connection) CreateSession(attachment SessionInfo) error {
 		}
 	}
 
+	con.srv.sessionOpsLock.Lock()
+	defer con.srv.sessionOpsLock.Unlock()
+
 	con.sessionLock.Lock()
 
 	// Abort if there's already another active session
@@ -164,6 +327,7 @@ func (con *connection) CreateSession(attachment SessionInfo) error {
 
 	// Create a new session
 	newSession := NewSession(attachment, con.srv.sessionKeyGen.Generate)
+	newSession.Expiration = expiration
 
 	// Try to notify about session creation
 	if err := con.notifySessionCreated(&newSession); err != nil {
@@ -177,37 +341,57 @@ func (con *connection) CreateSession(attachment SessionInfo) error {
 	con.srv.sessionRegistry.register(con)
 	con.sessionLock.Unlock()
 
+	con.srv.metrics.SessionCreated()
+
 	// Call session creation hook
 	if err := con.srv.sessionManager.OnSessionCreated(con); err != nil {
-		con.srv.errorLog.Printf("OnSessionCreated hook failed: %s", err)
+		con.srv.logger.Error(
+			"OnSessionCreated hook failed",
+			con.logFields("error", err)...,
+		)
 	}
 
 	return nil
 }
 
 func (con *connection) notifySessionCreated(newSession *Session) error {
+	return con.writeSession(msg.MsgSessionCreated, newSession)
+}
+
+func (con *connection) notifySessionUpdated(session *Session) error {
+	if con.legacy {
+		// Legacy clients don't support session updates
+		return nil
+	}
+	return con.writeSession(msg.MsgSessionUpdated, session)
+}
+
+// writeSession writes a session notification message of the given type
+// carrying the JSON encoded session
+func (con *connection) writeSession(msgType byte, session *Session) error {
 	// Serialize session info
 	var sessionInfo map[string]interface{}
-	if newSession.Info != nil {
+	if session.Info != nil {
 		sessionInfo = make(map[string]interface{})
-		for _, field := range newSession.Info.Fields() {
-			sessionInfo[field] = newSession.Info.Value(field)
+		for _, field := range session.Info.Fields() {
+			sessionInfo[field] = session.Info.Value(field)
 		}
 	}
 
 	encoded, err := json.Marshal(JSONEncodedSession{
-		newSession.Key,
-		newSession.Creation,
-		newSession.LastLookup,
-		sessionInfo,
+		Key:        session.Key,
+		Creation:   session.Creation,
+		LastLookup: session.LastLookup,
+		Expiration: session.Expiration,
+		Info:       sessionInfo,
 	})
 	if err != nil {
 		return fmt.Errorf("Couldn't marshal session object: %s", err)
 	}
 
-	// Notify client about the session creation
+	// Notify client about the session
 	message := make([]byte, 1+len(encoded))
-	message[0] = msg.MsgSessionCreated
+	message[0] = msgType
 
 	for i := 0; i < len(encoded); i++ {
 		message[1+i] = encoded[i]
@@ -215,9 +399,15 @@ func (con *connection) notifySessionCreated(newSession *Session) error {
 	return con.sock.Write(message)
 }
 
-func (con *connection) notifySessionClosed() error {
+func (con *connection) notifySessionClosed(reason SessionCloseReason) error {
+	message := msg.NewSessionClosedMessage(byte(reason))
+	if con.legacy {
+		// Legacy clients don't support closure reasons
+		message = message[:msg.MsgMinLenSessionClosed]
+	}
+
 	// Notify client about the session destruction
-	if err := con.sock.Write([]byte{msg.MsgSessionClosed}); err != nil {
+	if err := con.sock.Write(message); err != nil {
 		return fmt.Errorf(
 			"Couldn't notify client about the session destruction: %s",
 			err,
@@ -232,6 +422,9 @@ func (con *connection) CloseSession() error {
 		return SessionsDisabledErr{}
 	}
 
+	con.srv.sessionOpsLock.Lock()
+	defer con.srv.sessionOpsLock.Unlock()
+
 	con.sessionLock.Lock()
 	if con.session == nil {
 		con.sessionLock.Unlock()
@@ -242,7 +435,70 @@ func (con *connection) CloseSession() error {
 	con.session = nil
 	con.sessionLock.Unlock()
 
-	return con.notifySessionClosed()
+	con.srv.metrics.SessionClosed()
+
+	return con.notifySessionClosed(SessionCloseServer)
+}
+
+// UpdateSessionInfo implements the Connection interface
+func (con *connection) UpdateSessionInfo(info SessionInfo) error {
+	if !con.srv.sessionsEnabled {
+		return SessionsDisabledErr{}
+	}
+	sessionKey := con.SessionKey()
+	if sessionKey == "" {
+		return fmt.Errorf("Can't update session info, no active session")
+	}
+	return con.srv.UpdateSessionInfo(sessionKey, info)
+}
+
+// RotateSessionKey implements the Connection interface
+func (con *connection) RotateSessionKey() error {
+	if !con.srv.sessionsEnabled {
+		return SessionsDisabledErr{}
+	}
+	sessionKey := con.SessionKey()
+	if sessionKey == "" {
+		return fmt.Errorf("Can't rotate session key, no active session")
+	}
+	_, err := con.srv.rotateSessionKey(sessionKey)
+	return err
+}
+
+// updateSessionInfo replaces the info of the session of this connection
+// if it's the session identified by the given key.
+// Returns a copy of the updated session
+// or nil if the connection isn't attached to the given session
+func (con *connection) updateSessionInfo(
+	sessionKey string,
+	info SessionInfo,
+) *Session {
+	con.sessionLock.Lock()
+	defer con.sessionLock.Unlock()
+	if con.session == nil || con.session.Key != sessionKey {
+		return nil
+	}
+	updated := con.session.Clone()
+	updated.Info = nil
+	if info != nil {
+		updated.Info = info.Copy()
+	}
+	con.session = updated
+	return updated.Clone()
+}
+
+// detachSession resets the session of this connection and deregisters it
+// from the session registry if it's the session identified by the given key.
+// Returns false if the connection isn't attached to the given session
+func (con *connection) detachSession(sessionKey string) bool {
+	con.sessionLock.Lock()
+	defer con.sessionLock.Unlock()
+	if con.session == nil || con.session.Key != sessionKey {
+		return false
+	}
+	con.srv.sessionRegistry.deregister(con)
+	con.session = nil
+	return true
 }
 
 // HasSession implements the Connection interface
@@ -259,6 +515,23 @@ func (con *connection) Session() *Session {
 	return con.session.Clone()
 }
 
+// logFields returns the logging fields identifying the connection
+// followed by the given additional fields
+func (con *connection) logFields(fields ...interface{}) []interface{} {
+	remoteAddr := ""
+	if con.info.RemoteAddr != nil {
+		remoteAddr = con.info.RemoteAddr.String()
+	}
+	base := []interface{}{
+		"connection", con.id,
+		"remoteAddr", remoteAddr,
+	}
+	if sessionKey := con.SessionKey(); sessionKey != "" {
+		base = append(base, "session", sessionKey)
+	}
+	return append(base, fields...)
+}
+
 // SessionKey implements the Connection interface
 func (con *connection) SessionKey() string {
 	con.sessionLock.RLock()
@@ -289,6 +562,39 @@ func (con *connection) SessionInfo(name string) interface{} {
 	return con.session.Info.Value(name)
 }
 
+// Subscribe implements the Connection interface
+func (con *connection) Subscribe(topic string) error {
+	if err := verifyTopic(topic); err != nil {
+		return err
+	}
+	if !con.IsActive() {
+		return NewDisconnectedErr(
+			fmt.Errorf("Can't subscribe closed connection to topic"),
+		)
+	}
+	con.srv.topicRegistry.subscribe(con, topic)
+
+	// Revert the subscription if the connection was closed in the meantime
+	// to not leak it after unlinking
+	if !con.IsActive() {
+		con.srv.topicRegistry.unsubscribe(con, topic)
+		return NewDisconnectedErr(
+			fmt.Errorf("Can't subscribe closed connection to topic"),
+		)
+	}
+	return nil
+}
+
+// Unsubscribe implements the Connection interface
+func (con *connection) Unsubscribe(topic string) {
+	con.srv.topicRegistry.unsubscribe(con, topic)
+}
+
+// Subscriptions implements the Connection interface
+func (con *connection) Subscriptions() []string {
+	return con.srv.topicRegistry.connectionTopics(con)
+}
+
 // Close implements the Connection interface
 func (con *connection) Close() {
 	unlink := false
@@ -304,6 +610,10 @@ func (con *connection) Close() {
 	}
 	con.statLock.Unlock()
 
+	// Cancel the contexts of all currently running handlers immediately
+	// even though the actual unlinking is deferred until they return
+	con.cancel()
+
 	if unlink {
 		con.unlink()
 	}
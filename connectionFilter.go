@@ -0,0 +1,35 @@
+package webwire
+
+import (
+	"net"
+)
+
+// ConnectionFilter represents the type of a connection filter function
+// returning true for all connections that match the filter
+type ConnectionFilter func(Connection) bool
+
+// BySessionKey returns a connection filter matching all connections
+// of the session identified by the given key
+func BySessionKey(sessionKey string) ConnectionFilter {
+	return func(con Connection) bool {
+		return con.SessionKey() == sessionKey
+	}
+}
+
+// ByRemoteAddr returns a connection filter matching all connections
+// of the given remote address. The address may either be a full address
+// including the port or a host only, in the latter case
+// all connections of the host are matched
+func ByRemoteAddr(addr string) ConnectionFilter {
+	return func(con Connection) bool {
+		remoteAddr := con.Info().RemoteAddr
+		if remoteAddr == nil {
+			return false
+		}
+		if remoteAddr.String() == addr {
+			return true
+		}
+		host, _, err := net.SplitHostPort(remoteAddr.String())
+		return err == nil && host == addr
+	}
+}
@@ -0,0 +1,80 @@
+package webwire
+
+import (
+	"sync"
+)
+
+// connectionRegistry represents a thread safe registry
+// of all currently connected clients
+type connectionRegistry struct {
+	lock     sync.RWMutex
+	lastID   uint64
+	registry map[uint64]*connection
+}
+
+// newConnectionRegistry returns a new instance of a connection registry
+func newConnectionRegistry() *connectionRegistry {
+	return &connectionRegistry{
+		lock:     sync.RWMutex{},
+		lastID:   0,
+		registry: make(map[uint64]*connection),
+	}
+}
+
+// register assigns a new unique identifier to the given connection
+// and registers it
+func (reg *connectionRegistry) register(con *connection) {
+	reg.lock.Lock()
+	defer reg.lock.Unlock()
+	reg.lastID++
+	con.id = reg.lastID
+	reg.registry[con.id] = con
+}
+
+// deregister removes the given connection from the registry.
+// Does nothing if the connection isn't registered
+func (reg *connectionRegistry) deregister(con *connection) {
+	reg.lock.Lock()
+	defer reg.lock.Unlock()
+	if registered, exists := reg.registry[con.id]; exists && registered == con {
+		delete(reg.registry, con.id)
+	}
+}
+
+// connection returns the connection identified by the given identifier
+// or nil if there's no such connection
+func (reg *connectionRegistry) connection(id uint64) *connection {
+	reg.lock.RLock()
+	defer reg.lock.RUnlock()
+	return reg.registry[id]
+}
+
+// connectionsNum returns the number of currently registered connections
+func (reg *connectionRegistry) connectionsNum() int {
+	reg.lock.RLock()
+	defer reg.lock.RUnlock()
+	return len(reg.registry)
+}
+
+// connections returns a snapshot of all currently registered connections
+// matching the given filter. All connections are matched if filter is nil.
+// The filter is called outside the lock to allow it to access the registry
+func (reg *connectionRegistry) connections(filter ConnectionFilter) []*connection {
+	reg.lock.RLock()
+	list := make([]*connection, 0, len(reg.registry))
+	for _, con := range reg.registry {
+		list = append(list, con)
+	}
+	reg.lock.RUnlock()
+
+	if filter == nil {
+		return list
+	}
+	filtered := list[:0]
+	for _, con := range list {
+		if filter(con) {
+			filtered = append(filtered, con)
+		}
+	}
+	return filtered
+}
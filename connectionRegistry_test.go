@@ -0,0 +1,62 @@
+package webwire
+
+import "testing"
+
+// TestConnRegRegistration tests registration and deregistration
+func TestConnRegRegistration(t *testing.T) {
+	reg := newConnectionRegistry()
+	cltA := newConnection(nil, "", nil)
+	cltB := newConnection(nil, "", nil)
+
+	reg.register(cltA)
+	reg.register(cltB)
+
+	// Expect unique identifiers to be assigned
+	if cltA.ID() == 0 || cltA.ID() == cltB.ID() {
+		t.Fatalf("Expected unique identifiers, got: %d, %d", cltA.ID(), cltB.ID())
+	}
+
+	// Expect 2 registered connections
+	if reg.connectionsNum() != 2 {
+		t.Fatal("Expected connectionsNum to return 2")
+	}
+	if reg.connection(cltA.ID()) != cltA {
+		t.Fatal("Expected connection A to be found by its identifier")
+	}
+
+	reg.deregister(cltA)
+	reg.deregister(cltA)
+
+	// Expect only connection B to remain
+	if reg.connectionsNum() != 1 {
+		t.Fatal("Expected connectionsNum to return 1")
+	}
+	if reg.connection(cltA.ID()) != nil {
+		t.Fatal("Expected connection A to be removed")
+	}
+}
+
+// TestConnRegFilter tests filtering connections by session key
+func TestConnRegFilter(t *testing.T) {
+	reg := newConnectionRegistry()
+
+	cltA := newConnection(nil, "", nil)
+	sessA := NewSession(nil, func() string { return "testkey_A" })
+	cltA.session = &sessA
+
+	cltB := newConnection(nil, "", nil)
+
+	reg.register(cltA)
+	reg.register(cltB)
+
+	// Expect all connections to be returned without filter
+	if len(reg.connections(nil)) != 2 {
+		t.Fatal("Expected 2 connections without filter")
+	}
+
+	// Expect only connection A to match its session key
+	list := reg.connections(BySessionKey("testkey_A"))
+	if len(list) != 1 || list[0] != cltA {
+		t.Fatal("Expected only connection A to match the session key filter")
+	}
+}
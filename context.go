@@ -0,0 +1,145 @@
+package webwire
+
+import (
+	"context"
+
+	msg "github.com/qbeon/webwire-go/message"
+)
+
+// contextKey represents the type of the keys of the values
+// attached to handler contexts by the server
+type contextKey int
+
+const (
+	// ctxKeyConnection is the key of the connection
+	// the handled message was received on
+	ctxKeyConnection contextKey = iota
+
+	// ctxKeyRequestIdentifier is the key of the identifier
+	// of the handled request
+	ctxKeyRequestIdentifier
+
+	// ctxKeyTraceContext is the key of the propagated trace context
+	ctxKeyTraceContext
+
+	// ctxKeyPipeSocket is the key of the server side socket
+	// of a connection request dialed through a pipe transport
+	ctxKeyPipeSocket
+)
+
+// ConnectionFromContext returns the connection attached to the given
+// handler context. Returns false if there's no connection attached
+func ConnectionFromContext(ctx context.Context) (Connection, bool) {
+	con, ok := ctx.Value(ctxKeyConnection).(Connection)
+	return con, ok
+}
+
+// RequestIdentifierFromContext returns the identifier of the request
+// attached to the given handler context.
+// Returns false if the context doesn't belong to a request handler
+func RequestIdentifierFromContext(ctx context.Context) ([8]byte, bool) {
+	ident, ok := ctx.Value(ctxKeyRequestIdentifier).([8]byte)
+	return ident, ok
+}
+
+// pendingRequest represents a request registered for cancelation
+type pendingRequest struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// registerRequest registers the request identified by the given identifier
+// for cancelation by the client before it's queued for execution.
+// It's called by the goroutine reading the connection, a cancelation
+// can thus never be read before the request it refers to is registered
+func (con *connection) registerRequest(identifier [8]byte) {
+	con.requestsLock.Lock()
+	defer con.requestsLock.Unlock()
+
+	ctx, cancel := context.WithCancel(context.WithValue(
+		con.ctx,
+		ctxKeyRequestIdentifier,
+		identifier,
+	))
+	con.requests[identifier] = pendingRequest{ctx: ctx, cancel: cancel}
+}
+
+// requestContext returns the context of the registered request identified
+// by the given identifier.
+// Returns false if the request was canceled by the client in the meantime
+func (con *connection) requestContext(
+	identifier [8]byte,
+) (context.Context, bool) {
+	con.requestsLock.Lock()
+	request, registered := con.requests[identifier]
+	con.requestsLock.Unlock()
+	return request.ctx, registered
+}
+
+// requestCanceled returns true if the registered request identified by the
+// given identifier was canceled by the client while awaiting execution
+func (con *connection) requestCanceled(identifier [8]byte) bool {
+	_, registered := con.requestContext(identifier)
+	return !registered
+}
+
+// handlerContext returns a new context for the handler of the given message
+// derived from the connection context, which is canceled as soon as
+// either the connection is closed or the server begins shutting down.
+// The values carried by the message headers are extracted
+// by the propagator if any.
+// Request handler contexts are derived from the context registered by
+// registerRequest and must be released by releaseRequest
+func (con *connection) handlerContext(message *msg.Message) context.Context {
+	ctx := con.ctx
+	if isRequestMessage(message) {
+		if requestCtx, registered := con.requestContext(
+			message.Identifier,
+		); registered {
+			ctx = requestCtx
+		} else {
+			// The request was canceled in the meantime
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithCancel(context.WithValue(
+				ctx,
+				ctxKeyRequestIdentifier,
+				message.Identifier,
+			))
+			cancel()
+		}
+	}
+	if con.srv != nil && con.srv.options.Propagator != nil {
+		ctx = con.srv.options.Propagator.Extract(ctx, message.Headers)
+	}
+	return ctx
+}
+
+// cancelRequest cancels the context of the request identified by
+// the given identifier whether it's still queued or already being handled.
+// Returns false if there's no such request currently registered
+func (con *connection) cancelRequest(identifier [8]byte) bool {
+	con.requestsLock.Lock()
+	request, exists := con.requests[identifier]
+	delete(con.requests, identifier)
+	con.requestsLock.Unlock()
+	if !exists {
+		return false
+	}
+	request.cancel()
+	return true
+}
+
+// releaseRequest releases the context of the request
+// identified by the given identifier after it was handled or dropped.
+// Returns true if the request was canceled by the client in the meantime
+func (con *connection) releaseRequest(identifier [8]byte) (canceled bool) {
+	con.requestsLock.Lock()
+	request, exists := con.requests[identifier]
+	delete(con.requests, identifier)
+	con.requestsLock.Unlock()
+	if !exists {
+		return true
+	}
+	request.cancel()
+	return false
+}
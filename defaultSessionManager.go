@@ -6,13 +6,19 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 )
 
+// sessionFileExt defines the file extension of default session files
+const sessionFileExt = ".wwrsess"
+
 // SessionFile represents the serialization structure of a default session file
 type SessionFile struct {
 	Creation   time.Time              `json:"c"`
 	LastLookup time.Time              `json:"l"`
+	Expiration SessionExpiration      `json:"x"`
 	Info       map[string]interface{} `json:"i"`
 }
 
@@ -40,15 +46,54 @@ func (sessf *SessionFile) Save(filePath string) error {
 	return nil
 }
 
+// DefaultSessionManagerOptions represents the options
+// of the default session manager
+type DefaultSessionManagerOptions struct {
+	// Path defines the session directory.
+	// The wwrsess directory next to the executable is used by default
+	Path string
+
+	// Expiration defines the expiration policy applied to sessions
+	// that don't define their own, which means that sessions
+	// without an expiration policy never expire by default
+	Expiration SessionExpiration
+
+	// SweepInterval defines the interval at which the background sweeper
+	// deletes the files of expired sessions.
+	// The sweeper is disabled if the interval is zero
+	SweepInterval time.Duration
+}
+
 // DefaultSessionManager represents a default session manager implementation.
 // It uses files as a persistent storage
 type DefaultSessionManager struct {
-	path string
+	path       string
+	expiration SessionExpiration
+	stop       chan struct{}
+	stopOnce   sync.Once
+	sweeping   sync.WaitGroup
+
+	// lock synchronizes the access to the session files
+	// and the set of active sessions
+	lock   sync.Mutex
+	active map[string]struct{}
 }
 
 // NewDefaultSessionManager constructs a new default session manager instance.
 // Verifies the existence of the given session directory and creates it if it doesn't exist yet
 func NewDefaultSessionManager(sessFilesPath string) *DefaultSessionManager {
+	return NewDefaultSessionManagerWithOptions(DefaultSessionManagerOptions{
+		Path: sessFilesPath,
+	})
+}
+
+// NewDefaultSessionManagerWithOptions constructs a new default session
+// manager instance starting the background sweeper if enabled.
+// Close must be called to stop the sweeper
+func NewDefaultSessionManagerWithOptions(
+	opts DefaultSessionManagerOptions,
+) *DefaultSessionManager {
+	sessFilesPath := opts.Path
 	if len(sessFilesPath) < 1 {
 		// Use the current directory as parent of the session directory by default
 		var err error
@@ -81,23 +126,151 @@ func NewDefaultSessionManager(sessFilesPath string) *DefaultSessionManager {
 		))
 	}
 
-	return &DefaultSessionManager{
-		path: sessFilesPath,
+	mng := &DefaultSessionManager{
+		path:       sessFilesPath,
+		expiration: opts.Expiration,
+		stop:       make(chan struct{}),
+		active:     make(map[string]struct{}),
+	}
+
+	if opts.SweepInterval > 0 {
+		mng.sweeping.Add(1)
+		go mng.sweeper(opts.SweepInterval)
 	}
+
+	return mng
 }
 
 // filePath generates an absolute session file path given the session key
 func (mng *DefaultSessionManager) filePath(sessionKey string) string {
-	return filepath.Join(mng.path, sessionKey+".wwrsess")
+	return filepath.Join(mng.path, sessionKey+sessionFileExt)
+}
+
+// expirationOf returns the expiration policy of the given session file
+// falling back to the default policy of the manager
+func (mng *DefaultSessionManager) expirationOf(
+	file *SessionFile,
+) SessionExpiration {
+	if file.Expiration.IsZero() {
+		return mng.expiration
+	}
+	return file.Expiration
+}
+
+// expired returns true if the given session file is expired.
+// Active sessions are in use and thus never idle.
+// The lock must be held by the caller
+func (mng *DefaultSessionManager) expired(
+	key string,
+	file *SessionFile,
+	now time.Time,
+) bool {
+	lastUse := file.LastLookup
+	if _, active := mng.active[key]; active {
+		lastUse = now
+	}
+	return mng.expirationOf(file).Expired(file.Creation, lastUse, now)
+}
+
+// sweeper periodically sweeps the session directory
+// until the manager is closed
+func (mng *DefaultSessionManager) sweeper(interval time.Duration) {
+	defer mng.sweeping.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			mng.Sweep()
+		case <-mng.stop:
+			return
+		}
+	}
+}
+
+// Sweep deletes the files of all expired inactive sessions
+// returning the number of deleted files.
+// Unreadable files are skipped, the returned error reports the first failure
+func (mng *DefaultSessionManager) Sweep() (int, error) {
+	entries, err := ioutil.ReadDir(mng.path)
+	if err != nil {
+		return 0, fmt.Errorf("Couldn't read session directory: %s", err)
+	}
+
+	now := time.Now()
+	deleted := 0
+	var firstErr error
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), sessionFileExt) {
+			continue
+		}
+		key := strings.TrimSuffix(entry.Name(), sessionFileExt)
+		removed, err := mng.sweepFile(key, now)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if removed {
+			deleted++
+		}
+	}
+	return deleted, firstErr
+}
+
+// sweepFile deletes the file of the given session if it's expired.
+// Returns true if the file was deleted
+func (mng *DefaultSessionManager) sweepFile(
+	key string,
+	now time.Time,
+) (bool, error) {
+	mng.lock.Lock()
+	defer mng.lock.Unlock()
+
+	path := mng.filePath(key)
+	var file SessionFile
+	if err := file.Parse(path); err != nil {
+		if _, statErr := os.Stat(path); os.IsNotExist(statErr) {
+			// Deleted in the meantime
+			return false, nil
+		}
+		return false, err
+	}
+	if !mng.expired(key, &file, now) {
+		return false, nil
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf(
+			"Couldn't delete expired session file: %s",
+			err,
+		)
+	}
+	return true, nil
+}
+
+// Close stops the background sweeper if it's running
+// and blocks until it's stopped.
+// Does nothing when called multiple times
+func (mng *DefaultSessionManager) Close() {
+	mng.stopOnce.Do(func() {
+		close(mng.stop)
+	})
+	mng.sweeping.Wait()
 }
 
 // OnSessionCreated implements the session manager interface.
 // It writes the created session into a file using the session key as file name
 func (mng *DefaultSessionManager) OnSessionCreated(conn Connection) error {
+	mng.lock.Lock()
+	defer mng.lock.Unlock()
+
 	sess := conn.Session()
 	sessFile := SessionFile{
 		Creation:   sess.Creation,
 		LastLookup: sess.LastLookup,
+		Expiration: sess.Expiration,
 		Info:       SessionInfoToVarMap(sess.Info),
 	}
 	return sessFile.Save(mng.filePath(conn.SessionKey()))
@@ -106,10 +279,14 @@ func (mng *DefaultSessionManager) OnSessionCreated(conn Connection) error {
 // OnSessionLookup implements the session manager interface.
 // It searches the session file directory for the session file and loads it.
 // It also updates the file by updating the last lookup session field.
+// Expired session files are deleted and reported as not found
 func (mng *DefaultSessionManager) OnSessionLookup(key string) (
 	SessionLookupResult,
 	error,
 ) {
+	mng.lock.Lock()
+	defer mng.lock.Unlock()
+
 	path := mng.filePath(key)
 
 	// Lookup session file
@@ -132,10 +309,23 @@ func (mng *DefaultSessionManager) OnSessionLookup(key string) (
 		)
 	}
 
+	expiration := mng.expirationOf(&file)
+	now := time.Now().UTC()
+	if mng.expired(key, &file, now) {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return SessionLookupResult{}, fmt.Errorf(
+				"Couldn't delete expired session file: %s",
+				err,
+			)
+		}
+		return SessionLookupResult{}, SessNotFoundErr{}
+	}
+
 	// Update last lookup
 	newSessionFile := SessionFile{
 		Creation:   file.Creation,
-		LastLookup: time.Now().UTC(),
+		LastLookup: now,
+		Expiration: file.Expiration,
 		Info:       file.Info,
 	}
 	if err := newSessionFile.Save(mng.filePath(key)); err != nil {
@@ -145,13 +335,110 @@ func (mng *DefaultSessionManager) OnSessionLookup(key string) (
 		)
 	}
 
-	return SessionLookupResult(file), nil
+	return SessionLookupResult{
+		Creation:   file.Creation,
+		LastLookup: file.LastLookup,
+		Expiration: expiration,
+		Info:       file.Info,
+	}, nil
+}
+
+// OnSessionUpdated implements the session manager interface.
+// It replaces the info of the according session file
+func (mng *DefaultSessionManager) OnSessionUpdated(
+	sessionKey string,
+	info SessionInfo,
+) error {
+	mng.lock.Lock()
+	defer mng.lock.Unlock()
+
+	path := mng.filePath(sessionKey)
+
+	var file SessionFile
+	if err := file.Parse(path); err != nil {
+		if _, statErr := os.Stat(path); os.IsNotExist(statErr) {
+			return SessNotFoundErr{}
+		}
+		return fmt.Errorf("Couldn't parse session file: %s", err)
+	}
+
+	file.Info = SessionInfoToVarMap(info)
+	if err := file.Save(path); err != nil {
+		return fmt.Errorf(
+			"Couldn't update session info, failed writing file: %s",
+			err,
+		)
+	}
+	return nil
+}
+
+// OnSessionKeyRotated implements the session manager interface.
+// It renames the according session file
+func (mng *DefaultSessionManager) OnSessionKeyRotated(
+	oldKey string,
+	newKey string,
+) error {
+	mng.lock.Lock()
+	defer mng.lock.Unlock()
+
+	oldPath := mng.filePath(oldKey)
+	if _, err := os.Stat(oldPath); os.IsNotExist(err) {
+		return SessNotFoundErr{}
+	}
+	if err := os.Rename(oldPath, mng.filePath(newKey)); err != nil {
+		return fmt.Errorf("Couldn't rename session file: %s", err)
+	}
+	if _, active := mng.active[oldKey]; active {
+		delete(mng.active, oldKey)
+		mng.active[newKey] = struct{}{}
+	}
+	return nil
+}
+
+// OnSessionActivityChanged implements the SessionActivityObserver interface.
+// It keeps track of the active sessions, which are never swept,
+// and updates the last lookup field of sessions becoming inactive
+func (mng *DefaultSessionManager) OnSessionActivityChanged(
+	sessionKey string,
+	active bool,
+) error {
+	mng.lock.Lock()
+	defer mng.lock.Unlock()
+
+	if active {
+		mng.active[sessionKey] = struct{}{}
+		return nil
+	}
+	delete(mng.active, sessionKey)
+
+	path := mng.filePath(sessionKey)
+	var file SessionFile
+	if err := file.Parse(path); err != nil {
+		if _, statErr := os.Stat(path); os.IsNotExist(statErr) {
+			return SessNotFoundErr{}
+		}
+		return fmt.Errorf("Couldn't parse session file: %s", err)
+	}
+
+	file.LastLookup = time.Now().UTC()
+	if err := file.Save(path); err != nil {
+		return fmt.Errorf(
+			"Couldn't update last lookup field, failed writing file: %s",
+			err,
+		)
+	}
+	return nil
 }
 
 // OnSessionClosed implements the session manager interface.
 // It closes the session by deleting the according session file
 func (mng *DefaultSessionManager) OnSessionClosed(sessionKey string) error {
-	if err := os.Remove(mng.filePath(sessionKey)); err != nil {
+	mng.lock.Lock()
+	defer mng.lock.Unlock()
+
+	if err := os.Remove(mng.filePath(sessionKey)); os.IsNotExist(err) {
+		return SessNotFoundErr{}
+	} else if err != nil {
 		return fmt.Errorf(
 			"Unexpected error during session destruction: %s",
 			err,
@@ -0,0 +1,193 @@
+package webwire
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestSessionExpirationExpired tests the absolute
+// and sliding session expiration policies
+func TestSessionExpirationExpired(t *testing.T) {
+	creation := time.Now()
+	lastLookup := creation.Add(time.Hour)
+
+	cases := []struct {
+		expiration SessionExpiration
+		now        time.Time
+		expired    bool
+	}{
+		{SessionExpiration{}, creation.Add(1000 * time.Hour), false},
+		{SessionExpiration{MaxAge: 2 * time.Hour}, lastLookup, false},
+		{SessionExpiration{MaxAge: 2 * time.Hour}, creation.Add(2 * time.Hour), true},
+		{SessionExpiration{IdleTimeout: time.Hour}, creation.Add(90 * time.Minute), false},
+		{SessionExpiration{IdleTimeout: time.Hour}, lastLookup.Add(time.Hour), true},
+		{
+			SessionExpiration{MaxAge: 90 * time.Minute, IdleTimeout: time.Hour},
+			creation.Add(100 * time.Minute),
+			true,
+		},
+	}
+
+	for i, c := range cases {
+		expired := c.expiration.Expired(creation, lastLookup, c.now)
+		if expired != c.expired {
+			t.Errorf("case %d: expected expired to be %t", i, c.expired)
+		}
+	}
+}
+
+// TestDefaultSessionManagerExpiry tests the lookup and sweeping
+// of expired session files
+func TestDefaultSessionManagerExpiry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wwrsess")
+	if err != nil {
+		t.Fatalf("Couldn't create session directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	mng := NewDefaultSessionManagerWithOptions(DefaultSessionManagerOptions{
+		Path:       dir,
+		Expiration: SessionExpiration{IdleTimeout: time.Hour},
+	})
+	defer mng.Close()
+
+	now := time.Now().UTC()
+	files := map[string]SessionFile{
+		// Expired according to the default policy of the manager
+		"idle": {
+			Creation:   now.Add(-3 * time.Hour),
+			LastLookup: now.Add(-2 * time.Hour),
+		},
+		// Expired according to its own policy
+		"old": {
+			Creation:   now.Add(-2 * time.Hour),
+			LastLookup: now,
+			Expiration: SessionExpiration{MaxAge: time.Hour},
+		},
+		// Not expired thanks to its own policy
+		"remembered": {
+			Creation:   now.Add(-3 * time.Hour),
+			LastLookup: now.Add(-2 * time.Hour),
+			Expiration: SessionExpiration{IdleTimeout: 24 * time.Hour},
+		},
+		"active": {
+			Creation:   now.Add(-3 * time.Hour),
+			LastLookup: now,
+		},
+	}
+	for key, file := range files {
+		if err := file.Save(mng.filePath(key)); err != nil {
+			t.Fatalf("Couldn't save session file: %s", err)
+		}
+	}
+
+	// Expect expired sessions to be reported as not found and deleted
+	if _, err := mng.OnSessionLookup("idle"); err != (SessNotFoundErr{}) {
+		t.Fatalf("Expected SessNotFoundErr, got: %v", err)
+	}
+	if _, err := os.Stat(mng.filePath("idle")); !os.IsNotExist(err) {
+		t.Fatalf("Expected the expired session file to be deleted")
+	}
+
+	result, err := mng.OnSessionLookup("remembered")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if result.Expiration.IdleTimeout != 24*time.Hour {
+		t.Fatalf("Unexpected expiration policy: %v", result.Expiration)
+	}
+
+	// Expect the sweeper to delete the remaining expired session only
+	deleted, err := mng.Sweep()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if deleted != 1 {
+		t.Fatalf("Expected 1 deleted session file, got: %d", deleted)
+	}
+	for key, exists := range map[string]bool{
+		"old":        false,
+		"remembered": true,
+		"active":     true,
+	} {
+		_, err := os.Stat(mng.filePath(key))
+		if exists != !os.IsNotExist(err) {
+			t.Errorf("Expected existence of %q to be %t", key, exists)
+		}
+	}
+}
+
+// TestDefaultSessionManagerSweeper tests the background sweeper
+func TestDefaultSessionManagerSweeper(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wwrsess")
+	if err != nil {
+		t.Fatalf("Couldn't create session directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	mng := NewDefaultSessionManagerWithOptions(DefaultSessionManagerOptions{
+		Path:          dir,
+		SweepInterval: 10 * time.Millisecond,
+	})
+	defer mng.Close()
+
+	file := SessionFile{
+		Creation:   time.Now().UTC().Add(-time.Hour),
+		Expiration: SessionExpiration{MaxAge: time.Minute},
+	}
+	if err := file.Save(mng.filePath("expired")); err != nil {
+		t.Fatalf("Couldn't save session file: %s", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(mng.filePath("expired")); os.IsNotExist(err) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the sweeper to delete the expired session file")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestDefaultSessionManagerUpdate tests updating the info of a session file
+func TestDefaultSessionManagerUpdate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wwrsess")
+	if err != nil {
+		t.Fatalf("Couldn't create session directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	mng := NewDefaultSessionManager(dir)
+
+	file := SessionFile{
+		Creation:   time.Now().UTC(),
+		LastLookup: time.Now().UTC(),
+		Info:       map[string]interface{}{"role": "user"},
+	}
+	if err := file.Save(mng.filePath("key")); err != nil {
+		t.Fatalf("Couldn't save session file: %s", err)
+	}
+
+	if err := mng.OnSessionUpdated(
+		"key",
+		GenericSessionInfoParser(map[string]interface{}{"role": "admin"}),
+	); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	result, err := mng.OnSessionLookup("key")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if role := result.Info["role"]; role != "admin" {
+		t.Fatalf("Expected the updated info to be persisted, got: %v", role)
+	}
+
+	if err := mng.OnSessionUpdated("inexistent", nil); err != (SessNotFoundErr{}) {
+		t.Fatalf("Expected SessNotFoundErr, got: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package webwire
+
+// detachSession closes the session identified by the given key on all of its
+// connections without closing the connections themselves,
+// notifies the clients about the closure reason and invokes
+// the OnSessionClosed session manager hook.
+// Returns the number of detached connections
+// or -1 without doing anything if the session isn't active
+func (srv *server) detachSession(
+	sessionKey string,
+	reason SessionCloseReason,
+) int {
+	srv.sessionOpsLock.Lock()
+	defer srv.sessionOpsLock.Unlock()
+
+	connections := srv.sessionRegistry.sessionConnections(sessionKey)
+	if connections == nil {
+		return -1
+	}
+
+	detached := 0
+	for _, con := range connections {
+		if !con.detachSession(sessionKey) {
+			continue
+		}
+		detached++
+		if err := con.notifySessionClosed(reason); err != nil {
+			srv.logger.Warn(
+				"Couldn't notify client about the session closure",
+				con.logFields(
+					"session", sessionKey,
+					"reason", reason,
+					"error", err,
+				)...,
+			)
+		}
+	}
+
+	srv.metrics.SessionClosed()
+
+	if err := srv.sessionManager.OnSessionClosed(sessionKey); err != nil {
+		srv.logger.Error(
+			"OnSessionClosed hook failed",
+			"session", sessionKey,
+			"error", err,
+		)
+	}
+	return detached
+}
+
+// sessionActivityChanged invokes the OnSessionActivityChanged hook
+// of the session manager if it implements the SessionActivityObserver
+// interface when a session becomes either active or inactive
+func (srv *server) sessionActivityChanged(sessionKey string, active bool) {
+	observer, isObserver := srv.sessionManager.(SessionActivityObserver)
+	if !isObserver {
+		return
+	}
+	if err := observer.OnSessionActivityChanged(
+		sessionKey,
+		active,
+	); err != nil {
+		if _, isSessNotFoundErr := err.(SessNotFoundErr); !isSessNotFoundErr {
+			srv.logger.Error(
+				"OnSessionActivityChanged hook failed",
+				"session", sessionKey,
+				"active", active,
+				"error", err,
+			)
+		}
+	}
+}
+
+// expireSession closes the active session identified by the given key
+// once its lifetime is over
+func (srv *server) expireSession(sessionKey string) {
+	srv.logger.Debug("Session expired", "session", sessionKey)
+	srv.detachSession(sessionKey, SessionCloseExpired)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -16,6 +17,9 @@ func (clt *ChatroomClient) OnSessionCreated(newSession *webwire.Session) {
 	log.Printf("Authenticated as %s", username)
 }
 
+// OnSessionUpdated implements the webwireClient.Implementation interface
+func (clt *ChatroomClient) OnSessionUpdated(_ *webwire.Session) {}
+
 // OnSignal implements the webwireClient.Implementation interface.
 // it's invoked when the client receives a signal from the server
 // containing a chatroom message
@@ -39,4 +43,16 @@ func (clt *ChatroomClient) OnSignal(message webwire.Payload) {
 func (clt *ChatroomClient) OnDisconnected() {}
 
 // OnSessionClosed implements the wwrclt.Implementation interface
-func (clt *ChatroomClient) OnSessionClosed() {}
+func (clt *ChatroomClient) OnSessionClosed(_ webwire.SessionCloseReason) {}
+
+// OnRequest implements the wwrclt.Implementation interface.
+// Rejects all requests, not needed in this example
+func (clt *ChatroomClient) OnRequest(
+	_ context.Context,
+	_ webwire.Message,
+) (webwire.Payload, error) {
+	return nil, webwire.ReqErr{
+		Code:    "REQ_NOT_SUPPORTED",
+		Message: "Requests are not supported by this client",
+	}
+}
@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	wwr "github.com/qbeon/webwire-go"
 )
@@ -44,7 +46,13 @@ func main() {
 	go func() {
 		sig := <-osSignals
 		log.Printf("Termination demanded by the OS (%s), shutting down...", sig)
-		if err := server.Shutdown(); err != nil {
+		// Abandon remaining handlers after 10 seconds
+		ctx, cancel := context.WithTimeout(
+			context.Background(),
+			10*time.Second,
+		)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
 			log.Printf("Error during server shutdown: %s", err)
 		}
 		log.Println("Server gracefully terminated")
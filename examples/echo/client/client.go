@@ -39,14 +39,29 @@ func NewEchoClient(serverAddr string) *EchoClient {
 func (clt *EchoClient) OnDisconnected() {}
 
 // OnSessionClosed implements the wwrclt.Implementation interface
-func (clt *EchoClient) OnSessionClosed() {}
+func (clt *EchoClient) OnSessionClosed(_ wwr.SessionCloseReason) {}
 
 // OnSessionCreated implements the wwrclt.Implementation interface
 func (clt *EchoClient) OnSessionCreated(_ *wwr.Session) {}
 
+// OnSessionUpdated implements the wwrclt.Implementation interface
+func (clt *EchoClient) OnSessionUpdated(_ *wwr.Session) {}
+
 // OnSignal implements the wwrclt.Implementation interface
 func (clt *EchoClient) OnSignal(_ wwr.Payload) {}
 
+// OnRequest implements the wwrclt.Implementation interface.
+// Rejects all requests, not needed in this example
+func (clt *EchoClient) OnRequest(
+	_ context.Context,
+	_ wwr.Message,
+) (wwr.Payload, error) {
+	return nil, wwr.ReqErr{
+		Code:    "REQ_NOT_SUPPORTED",
+		Message: "Requests are not supported by this client",
+	}
+}
+
 // Request sends a message to the server and returns the reply.
 // panics if the request fails for whatever reason
 func (clt *EchoClient) Request(
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"sync"
@@ -12,7 +13,7 @@ import (
 )
 
 var serverAddr = flag.String("addr", ":8081", "server address")
-var counterTarget = flag.Uint("n", 6, "number of signals to listen for")
+var counterTarget = flag.Uint("n", 6, "number of messages to listen for")
 
 // PubSubClient implements the wwrclt.Implementation interface
 type PubSubClient struct {
@@ -50,16 +51,23 @@ func NewPubSubClient(serverAddr string, counterTarget uint) *PubSubClient {
 func (clt *PubSubClient) OnDisconnected() {}
 
 // OnSessionClosed implements the wwrclt.Implementation interface
-func (clt *PubSubClient) OnSessionClosed() {}
+func (clt *PubSubClient) OnSessionClosed(_ wwr.SessionCloseReason) {}
 
 // OnSessionCreated implements the wwrclt.Implementation interface
 func (clt *PubSubClient) OnSessionCreated(_ *wwr.Session) {}
 
-// OnSignal implements the wwrclt.Implementation interface
-func (clt *PubSubClient) OnSignal(message wwr.Payload) {
+// OnSessionUpdated implements the wwrclt.Implementation interface
+func (clt *PubSubClient) OnSessionUpdated(_ *wwr.Session) {}
+
+// OnSignal implements the wwrclt.Implementation interface.
+// Does nothing, not needed in this example
+func (clt *PubSubClient) OnSignal(_ wwr.Payload) {}
+
+// onTime handles the messages published to the time topic
+func (clt *PubSubClient) onTime(message wwr.Payload) {
 	clt.counter++
 	log.Printf(
-		"Signal %d of %d received: %s",
+		"Message %d of %d received: %s",
 		clt.counter,
 		clt.target,
 		string(message.Data()),
@@ -67,6 +75,23 @@ func (clt *PubSubClient) OnSignal(message wwr.Payload) {
 	clt.targetReached.Done()
 }
 
+// OnRequest implements the wwrclt.Implementation interface.
+// Rejects all requests, not needed in this example
+func (clt *PubSubClient) OnRequest(
+	_ context.Context,
+	_ wwr.Message,
+) (wwr.Payload, error) {
+	return nil, wwr.ReqErr{
+		Code:    "REQ_NOT_SUPPORTED",
+		Message: "Requests are not supported by this client",
+	}
+}
+
+// Subscribe subscribes the client to the time topic
+func (clt *PubSubClient) Subscribe() error {
+	return clt.connection.Subscribe("time", clt.onTime)
+}
+
 // AwaitCounterTargetReached blocks the calling goroutine until the counter
 // target is reached
 func (clt *PubSubClient) AwaitCounterTargetReached() {
@@ -80,6 +105,10 @@ func main() {
 	// Initialize a new pub-sub client instance
 	client := NewPubSubClient(*serverAddr, *counterTarget)
 
-	// Wait until N signals are received before disconnecting
+	if err := client.Subscribe(); err != nil {
+		log.Fatalf("Couldn't subscribe: %s", err)
+	}
+
+	// Wait until N messages are received before disconnecting
 	client.AwaitCounterTargetReached()
 }
@@ -8,26 +8,24 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"sync"
 	"syscall"
 	"time"
 
 	wwr "github.com/qbeon/webwire-go"
 )
 
+// timeTopic is the topic the current time is published to
+const timeTopic = "time"
+
 // PubSubServer implements the webwire.ServerImplementation interface
 type PubSubServer struct {
 	broadcastInterval time.Duration
-	connectedClients  map[wwr.Connection]bool
-	mapLock           sync.Mutex
 }
 
 // NewPubSubServer constructs a new pub-sub webwire server implementation instance
 func NewPubSubServer() *PubSubServer {
 	return &PubSubServer{
 		1 * time.Second,
-		make(map[wwr.Connection]bool),
-		sync.Mutex{},
 	}
 }
 
@@ -67,43 +65,35 @@ func (srv *PubSubServer) BeforeUpgrade(resp http.ResponseWriter, req *http.Reque
 }
 
 // OnClientConnected implements the webwire.ServerImplementation interface.
-// Registers a new connected client
-func (srv *PubSubServer) OnClientConnected(client wwr.Connection) {
-	srv.mapLock.Lock()
-	srv.connectedClients[client] = true
-	srv.mapLock.Unlock()
-}
+// Does nothing, clients subscribe to the time topic on their own
+func (srv *PubSubServer) OnClientConnected(client wwr.Connection) {}
 
-// OnClientDisconnected implements the webwire.ServerImplementation interface
-// Deregisters a gone client
-func (srv *PubSubServer) OnClientDisconnected(client wwr.Connection) {
-	srv.mapLock.Lock()
-	delete(srv.connectedClients, client)
-	srv.mapLock.Unlock()
-}
+// OnClientDisconnected implements the webwire.ServerImplementation interface.
+// Does nothing, subscriptions of gone clients are removed automatically
+func (srv *PubSubServer) OnClientDisconnected(client wwr.Connection) {}
 
-// Broadcast begins sending the current time in 1 second intervals.
+// Broadcast begins publishing the current time in 1 second intervals.
 // Blocks the calling goroutine
-func (srv *PubSubServer) Broadcast() {
+func (srv *PubSubServer) Broadcast(server wwr.Server) {
 	for {
-		time.Sleep(1 * time.Second)
-
-		srv.mapLock.Lock()
+		time.Sleep(srv.broadcastInterval)
 
-		if len(srv.connectedClients) < 1 {
-			log.Println("No clients connected, aborting broadcast")
-			srv.mapLock.Unlock()
+		subscribers := server.TopicSubscribersNum(timeTopic)
+		if subscribers < 1 {
+			log.Println("No clients subscribed, aborting broadcast")
 			continue
 		}
 
 		msg := time.Now().String()
 
-		log.Printf("Broadcasting message '%s', to %d clients", msg, len(srv.connectedClients))
+		log.Printf("Broadcasting message '%s', to %d clients", msg, subscribers)
 
-		for client := range srv.connectedClients {
-			client.Signal("", wwr.NewPayload(wwr.EncodingBinary, []byte(msg)))
+		if err := server.Publish(
+			timeTopic,
+			wwr.NewPayload(wwr.EncodingBinary, []byte(msg)),
+		); err != nil {
+			log.Printf("Couldn't publish message: %s", err)
 		}
-		srv.mapLock.Unlock()
 	}
 }
 
@@ -129,7 +119,7 @@ func main() {
 	}
 
 	// Start broadcast
-	go serverImpl.Broadcast()
+	go serverImpl.Broadcast(server)
 
 	// Listen for OS signals and shutdown server in case of demanded termination
 	osSignals := make(chan os.Signal, 1)
@@ -141,7 +131,13 @@ func main() {
 		log.Printf("Termination demanded by the OS (%s), shutting down...", sig)
 
 		// Shutdown the webwire server
-		if err := server.Shutdown(); err != nil {
+		// Abandon remaining handlers after 10 seconds
+		ctx, cancel := context.WithTimeout(
+			context.Background(),
+			10*time.Second,
+		)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
 			log.Printf("Error during server shutdown: %s", err)
 		}
 		log.Println("Server gracefully terminated")
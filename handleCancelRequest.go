@@ -0,0 +1,15 @@
+package webwire
+
+import (
+	msg "github.com/qbeon/webwire-go/message"
+)
+
+// handleCancelRequest handles request cancelation messages
+// canceling the context of the handler of the referenced request.
+// The reply to the canceled request is not sent
+func (srv *server) handleCancelRequest(
+	con *connection,
+	message *msg.Message,
+) {
+	con.cancelRequest(message.Identifier)
+}
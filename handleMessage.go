@@ -6,67 +6,194 @@ import (
 	msg "github.com/qbeon/webwire-go/message"
 )
 
-// handleMessage handles incoming messages
-func (srv *server) handleMessage(con *connection, message []byte) {
-	// Parse message
+// dispatchMessage dispatches an incoming message for handling
+// respecting the configured message order.
+// It's called by the goroutine reading the connection
+func (srv *server) dispatchMessage(con *connection, message []byte) {
+	// Parse the message right away to determine its order
+	// and register requests for cancelation before they're queued
+	parsedMessage := srv.parseMessage(con, message)
+	if parsedMessage == nil {
+		return
+	}
+	if isControlMessage(parsedMessage) {
+		go srv.handleControlMessage(con, parsedMessage)
+		return
+	}
+	if isRequestMessage(parsedMessage) {
+		con.registerRequest(parsedMessage.Identifier)
+	}
+
+	if srv.options.MessageOrder == Unordered {
+		go srv.executeMessage(con, parsedMessage)
+		return
+	}
+
+	key := ""
+	if srv.options.MessageOrder == OrderedPerName {
+		key = parsedMessage.Name
+	}
+	con.queue.push(key, parsedMessage, func(message *msg.Message) {
+		srv.executeMessage(con, message)
+	})
+}
+
+// parseMessage parses the given message.
+// Returns nil if the message was either dropped or failed
+func (srv *server) parseMessage(con *connection, message []byte) *msg.Message {
 	var parsedMessage msg.Message
 	msgTypeParsed, parserErr := parsedMessage.Parse(message)
 	if !msgTypeParsed {
 		// Couldn't determine message type, drop message
-		return
-	} else if parserErr != nil {
+		return nil
+	}
+	srv.metrics.MessageReceived(parsedMessage.Type, len(message))
+	if parserErr != nil {
 		// Couldn't parse message, protocol error
-		srv.warnLog.Println("Parser error:", parserErr)
+		srv.logger.Warn(
+			"Couldn't parse message",
+			con.logFields(
+				"messageType", parsedMessage.Type,
+				"error", parserErr,
+			)...,
+		)
 
 		// Respond with an error but don't break the connection
 		// because protocol errors are not critical errors
 		srv.failMsg(con, &parsedMessage, ProtocolErr{})
+		return nil
+	}
+	return &parsedMessage
+}
+
+// isControlMessage returns true for request cancelations and replies,
+// which are handled immediately without occupying a handler slot
+// to not let them queue up behind other handlers
+func isControlMessage(message *msg.Message) bool {
+	switch message.Type {
+	case msg.MsgCancelRequest:
+		fallthrough
+	case msg.MsgReplyBinary:
+		fallthrough
+	case msg.MsgReplyUtf8:
+		fallthrough
+	case msg.MsgReplyUtf16:
+		fallthrough
+	case msg.MsgErrorReply:
+		fallthrough
+	case msg.MsgInternalError:
+		return true
+	}
+	return false
+}
+
+// isRequestMessage returns true for requests,
+// which can be canceled by the client
+func isRequestMessage(message *msg.Message) bool {
+	switch message.Type {
+	case msg.MsgRequestBinary:
+		fallthrough
+	case msg.MsgRequestUtf8:
+		fallthrough
+	case msg.MsgRequestUtf16:
+		return true
+	}
+	return false
+}
+
+// handleControlMessage handles request cancelations and replies
+func (srv *server) handleControlMessage(con *connection, message *msg.Message) {
+	if message.Type == msg.MsgCancelRequest {
+		srv.handleCancelRequest(con, message)
 		return
 	}
+	srv.handleReply(con, message)
+}
+
+// executeMessage executes the handler of the given message
+// occupying a handler slot
+func (srv *server) executeMessage(con *connection, message *msg.Message) {
+	request := isRequestMessage(message)
+
+	// Requests stop awaiting execution as soon as they're canceled
+	ctx := context.Background()
+	if request {
+		requestCtx, registered := con.requestContext(message.Identifier)
+		if !registered {
+			// Canceled by the client while queued
+			return
+		}
+		ctx = requestCtx
+	}
 
 	// Deregister the handler only if a handler was registered
-	if srv.registerHandler(con, &parsedMessage) {
-		defer srv.deregisterHandler(con)
+	ticket := srv.registerHandler(ctx, con, message)
+	if ticket == nil {
+		if request {
+			con.releaseRequest(message.Identifier)
+		}
+		return
+	}
+	defer srv.deregisterHandler(con, ticket)
+
+	// Drop requests canceled by the client while awaiting a handler slot
+	if request && con.requestCanceled(message.Identifier) {
+		return
 	}
 
-	switch parsedMessage.Type {
+	switch message.Type {
 	case msg.MsgSignalBinary:
 		fallthrough
 	case msg.MsgSignalUtf8:
 		fallthrough
 	case msg.MsgSignalUtf16:
-		srv.handleSignal(con, &parsedMessage)
+		srv.handleSignal(con, message)
 
 	case msg.MsgRequestBinary:
 		fallthrough
 	case msg.MsgRequestUtf8:
 		fallthrough
 	case msg.MsgRequestUtf16:
-		srv.handleRequest(con, &parsedMessage)
+		srv.handleRequest(con, message)
 
 	case msg.MsgRestoreSession:
-		srv.handleSessionRestore(con, &parsedMessage)
+		srv.handleSessionRestore(con, message)
 	case msg.MsgCloseSession:
-		srv.handleSessionClosure(con, &parsedMessage)
+		srv.handleSessionClosure(con, message)
+
+	case msg.MsgSubscribe:
+		srv.handleSubscribe(con, message)
+	case msg.MsgUnsubscribe:
+		srv.handleUnsubscribe(con, message)
 	}
 }
 
 // registerHandler increments the number of currently executed handlers.
-// It blocks if the current number of max concurrent handlers was reached
-// and frees only when a handler slot is freed for this handler to be executed
+// It blocks until the handler scheduler grants the handler execution
+// according to the configured concurrency limits
+// or the given context is canceled.
+// Returns nil if the handler wasn't registered
 func (srv *server) registerHandler(
+	ctx context.Context,
 	con *connection,
 	message *msg.Message,
-) bool {
+) *handlerTicket {
 	failMsg := false
 
-	// Wait for free handler slots
-	// if the number of concurrent handler is limited
+	// Wait for the handler to be granted execution
 	if !con.IsActive() {
-		return false
+		return nil
 	}
-	if srv.options.IsConcurrentHandlersLimited() {
-		srv.handlerSlots.Acquire(context.Background(), 1)
+	ticket := srv.handlerScheduler.acquire(ctx, con)
+	if ticket == nil {
+		// Abandoned while queued, reject requests that weren't canceled
+		// by the client but by the closure of either the connection
+		// or the server
+		if message.RequiresReply() && con.IsActive() &&
+			!con.requestCanceled(message.Identifier) {
+			srv.failMsgShutdown(con, message)
+		}
+		return nil
 	}
 
 	srv.opsLock.Lock()
@@ -78,19 +205,23 @@ func (srv *server) registerHandler(
 	}
 	srv.opsLock.Unlock()
 
-	if failMsg && message.RequiresReply() {
+	if failMsg {
+		srv.handlerScheduler.release(ticket)
+
 		// Don't process the message, fail it
-		srv.failMsgShutdown(con, message)
-		return false
+		if message.RequiresReply() {
+			srv.failMsgShutdown(con, message)
+		}
+		return nil
 	}
 
 	con.registerTask()
-	return true
+	return ticket
 }
 
 // deregisterHandler decrements the number of currently executed handlers
 // and shuts down the server if scheduled and no more operations are left
-func (srv *server) deregisterHandler(con *connection) {
+func (srv *server) deregisterHandler(con *connection, ticket *handlerTicket) {
 	srv.opsLock.Lock()
 	srv.currentOps--
 	if srv.shutdown && srv.currentOps < 1 {
@@ -100,10 +231,8 @@ func (srv *server) deregisterHandler(con *connection) {
 
 	con.deregisterTask()
 
-	// Release a handler slot
-	if srv.options.IsConcurrentHandlersLimited() {
-		srv.handlerSlots.Release(1)
-	}
+	// Release the handler slot
+	srv.handlerScheduler.release(ticket)
 }
 
 // fulfillMsg fulfills the message sending the reply
@@ -121,8 +250,12 @@ func (srv *server) fulfillMsg(
 			replyPayloadData,
 		),
 	); err != nil {
-		srv.errorLog.Println("Writing failed:", err)
+		srv.logger.Error(
+			"Couldn't write reply",
+			con.logFields("request", message.Name, "error", err)...,
+		)
 	}
+	srv.metrics.RequestFulfilled()
 }
 
 // failMsg fails the message returning an error reply
@@ -138,40 +271,48 @@ func (srv *server) failMsg(
 	}
 
 	var replyMsg []byte
+	var errCode string
 	switch err := reqErr.(type) {
 	case ReqErr:
+		errCode = err.Code
 		replyMsg = msg.NewErrorReplyMessage(
 			message.Identifier,
 			err.Code,
 			err.Message,
 		)
 	case *ReqErr:
+		errCode = err.Code
 		replyMsg = msg.NewErrorReplyMessage(
 			message.Identifier,
 			err.Code,
 			err.Message,
 		)
 	case MaxSessConnsReachedErr:
+		errCode = ErrCodeMaxSessConnsReached
 		replyMsg = msg.NewSpecialRequestReplyMessage(
 			msg.MsgMaxSessConnsReached,
 			message.Identifier,
 		)
 	case SessNotFoundErr:
+		errCode = ErrCodeSessNotFound
 		replyMsg = msg.NewSpecialRequestReplyMessage(
 			msg.MsgSessionNotFound,
 			message.Identifier,
 		)
 	case SessionsDisabledErr:
+		errCode = ErrCodeSessionsDisabled
 		replyMsg = msg.NewSpecialRequestReplyMessage(
 			msg.MsgSessionsDisabled,
 			message.Identifier,
 		)
 	case ProtocolErr:
+		errCode = ErrCodeProtocol
 		replyMsg = msg.NewSpecialRequestReplyMessage(
 			msg.MsgReplyProtocolError,
 			message.Identifier,
 		)
 	default:
+		errCode = ErrCodeInternal
 		replyMsg = msg.NewSpecialRequestReplyMessage(
 			msg.MsgInternalError,
 			message.Identifier,
@@ -180,8 +321,16 @@ func (srv *server) failMsg(
 
 	// Send request failure notification
 	if err := con.sock.Write(replyMsg); err != nil {
-		srv.errorLog.Println("Writing failed:", err)
+		srv.logger.Error(
+			"Couldn't write error reply",
+			con.logFields(
+				"request", message.Name,
+				"errorCode", errCode,
+				"error", err,
+			)...,
+		)
 	}
+	srv.metrics.RequestFailed(errCode)
 }
 
 // failMsgShutdown sends request failure reply due to current server shutdown
@@ -190,6 +339,10 @@ func (srv *server) failMsgShutdown(con *connection, message *msg.Message) {
 		msg.MsgReplyShutdown,
 		message.Identifier,
 	)); err != nil {
-		srv.errorLog.Println("Writing failed:", err)
+		srv.logger.Error(
+			"Couldn't write shutdown reply",
+			con.logFields("request", message.Name, "error", err)...,
+		)
 	}
+	srv.metrics.RequestFailed(ErrCodeShutdown)
 }
@@ -1,17 +1,30 @@
 package webwire
 
-// handleMetadata handles endpoint metadata requests
 import (
 	"encoding/json"
 	"net/http"
 )
 
-func (srv *server) handleMetadata(resp http.ResponseWriter) {
+// handleMetadata handles endpoint metadata requests
+// announcing the legacy protocol version to legacy clients
+func (srv *server) handleMetadata(
+	resp http.ResponseWriter,
+	req *http.Request,
+	origin string,
+) {
+	version := protocolVersion
+	if isLegacyClient(req) {
+		version = legacyProtocolVersion
+	}
 	resp.Header().Set("Content-Type", "application/json")
-	resp.Header().Set("Access-Control-Allow-Origin", "*")
-	json.NewEncoder(resp).Encode(struct {
-		ProtocolVersion string `json:"protocol-version"`
-	}{
-		protocolVersion,
+	srv.originPolicy.setCORSHeaders(resp.Header(), origin)
+	json.NewEncoder(resp).Encode(EndpointMetadata{
+		ProtocolVersion: version,
 	})
 }
+
+// isLegacyClient returns true if the given request was sent by a client
+// running the legacy protocol version, which doesn't announce its version
+func isLegacyClient(req *http.Request) bool {
+	return req.Header.Get(ProtocolVersionHeader) == ""
+}
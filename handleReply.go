@@ -0,0 +1,24 @@
+package webwire
+
+import (
+	msg "github.com/qbeon/webwire-go/message"
+)
+
+// handleReply handles replies to requests previously sent to the client
+// fulfilling or failing the according pending request
+func (srv *server) handleReply(con *connection, message *msg.Message) {
+	switch message.Type {
+	case msg.MsgErrorReply:
+		// The message name contains the error code in case of
+		// error reply messages, while the UTF8 encoded error message is
+		// contained in the message payload
+		con.requestManager.Fail(message.Identifier, ReqErr{
+			Code:    message.Name,
+			Message: string(message.Payload.Data),
+		})
+	case msg.MsgInternalError:
+		con.requestManager.Fail(message.Identifier, ReqInternalErr{})
+	default:
+		con.requestManager.Fulfill(message.Identifier, message.Payload)
+	}
+}
@@ -1,7 +1,7 @@
 package webwire
 
 import (
-	"context"
+	"time"
 
 	msg "github.com/qbeon/webwire-go/message"
 )
@@ -9,13 +9,21 @@ import (
 // handleRequest handles incoming requests
 // and returns an error if the ongoing connection cannot be proceeded
 func (srv *server) handleRequest(conn *connection, message *msg.Message) {
-	replyPayload, returnedErr := srv.impl.OnRequest(
-		context.Background(),
+	start := time.Now()
+	replyPayload, returnedErr := srv.requestHandler(
+		conn.handlerContext(message),
 		conn,
 		&MessageWrapper{
 			actual: message,
 		},
 	)
+	srv.metrics.RequestHandled(message.Name, time.Since(start))
+
+	// Don't reply to requests canceled by the client
+	if conn.releaseRequest(message.Identifier) {
+		return
+	}
+
 	switch returnedErr.(type) {
 	case nil:
 		// Initialize payload encoding & data
@@ -37,7 +45,10 @@ func (srv *server) handleRequest(conn *connection, message *msg.Message) {
 	case *ReqErr:
 		srv.failMsg(conn, message, returnedErr)
 	default:
-		srv.errorLog.Printf("Internal error during request handling: %s", returnedErr)
+		srv.logger.Error(
+			"Internal error during request handling",
+			conn.logFields("request", message.Name, "error", returnedErr)...,
+		)
 		srv.failMsg(conn, message, returnedErr)
 	}
 }
@@ -15,6 +15,9 @@ func (srv *server) handleSessionClosure(
 		return
 	}
 
+	srv.sessionOpsLock.Lock()
+	defer srv.sessionOpsLock.Unlock()
+
 	if !conn.HasSession() {
 		// Send confirmation even though no session was closed
 		srv.fulfillMsg(conn, message, 0, nil)
@@ -25,17 +28,18 @@ func (srv *server) handleSessionClosure(
 	srv.sessionRegistry.deregister(conn)
 
 	// Synchronize session destruction to the client
-	if err := conn.notifySessionClosed(); err != nil {
+	if err := conn.notifySessionClosed(SessionCloseClient); err != nil {
 		srv.failMsg(conn, message, nil)
-		srv.errorLog.Printf("CRITICAL: Internal server error, "+
-			"couldn't notify client about the session destruction: %s",
-			err,
+		srv.logger.Error(
+			"Couldn't notify client about the session destruction",
+			conn.logFields("error", err)...,
 		)
 		return
 	}
 
 	// Reset the session on the connection
 	conn.setSession(nil)
+	srv.metrics.SessionClosed()
 
 	// Send confirmation
 	srv.fulfillMsg(conn, message, 0, nil)
@@ -3,6 +3,7 @@ package webwire
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	msg "github.com/qbeon/webwire-go/message"
 )
@@ -18,6 +19,11 @@ func (srv *server) handleSessionRestore(
 		return
 	}
 
+	// Prevent the session from being closed or rotated
+	// between its lookup and the registration of the connection
+	srv.sessionOpsLock.Lock()
+	defer srv.sessionOpsLock.Unlock()
+
 	key := string(message.Payload.Data)
 
 	sessConsNum := srv.sessionRegistry.sessionConnectionsNum(key)
@@ -37,25 +43,44 @@ func (srv *server) handleSessionRestore(
 		return
 	default:
 		srv.failMsg(con, message, nil)
-		srv.errorLog.Printf("CRITICAL: Session search handler failed: %s", err)
+		srv.logger.Error(
+			"Session lookup failed",
+			con.logFields("sessionKey", key, "error", err)...,
+		)
 		return
 	case nil:
 	}
 
+	// Reject expired sessions the session manager didn't report as not found.
+	// Active sessions are in use and thus never idle
+	now := time.Now()
+	lastUse := result.LastLookup
+	if sessConsNum > 0 {
+		lastUse = now
+	}
+	if result.Expiration.Expired(result.Creation, lastUse, now) {
+		srv.failMsg(con, message, SessNotFoundErr{})
+		return
+	}
+
 	// JSON encode the session
 	encodedSessionObj := JSONEncodedSession{
 		Key:        key,
 		Creation:   result.Creation,
 		LastLookup: result.LastLookup,
+		Expiration: result.Expiration,
 		Info:       result.Info,
 	}
 	encodedSession, err := json.Marshal(&encodedSessionObj)
 	if err != nil {
 		srv.failMsg(con, message, nil)
-		srv.errorLog.Printf(
-			"Couldn't encode session object (%v): %s",
-			encodedSessionObj,
-			err,
+		srv.logger.Error(
+			"Couldn't encode session object",
+			con.logFields(
+				"sessionKey", key,
+				"session", encodedSessionObj,
+				"error", err,
+			)...,
 		)
 		return
 	}
@@ -70,6 +95,7 @@ func (srv *server) handleSessionRestore(
 		Key:        key,
 		Creation:   result.Creation,
 		LastLookup: result.LastLookup,
+		Expiration: result.Expiration,
 		Info:       parsedSessInfo,
 	})
 	if err := srv.sessionRegistry.register(con); err != nil {
@@ -78,5 +104,7 @@ func (srv *server) handleSessionRestore(
 		))
 	}
 
+	srv.metrics.SessionRestored()
+
 	srv.fulfillMsg(con, message, EncodingUtf8, encodedSession)
 }
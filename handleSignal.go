@@ -1,7 +1,7 @@
 package webwire
 
 import (
-	"context"
+	"time"
 
 	msg "github.com/qbeon/webwire-go/message"
 )
@@ -18,13 +18,15 @@ func (srv *server) handleSignal(con *connection, message *msg.Message) {
 	srv.currentOps++
 	srv.opsLock.Unlock()
 
-	srv.impl.OnSignal(
-		context.Background(),
+	start := time.Now()
+	srv.signalHandler(
+		con.handlerContext(message),
 		con,
 		&MessageWrapper{
 			actual: message,
 		},
 	)
+	srv.metrics.SignalHandled(message.Name, time.Since(start))
 
 	// Mark signal as done and shutdown the server if scheduled and no ops are left
 	srv.opsLock.Lock()
@@ -0,0 +1,56 @@
+package webwire
+
+import (
+	msg "github.com/qbeon/webwire-go/message"
+)
+
+// handleSubscribe handles topic subscription requests
+func (srv *server) handleSubscribe(
+	conn *connection,
+	message *msg.Message,
+) {
+	topic := string(message.Payload.Data)
+	if err := verifyTopic(topic); err != nil {
+		srv.failMsg(conn, message, NewProtocolErr(err))
+		return
+	}
+
+	// Let the subscription filter decide whether the subscription is allowed
+	if srv.options.SubscriptionFilter != nil {
+		switch err := srv.options.SubscriptionFilter(conn, topic).(type) {
+		case nil:
+		case ReqErr:
+			srv.failMsg(conn, message, err)
+			return
+		case *ReqErr:
+			srv.failMsg(conn, message, err)
+			return
+		default:
+			srv.logger.Error(
+				"Internal error during subscription filtering",
+				conn.logFields("topic", topic, "error", err)...,
+			)
+			srv.failMsg(conn, message, err)
+			return
+		}
+	}
+
+	if err := conn.Subscribe(topic); err != nil {
+		srv.failMsg(conn, message, err)
+		return
+	}
+
+	// Send confirmation
+	srv.fulfillMsg(conn, message, 0, nil)
+}
+
+// handleUnsubscribe handles topic unsubscription requests
+func (srv *server) handleUnsubscribe(
+	conn *connection,
+	message *msg.Message,
+) {
+	conn.Unsubscribe(string(message.Payload.Data))
+
+	// Send confirmation even though there might have been no subscription
+	srv.fulfillMsg(conn, message, 0, nil)
+}
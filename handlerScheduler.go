@@ -0,0 +1,247 @@
+package webwire
+
+import (
+	"context"
+	"sync"
+)
+
+// HandlerStats represents the counters of the handler scheduler
+type HandlerStats struct {
+	// Running is the number of currently executed handlers
+	Running uint32
+
+	// Queued is the number of handlers currently awaiting execution
+	// due to either the global, the per-connection
+	// or the per-session concurrency limit
+	Queued uint32
+
+	// TotalExecuted is the total number of handlers granted execution
+	TotalExecuted uint64
+
+	// TotalQueued is the total number of handlers
+	// that had to await execution
+	TotalQueued uint64
+}
+
+// handlerTicket represents a handler either awaiting
+// or being granted execution
+type handlerTicket struct {
+	con        *connection
+	sessionKey string
+	granted    chan struct{}
+}
+
+// handlerScheduler limits the number of concurrently executed handlers
+// globally, per connection and per session.
+// Handlers awaiting execution are queued per connection
+// while the global handler slots are shared among the connections
+// in a round-robin fashion
+type handlerScheduler struct {
+	lock            sync.Mutex
+	maxHandlers     uint32
+	maxConnHandlers uint32
+	maxSessHandlers uint32
+	connRunning     map[*connection]uint32
+	sessRunning     map[string]uint32
+	waiting         map[*connection][]*handlerTicket
+	roundRobin      []*connection
+	stats           HandlerStats
+}
+
+// newHandlerScheduler returns a new handler scheduler instance,
+// a limit of zero stands for unlimited
+func newHandlerScheduler(
+	maxHandlers uint32,
+	maxConnHandlers uint32,
+	maxSessHandlers uint32,
+) *handlerScheduler {
+	return &handlerScheduler{
+		lock:            sync.Mutex{},
+		maxHandlers:     maxHandlers,
+		maxConnHandlers: maxConnHandlers,
+		maxSessHandlers: maxSessHandlers,
+		connRunning:     make(map[*connection]uint32),
+		sessRunning:     make(map[string]uint32),
+		waiting:         make(map[*connection][]*handlerTicket),
+		roundRobin:      make([]*connection, 0),
+	}
+}
+
+// acquire blocks the calling goroutine until the handler
+// of the given connection is granted execution.
+// The returned ticket must be released after the handler returned.
+// Returns nil if the given context is canceled while the handler is queued,
+// in which case the handler is removed from the queue
+func (hs *handlerScheduler) acquire(
+	ctx context.Context,
+	con *connection,
+) *handlerTicket {
+	ticket := &handlerTicket{
+		con:        con,
+		sessionKey: con.SessionKey(),
+	}
+
+	hs.lock.Lock()
+
+	// Execute immediately if the limits permit it and there are
+	// no other handlers of this connection queued before this one
+	if len(hs.waiting[con]) < 1 && hs.permits(ticket) {
+		hs.grant(ticket)
+		hs.lock.Unlock()
+		return ticket
+	}
+
+	// Queue the handler
+	ticket.granted = make(chan struct{})
+	if len(hs.waiting[con]) < 1 {
+		hs.roundRobin = append(hs.roundRobin, con)
+	}
+	hs.waiting[con] = append(hs.waiting[con], ticket)
+	hs.stats.Queued++
+	hs.stats.TotalQueued++
+	hs.lock.Unlock()
+
+	select {
+	case <-ticket.granted:
+		return ticket
+	case <-ctx.Done():
+	}
+
+	hs.lock.Lock()
+	defer hs.lock.Unlock()
+	select {
+	case <-ticket.granted:
+		// Granted in the meantime
+		return ticket
+	default:
+	}
+	hs.dequeue(ticket)
+	hs.schedule()
+	return nil
+}
+
+// dequeue removes the given queued handler from the queue.
+// The lock must be held by the caller
+func (hs *handlerScheduler) dequeue(ticket *handlerTicket) {
+	queue := hs.waiting[ticket.con]
+	for index, queued := range queue {
+		if queued != ticket {
+			continue
+		}
+		queue = append(queue[:index], queue[index+1:]...)
+		break
+	}
+	hs.stats.Queued--
+
+	if len(queue) > 0 {
+		hs.waiting[ticket.con] = queue
+		return
+	}
+	delete(hs.waiting, ticket.con)
+	for index, con := range hs.roundRobin {
+		if con == ticket.con {
+			hs.roundRobin = append(
+				hs.roundRobin[:index],
+				hs.roundRobin[index+1:]...,
+			)
+			break
+		}
+	}
+}
+
+// release frees the execution slot of the given ticket
+// and grants execution to queued handlers
+func (hs *handlerScheduler) release(ticket *handlerTicket) {
+	hs.lock.Lock()
+	defer hs.lock.Unlock()
+
+	hs.stats.Running--
+	if hs.connRunning[ticket.con] < 2 {
+		delete(hs.connRunning, ticket.con)
+	} else {
+		hs.connRunning[ticket.con]--
+	}
+	if ticket.sessionKey != "" {
+		if hs.sessRunning[ticket.sessionKey] < 2 {
+			delete(hs.sessRunning, ticket.sessionKey)
+		} else {
+			hs.sessRunning[ticket.sessionKey]--
+		}
+	}
+
+	hs.schedule()
+}
+
+// snapshot returns a snapshot of the scheduler counters
+func (hs *handlerScheduler) snapshot() HandlerStats {
+	hs.lock.Lock()
+	defer hs.lock.Unlock()
+	return hs.stats
+}
+
+// permits returns true if the limits permit the execution
+// of the given handler. The lock must be held by the caller
+func (hs *handlerScheduler) permits(ticket *handlerTicket) bool {
+	if hs.maxHandlers > 0 && hs.stats.Running >= hs.maxHandlers {
+		return false
+	}
+	if hs.maxConnHandlers > 0 &&
+		hs.connRunning[ticket.con] >= hs.maxConnHandlers {
+		return false
+	}
+	if hs.maxSessHandlers > 0 && ticket.sessionKey != "" &&
+		hs.sessRunning[ticket.sessionKey] >= hs.maxSessHandlers {
+		return false
+	}
+	return true
+}
+
+// grant marks the given handler as running.
+// The lock must be held by the caller
+func (hs *handlerScheduler) grant(ticket *handlerTicket) {
+	hs.stats.Running++
+	hs.stats.TotalExecuted++
+	hs.connRunning[ticket.con]++
+	if ticket.sessionKey != "" {
+		hs.sessRunning[ticket.sessionKey]++
+	}
+}
+
+// schedule grants execution to the queued handlers the limits permit
+// visiting the connections in a round-robin fashion.
+// Each visited connection is granted at most one handler per round
+// and is moved to the back of the queue if it has more handlers queued.
+// The lock must be held by the caller
+func (hs *handlerScheduler) schedule() {
+	for visited := 0; visited < len(hs.roundRobin); {
+		if hs.maxHandlers > 0 && hs.stats.Running >= hs.maxHandlers {
+			return
+		}
+
+		con := hs.roundRobin[0]
+		queue := hs.waiting[con]
+		ticket := queue[0]
+		if !hs.permits(ticket) {
+			// Skip connections limited by their own limits
+			hs.roundRobin = append(hs.roundRobin[1:], con)
+			visited++
+			continue
+		}
+
+		hs.grant(ticket)
+		hs.stats.Queued--
+		close(ticket.granted)
+
+		queue[0] = nil
+		if len(queue) < 2 {
+			delete(hs.waiting, con)
+			hs.roundRobin = hs.roundRobin[1:]
+		} else {
+			hs.waiting[con] = queue[1:]
+			hs.roundRobin = append(hs.roundRobin[1:], con)
+		}
+
+		// Start a new round after each granted handler
+		visited = 0
+	}
+}
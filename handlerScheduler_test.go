@@ -0,0 +1,147 @@
+package webwire
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// awaitQueued blocks until the given number of handlers is queued
+func awaitQueued(t *testing.T, hs *handlerScheduler, queued uint32) {
+	deadline := time.Now().Add(1 * time.Second)
+	for hs.snapshot().Queued != queued {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d queued handlers", queued)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestHandlerSchedRoundRobin tests whether the global handler slots
+// are shared among the connections in a round-robin fashion
+func TestHandlerSchedRoundRobin(t *testing.T) {
+	hs := newHandlerScheduler(1, 0, 0)
+	cltA := newConnection(nil, "", nil)
+	cltB := newConnection(nil, "", nil)
+
+	running := hs.acquire(context.Background(), cltA)
+
+	// Queue 3 handlers of connection A before a handler of connection B
+	granted := make(chan *handlerTicket, 4)
+	for i, con := range []*connection{cltA, cltA, cltA, cltB} {
+		go func(con *connection) {
+			granted <- hs.acquire(context.Background(), con)
+		}(con)
+		awaitQueued(t, hs, uint32(i+1))
+	}
+
+	// Expect connection B not to wait for all handlers of connection A
+	expectedOrder := []*connection{cltA, cltB, cltA, cltA}
+	for i, expected := range expectedOrder {
+		hs.release(running)
+		running = <-granted
+		if running.con != expected {
+			t.Fatalf("Unexpected connection granted at position %d", i)
+		}
+	}
+	hs.release(running)
+
+	stats := hs.snapshot()
+	if stats.Running != 0 || stats.Queued != 0 {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+	if stats.TotalExecuted != 5 || stats.TotalQueued != 4 {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+}
+
+// TestHandlerSchedConnLimit tests the per-connection handler limit
+func TestHandlerSchedConnLimit(t *testing.T) {
+	hs := newHandlerScheduler(0, 1, 0)
+	cltA := newConnection(nil, "", nil)
+	cltB := newConnection(nil, "", nil)
+
+	ticketA := hs.acquire(context.Background(), cltA)
+
+	// Expect the second handler of connection A to be queued
+	granted := make(chan *handlerTicket, 1)
+	go func() {
+		granted <- hs.acquire(context.Background(), cltA)
+	}()
+	awaitQueued(t, hs, 1)
+
+	// Expect connection B not to be affected by the limit of connection A
+	hs.release(hs.acquire(context.Background(), cltB))
+
+	hs.release(ticketA)
+	hs.release(<-granted)
+
+	if stats := hs.snapshot(); stats.Running != 0 || stats.Queued != 0 {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+}
+
+// TestHandlerSchedSessLimit tests the per-session handler limit
+func TestHandlerSchedSessLimit(t *testing.T) {
+	hs := newHandlerScheduler(0, 0, 1)
+	sess := NewSession(nil, func() string { return "testkey_A" })
+	cltA1 := newConnection(nil, "", nil)
+	cltA1.session = &sess
+	cltA2 := newConnection(nil, "", nil)
+	cltA2.session = &sess
+
+	ticket := hs.acquire(context.Background(), cltA1)
+
+	// Expect the handler of another connection of the same session
+	// to be queued
+	granted := make(chan *handlerTicket, 1)
+	go func() {
+		granted <- hs.acquire(context.Background(), cltA2)
+	}()
+	awaitQueued(t, hs, 1)
+
+	hs.release(ticket)
+	hs.release(<-granted)
+
+	if stats := hs.snapshot(); stats.Running != 0 || stats.Queued != 0 {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+}
+
+// TestHandlerSchedCancel tests whether queued handlers are removed
+// from the queue as soon as their context is canceled
+func TestHandlerSchedCancel(t *testing.T) {
+	hs := newHandlerScheduler(1, 0, 0)
+	cltA := newConnection(nil, "", nil)
+	cltB := newConnection(nil, "", nil)
+
+	running := hs.acquire(context.Background(), cltA)
+
+	// Queue a handler of connection A and cancel it
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan *handlerTicket, 1)
+	go func() {
+		canceled <- hs.acquire(ctx, cltA)
+	}()
+	awaitQueued(t, hs, 1)
+	cancel()
+	if ticket := <-canceled; ticket != nil {
+		t.Fatal("Expected the canceled handler not to be granted execution")
+	}
+	if stats := hs.snapshot(); stats.Queued != 0 {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+
+	// Expect the next queued handler to be granted execution
+	granted := make(chan *handlerTicket, 1)
+	go func() {
+		granted <- hs.acquire(context.Background(), cltB)
+	}()
+	awaitQueued(t, hs, 1)
+	hs.release(running)
+	hs.release(<-granted)
+
+	if stats := hs.snapshot(); stats.Running != 0 || stats.Queued != 0 {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+}
@@ -4,15 +4,18 @@ import "time"
 
 // heartbeat starts a heartbeat for the given connection
 // blocking the calling goroutine until the stop channel is triggered
-func (srv *server) heartbeat(conn Socket, stop chan struct{}) {
+func (srv *server) heartbeat(con *connection, stop chan struct{}) {
 	hearthbeatTicker := time.NewTicker(srv.options.HeartbeatInterval)
 HEARTBEAT_LOOP:
 	for {
-		if err := conn.WritePing(
+		if err := con.sock.WritePing(
 			nil,
 			time.Now().Add(srv.options.HeartbeatInterval),
 		); err != nil {
-			srv.errorLog.Printf("Couldn't write ping frame: %s", err)
+			srv.logger.Error(
+				"Couldn't write ping frame",
+				con.logFields("error", err)...,
+			)
 		}
 		select {
 		case <-hearthbeatTicker.C:
@@ -0,0 +1,69 @@
+package webwire
+
+import "context"
+
+// RequestInterceptor represents the type of a request interceptor function.
+// An interceptor wraps the request handling of the server implementation
+// and is expected to call next to continue the chain.
+// It may short-circuit the chain by returning without calling next
+// (for example returning a ReqErr) or rewrite the reply payload
+// returned by next
+type RequestInterceptor func(
+	ctx context.Context,
+	connection Connection,
+	message Message,
+	next RequestHandler,
+) (response Payload, err error)
+
+// SignalInterceptor represents the type of a signal interceptor function.
+// An interceptor wraps the signal handling of the server implementation
+// and is expected to call next to continue the chain.
+// It may short-circuit the chain by returning without calling next
+type SignalInterceptor func(
+	ctx context.Context,
+	connection Connection,
+	message Message,
+	next SignalHandler,
+)
+
+// chainRequestInterceptors wraps the given request handler into the
+// given interceptors. The first interceptor is the outermost one
+// and is thus invoked first
+func chainRequestInterceptors(
+	interceptors []RequestInterceptor,
+	handler RequestHandler,
+) RequestHandler {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor := interceptors[i]
+		next := handler
+		handler = func(
+			ctx context.Context,
+			connection Connection,
+			message Message,
+		) (Payload, error) {
+			return interceptor(ctx, connection, message, next)
+		}
+	}
+	return handler
+}
+
+// chainSignalInterceptors wraps the given signal handler into the
+// given interceptors. The first interceptor is the outermost one
+// and is thus invoked first
+func chainSignalInterceptors(
+	interceptors []SignalInterceptor,
+	handler SignalHandler,
+) SignalHandler {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor := interceptors[i]
+		next := handler
+		handler = func(
+			ctx context.Context,
+			connection Connection,
+			message Message,
+		) {
+			interceptor(ctx, connection, message, next)
+		}
+	}
+	return handler
+}
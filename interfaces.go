@@ -17,6 +17,20 @@ type Server interface {
 	// or crashes returning an error
 	Run() error
 
+	// RunTLS will launch the webwire server serving TLS connections
+	// blocking the calling goroutine just like Run.
+	// The certificate and key files are optional
+	// if ServerOptions.TLSConfig already provides a certificate
+	RunTLS(certFile, keyFile string) error
+
+	// ServeRaw serves the raw connections accepted by the given listener
+	// blocking the calling goroutine until either the server is shut down
+	// or the listener fails. Raw connections frame the messages directly
+	// over the stream without HTTP and WebSockets,
+	// clients must connect using sockets created by NewRawSocket.
+	// The listener is closed when the server shuts down
+	ServeRaw(listener net.Listener) error
+
 	// Addr returns the address the webwire server is listening on
 	Addr() net.Addr
 
@@ -26,8 +40,38 @@ type Server interface {
 	// During the shutdown incoming connections are rejected
 	// with 503 service unavailable.
 	// Incoming requests are rejected with an error while incoming signals
-	// are just ignored
-	Shutdown() error
+	// are just ignored.
+	// The contexts of all currently running handlers are canceled
+	// as soon as the shutdown begins.
+	// All connected clients are notified about the shutdown as soon as
+	// it begins and are disconnected when all handlers returned.
+	// If the given context is canceled or exceeds its deadline before
+	// all handlers returned then the remaining handlers are abandoned,
+	// all connections are closed immediately and the context error
+	// is returned translated to a webwire error type
+	Shutdown(ctx context.Context) error
+
+	// Connection returns the currently connected client
+	// identified by the given connection identifier.
+	// Returns nil if there's no such connection
+	Connection(id uint64) Connection
+
+	// Connections returns a snapshot of all currently connected clients
+	Connections() []Connection
+
+	// ConnectionsNum returns the number of currently connected clients
+	ConnectionsNum() int
+
+	// ForEachConnection calls fn for each currently connected client
+	// matching the given filter until fn returns false.
+	// All connections are matched if the filter is nil.
+	// The iteration is performed on a snapshot of the connections
+	// and doesn't block the acceptance or closure of connections
+	ForEachConnection(filter ConnectionFilter, fn func(Connection) bool)
+
+	// HandlerStats returns a snapshot of the counters
+	// of currently running and queued handlers
+	HandlerStats() HandlerStats
 
 	// ActiveSessionsNum returns the number of currently active sessions
 	ActiveSessionsNum() int
@@ -39,9 +83,59 @@ type Server interface {
 	SessionConnections(sessionKey string) []Connection
 
 	// CloseSession closes the session identified by the given key
-	// and returns the number of closed connections.
-	// If there was no session found -1 is returned
+	// on all of its connections without closing the connections,
+	// notifies the clients about the closure
+	// and invokes the OnSessionClosed session manager hook once.
+	// Returns the number of connections the session was closed on.
+	// If the session isn't active -1 is returned and nothing is done
 	CloseSession(sessionKey string) int
+
+	// CloseSessionConnections closes all connections of the session
+	// identified by the given key and returns the number of closed connections.
+	// The session itself remains restorable.
+	// If there was no session found -1 is returned
+	CloseSessionConnections(sessionKey string) int
+
+	// UpdateSessionInfo replaces the info of the session identified
+	// by the given key persisting it through the session manager
+	// and synchronizes it to all connections of the session.
+	// Returns the error returned by the OnSessionUpdated session manager hook,
+	// the connections remain unchanged if it fails
+	UpdateSessionInfo(sessionKey string, info SessionInfo) error
+
+	// Publish publishes the given payload to all connections subscribed
+	// to the given topic. The publication is encoded only once
+	// regardless of the number of subscribers.
+	// Returns an error if either the topic or the payload is invalid,
+	// failed deliveries to individual subscribers are logged
+	Publish(topic string, payload Payload) error
+
+	// TopicSubscribersNum returns the number of connections
+	// currently subscribed to the given topic
+	TopicSubscribersNum(topic string) int
+
+	// SignalSession sends a named signal containing the given payload
+	// to all connections of the session identified by the given key.
+	// The signal is encoded only once regardless of the number of connections.
+	// Returns the delivery errors mapped by the connections they occurred on
+	// or nil if the signal was delivered to all connections
+	SignalSession(
+		sessionKey string,
+		name string,
+		payload Payload,
+	) map[Connection]error
+
+	// Broadcast sends a named signal containing the given payload
+	// to all connections matching the given filter.
+	// The signal is sent to all connections if the filter is nil.
+	// The signal is encoded only once regardless of the number of connections.
+	// Returns the delivery errors mapped by the connections they occurred on
+	// or nil if the signal was delivered to all connections
+	Broadcast(
+		name string,
+		payload Payload,
+		filter ConnectionFilter,
+	) map[Connection]error
 }
 
 // ServerImplementation defines the interface of a webwire server implementation
@@ -53,7 +147,11 @@ type ServerImplementation interface {
 	// BeforeUpgrade is invoked right before the upgrade of an incoming HTTP connection request to
 	// a WebSocket connection and can be used to intercept or prevent connection attempts.
 	// If true is returned then the connection is normally established, though if false is returned
-	// then the connection won't be established and will be canceled immediately
+	// then the connection won't be established and will be canceled immediately.
+	// Raw connections served by ServeRaw are passed a request synthesized
+	// from their handshake carrying the remote address, the user agent string
+	// and the TLS connection state, anything written to resp is sent
+	// to the rejected client as the reason
 	BeforeUpgrade(resp http.ResponseWriter, req *http.Request) bool
 
 	// OnClientConnected is invoked when a new client successfully established a connection
@@ -71,6 +169,8 @@ type ServerImplementation interface {
 	OnClientDisconnected(client Connection)
 
 	// OnSignal is invoked when the webwire server receives a signal from a client.
+	// The given context is canceled when the client disconnects,
+	// the connection is closed or the server begins shutting down.
 	//
 	// This hook will be invoked by the goroutine serving the calling client and will block any
 	// other interactions with this client while executing
@@ -78,6 +178,8 @@ type ServerImplementation interface {
 
 	// OnRequest is invoked when the webwire server receives a request from a client.
 	// It must return either a response payload or an error.
+	// The given context is canceled when the client disconnects,
+	// the connection is closed or the server begins shutting down.
 	//
 	// A webwire.ReqErr error can be returned to reply with an error code and an error message,
 	// this is useful when the clients user code needs to be able to understand the error
@@ -98,17 +200,32 @@ type ServerImplementation interface {
 
 // Connection represents a connected client
 type Connection interface {
+	// ID returns the identifier of this connection
+	// which is unique for the lifetime of the server
+	ID() uint64
+
 	// IsActive returns true if this connection is in active state
 	// ready to accept incoming messages, otherwise returns false
 	IsActive() bool
 
 	// Info returns information about this connection including the
-	// client agent string, the remote address and the time of creation
+	// client agent string, the remote address, the time of creation
+	// and the TLS client certificate if any
 	Info() ClientInfo
 
 	// Signal sends a named signal containing the given payload to the client
 	Signal(name string, payload Payload) error
 
+	// Request sends a named request containing the given payload
+	// to the client and blocks the calling goroutine until either
+	// the reply is received, the request times out, the given context
+	// is canceled or the connection is closed.
+	// The request times out after the configured
+	// ServerOptions.DefaultRequestTimeout.
+	// Request will respect cancelable and timed contexts,
+	// nil contexts are also supported
+	Request(ctx context.Context, name string, payload Payload) (Payload, error)
+
 	// CreateSession creates a new session for this connection and
 	// automatically synchronizes the new session to the remote client.
 	// The synchronization happens asynchronously using a signal
@@ -116,12 +233,36 @@ type Connection interface {
 	// Returns an error if there's already another session active
 	CreateSession(attachment SessionInfo) error
 
+	// CreateSessionWithExpiration creates a new session just like
+	// CreateSession does but overrides the ServerOptions.SessionExpiration
+	// policy with the given one, such as a longer lifetime
+	// for "remember me" sessions
+	CreateSessionWithExpiration(
+		attachment SessionInfo,
+		expiration SessionExpiration,
+	) error
+
 	// CloseSession disables the currently active session for this connection
 	// and synchronize the closure to the remote client.
 	// The session will be destroyed if this is it's last connection remaining.
 	// Does nothing if there's no active session
 	CloseSession() error
 
+	// UpdateSessionInfo replaces the info of the currently active session
+	// and synchronizes it to all connections of the session
+	// just like Server.UpdateSessionInfo does.
+	// Returns an error if there's no active session
+	UpdateSessionInfo(info SessionInfo) error
+
+	// RotateSessionKey replaces the key of the currently active session
+	// with a new key generated by the session key generator,
+	// for example after a privilege change to prevent session fixation.
+	// The session is renamed through the OnSessionKeyRotated session manager
+	// hook and all connections of the session are notified about the new key.
+	// The old key becomes invalid immediately.
+	// Returns an error if there's no active session
+	RotateSessionKey() error
+
 	// HasSession returns true if this connection currently has
 	// a session assigned, otherwise returns false
 	HasSession() bool
@@ -145,9 +286,27 @@ type Connection interface {
 	// in the form of an empty interface to be casted to either concrete type
 	SessionInfo(name string) interface{}
 
+	// Subscribe subscribes this connection to the given topic
+	// making it receive all payloads published to it.
+	// Subscribing to an already subscribed topic does nothing.
+	// Subscriptions are automatically removed when the connection is closed.
+	// Returns an error if the topic is invalid
+	// or the connection is no longer active
+	Subscribe(topic string) error
+
+	// Unsubscribe removes the subscription of this connection
+	// to the given topic. Does nothing if there's no such subscription
+	Unsubscribe(topic string)
+
+	// Subscriptions returns the list of topics
+	// this connection is currently subscribed to
+	Subscriptions() []string
+
 	// Close marks this connection for shutdown.
 	// It defers closing the connection until all work on it is done
 	// and removes it from the session registry.
+	// The contexts of all currently running handlers of this connection
+	// are canceled immediately.
 	// Does nothing when called multiple times
 	Close()
 }
@@ -156,6 +315,7 @@ type Connection interface {
 type SessionLookupResult struct {
 	Creation   time.Time
 	LastLookup time.Time
+	Expiration SessionExpiration
 	Info       map[string]interface{}
 }
 
@@ -179,7 +339,11 @@ type SessionManager interface {
 	// otherwise it must first update the LastLookup field of the session
 	// to ensure it's not garbage collected and then return
 	// a webwire.SessionLookupResult object containing the time of the sessions
-	// creation and the exact copy of the session info object.
+	// creation, its expiration policy and the exact copy of the session info
+	// object.
+	// Sessions expired according to their expiration policy must be reported
+	// as not found, the server rejects the restoration of expired sessions
+	// that are returned nonetheless.
 	//
 	// If an error (that's not a webwire.SessNotFoundErr) is returned then
 	// it'll be logged and the session restoration will fail.
@@ -191,19 +355,64 @@ type SessionManager interface {
 	// session object then the session garbage collection won't work properly
 	OnSessionLookup(key string) (result SessionLookupResult, err error)
 
+	// OnSessionUpdated is invoked when the info of the session associated
+	// with the given key is replaced. The new info must be persisted
+	// and returned by subsequent lookups.
+	// If the session wasn't found it must return a webwire.SessNotFoundErr.
+	// If an error is returned then the update is aborted and the error
+	// is returned to the caller of the update.
+	//
+	// This hook is invoked by the goroutine calling either
+	// Connection.UpdateSessionInfo or Server.UpdateSessionInfo
+	OnSessionUpdated(sessionKey string, info SessionInfo) error
+
+	// OnSessionKeyRotated is invoked when the key of the session associated
+	// with the old key is replaced by the new key.
+	// The session must be renamed permanently so that it's no longer
+	// discoverable by the old key in the OnSessionLookup hook.
+	// If the session wasn't found it must return a webwire.SessNotFoundErr.
+	// If an error is returned then the rotation is aborted and the error
+	// is returned to the caller of Connection.RotateSessionKey.
+	//
+	// This hook is invoked by the goroutine calling
+	// Connection.RotateSessionKey
+	OnSessionKeyRotated(oldKey, newKey string) error
+
 	// OnSessionClosed is invoked when the session associated with the given key
 	// is closed (thus destroyed) either by the server or the client.
 	// A closed session must be permanently deleted and must not be discoverable
 	// in the OnSessionLookup hook any longer.
-	// If an error is returned then the it is logged.
+	// If the session wasn't found it should return a webwire.SessNotFoundErr,
+	// any other returned error is logged.
 	//
 	// This hook is invoked by either a goroutine calling the method
-	// connection.CloseSession(), or the goroutine serving the associated client,
+	// Server.CloseSession(), the expiry timer of the session,
+	// or the goroutine serving the associated client,
 	// in the case of which it will block any other interactions with
 	// this client while executing
 	OnSessionClosed(sessionKey string) error
 }
 
+// SessionActivityObserver defines the optional interface of session managers
+// observing the activity of sessions. The server invokes the hook
+// of session managers implementing it
+type SessionActivityObserver interface {
+	// OnSessionActivityChanged is invoked when the session associated
+	// with the given key becomes active by being attached to its first
+	// connection or inactive by being detached from its last connection.
+	// Active sessions are in use and must not expire due to idleness,
+	// the idle period of a session begins when it becomes inactive,
+	// which is why the LastLookup field of the session must be updated
+	// when it becomes inactive.
+	// If the session wasn't found it should return a webwire.SessNotFoundErr,
+	// any other returned error is logged.
+	//
+	// This hook is invoked while the session is being either attached or
+	// detached and must therefore not attach, detach, update or rotate
+	// sessions itself
+	OnSessionActivityChanged(sessionKey string, active bool) error
+}
+
 // SessionKeyGenerator defines the interface of a webwire servers session key generator.
 // This interface must not be implemented (!) unless the default generator doesn't meet the exact
 // needs of the library user, because the default generator already provides a secure implementation
@@ -246,6 +455,33 @@ type SessionInfo interface {
 // from the data given
 type SessionInfoParser func(map[string]interface{}) SessionInfo
 
+// SubscriptionFilter represents the type of a topic subscription filter
+// function. The subscription filter is invoked when a client requests
+// the subscription to a topic and must return an error
+// to reject the subscription.
+// A webwire.ReqErr error can be returned to reply with an error code
+// and an error message, any other error type is replied
+// as an internal error
+type SubscriptionFilter func(client Connection, topic string) error
+
+// OriginFilter represents the type of an origin filter function.
+// The origin filter is invoked for requests carrying an Origin header
+// that isn't allowed by ServerOptions.AllowedOrigins
+// and must return true to allow the request
+type OriginFilter func(origin string, req *http.Request) bool
+
+// Propagator propagates values of contexts across process boundaries
+// through message headers
+type Propagator interface {
+	// Inject writes the values carried by the given context
+	// into the given headers
+	Inject(ctx context.Context, headers map[string]string)
+
+	// Extract returns a new context derived from the given one
+	// carrying the values read from the given headers
+	Extract(ctx context.Context, headers map[string]string) context.Context
+}
+
 // Payload represents a WebWire message payload
 type Payload interface {
 	// Encoding returns the payload encoding type
@@ -269,6 +505,10 @@ type Message interface {
 	// Name returns the name of the message
 	Name() string
 
+	// Headers returns the headers of the message if any.
+	// The returned map must not be modified
+	Headers() map[string]string
+
 	// Payload returns the message payload
 	Payload() Payload
 }
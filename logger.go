@@ -0,0 +1,100 @@
+package webwire
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// Logger defines the interface of a leveled structured logger.
+// The variadic fields are alternating keys and values,
+// where the keys must be strings.
+// All methods must be thread safe
+type Logger interface {
+	// Debug logs a message with debug level
+	Debug(message string, fields ...interface{})
+
+	// Info logs a message with info level
+	Info(message string, fields ...interface{})
+
+	// Warn logs a message with warning level
+	Warn(message string, fields ...interface{})
+
+	// Error logs a message with error level
+	Error(message string, fields ...interface{})
+}
+
+// StdLogger implements the Logger interface on top of the standard library
+// loggers writing each level to the corresponding logger.
+// Messages are formatted as the message followed by key=value pairs.
+// Messages of levels without a logger are discarded
+type StdLogger struct {
+	DebugLog *log.Logger
+	InfoLog  *log.Logger
+	WarnLog  *log.Logger
+	ErrorLog *log.Logger
+}
+
+// NewStdLogger returns a new Logger writing warnings to the given
+// warning logger and errors to the given error logger.
+// Debug and info messages are discarded
+func NewStdLogger(warnLog, errorLog *log.Logger) *StdLogger {
+	return &StdLogger{
+		WarnLog:  warnLog,
+		ErrorLog: errorLog,
+	}
+}
+
+// Debug implements the Logger interface
+func (sl *StdLogger) Debug(message string, fields ...interface{}) {
+	writeLog(sl.DebugLog, message, fields)
+}
+
+// Info implements the Logger interface
+func (sl *StdLogger) Info(message string, fields ...interface{}) {
+	writeLog(sl.InfoLog, message, fields)
+}
+
+// Warn implements the Logger interface
+func (sl *StdLogger) Warn(message string, fields ...interface{}) {
+	writeLog(sl.WarnLog, message, fields)
+}
+
+// Error implements the Logger interface
+func (sl *StdLogger) Error(message string, fields ...interface{}) {
+	writeLog(sl.ErrorLog, message, fields)
+}
+
+// writeLog writes the formatted message to the given logger
+// reporting the caller of the logging method as the origin
+func writeLog(logger *log.Logger, message string, fields []interface{}) {
+	if logger == nil {
+		return
+	}
+	logger.Output(3, FormatLogFields(message, fields...))
+}
+
+// FormatLogFields formats the given message and fields
+// as the message followed by space separated key=value pairs.
+// Values containing spaces or quotes are quoted,
+// a trailing key without a value is paired with "(MISSING)"
+func FormatLogFields(message string, fields ...interface{}) string {
+	buf := bytes.NewBufferString(message)
+	for i := 0; i < len(fields); i += 2 {
+		buf.WriteByte(' ')
+		buf.WriteString(fmt.Sprint(fields[i]))
+		buf.WriteByte('=')
+		if i+1 >= len(fields) {
+			buf.WriteString("(MISSING)")
+			break
+		}
+		value := fmt.Sprint(fields[i+1])
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		buf.WriteString(value)
+	}
+	return buf.String()
+}
@@ -0,0 +1,49 @@
+package webwire
+
+import (
+	"bytes"
+	"log"
+	"testing"
+)
+
+// TestFormatLogFields tests the formatting of log messages and fields
+func TestFormatLogFields(t *testing.T) {
+	cases := []struct {
+		fields   []interface{}
+		expected string
+	}{
+		{nil, "msg"},
+		{[]interface{}{"connection", 4}, "msg connection=4"},
+		{
+			[]interface{}{"request", "get user", "session", ""},
+			`msg request="get user" session=""`,
+		},
+		{[]interface{}{"error", `say "hi"`}, `msg error="say \"hi\""`},
+		{[]interface{}{"topic"}, "msg topic=(MISSING)"},
+	}
+	for _, c := range cases {
+		if actual := FormatLogFields("msg", c.fields...); actual != c.expected {
+			t.Errorf("Expected %q, got: %q", c.expected, actual)
+		}
+	}
+}
+
+// TestStdLoggerLevels tests whether the standard logger adapter writes
+// each level to the corresponding logger and discards undefined levels
+func TestStdLoggerLevels(t *testing.T) {
+	warnBuf := &bytes.Buffer{}
+	errBuf := &bytes.Buffer{}
+	logger := NewStdLogger(log.New(warnBuf, "", 0), log.New(errBuf, "", 0))
+
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warning", "connection", 1)
+	logger.Error("failure", "error", "broken")
+
+	if warnBuf.String() != "warning connection=1\n" {
+		t.Errorf("Unexpected warning log output: %q", warnBuf.String())
+	}
+	if errBuf.String() != "failure error=broken\n" {
+		t.Errorf("Unexpected error log output: %q", errBuf.String())
+	}
+}
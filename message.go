@@ -11,6 +11,14 @@ type MessageWrapper struct {
 	actual *msg.Message
 }
 
+// NewMessageWrapper wraps the given parsed message
+// to make it implement the Message interface
+func NewMessageWrapper(message *msg.Message) *MessageWrapper {
+	return &MessageWrapper{
+		actual: message,
+	}
+}
+
 // MessageType implements the Message interface
 func (wrp *MessageWrapper) MessageType() byte {
 	return wrp.actual.Type
@@ -26,6 +34,11 @@ func (wrp *MessageWrapper) Name() string {
 	return wrp.actual.Name
 }
 
+// Headers implements the Message interface
+func (wrp *MessageWrapper) Headers() map[string]string {
+	return wrp.actual.Headers
+}
+
 // Payload implements the Message interface
 func (wrp *MessageWrapper) Payload() Payload {
 	return &EncodedPayload{
@@ -53,6 +53,23 @@ func TestMsgNewEmptyReqMsg(t *testing.T) {
 	}
 }
 
+// TestMsgNewCancelReqMsg tests NewCancelRequestMessage
+func TestMsgNewCancelReqMsg(t *testing.T) {
+	id := genRndMsgIdentifier()
+
+	// Compose encoded message
+	// Add type flag
+	expected := []byte{MsgCancelRequest}
+	// Add identifier
+	expected = append(expected, id[:]...)
+
+	actual := NewCancelRequestMessage(id)
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Binary results differ:\n%v\n%v", expected, actual)
+	}
+}
+
 // TestMsgNewReqMsgBinary tests NewRequestMessage
 // using default binary payload encoding
 func TestMsgNewReqMsgBinary(t *testing.T) {
@@ -335,6 +352,36 @@ func TestMsgNewSigMsgUtf8(t *testing.T) {
 	}
 }
 
+// TestMsgNewPublicationMsgUtf8 tests NewPublicationMessage
+// using UTF8 encoding
+func TestMsgNewPublicationMsgUtf8(t *testing.T) {
+	topic := genRndName(1, 255)
+	payload := pld.Payload{
+		Encoding: pld.Utf8,
+		Data:     []byte("random payload data"),
+	}
+
+	// Compose encoded message
+	// Add type flag
+	expected := []byte{MsgPublicationUtf8}
+	// Add topic length flag
+	expected = append(expected, byte(len(topic)))
+	// Add topic
+	expected = append(expected, []byte(topic)...)
+	// Add payload
+	expected = append(expected, payload.Data...)
+
+	actual := NewPublicationMessage(
+		string(topic),
+		payload.Encoding,
+		payload.Data,
+	)
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Binary results differ:\n%v\n%v", expected, actual)
+	}
+}
+
 // TestMsgNewSigMsgUtf16 tests NewSignalMessage using UTF16 encoding
 func TestMsgNewSigMsgUtf16(t *testing.T) {
 	name := genRndName(1, 255)
@@ -398,3 +445,57 @@ func TestMsgNewSigMsgUtf16OddNameLen(t *testing.T) {
 		t.Fatalf("Binary results differ:\n%v\n%v", expected, actual)
 	}
 }
+
+// TestMsgNewHeadersMsg tests NewHeadersMessage
+func TestMsgNewHeadersMsg(t *testing.T) {
+	wrapped := NewSignalMessage("sig", pld.Utf8, []byte("data"))
+
+	// Compose encoded message
+	// Add type flag
+	expected := []byte{MsgHeaders}
+	// Add headers section length
+	expected = append(expected, 0, 13)
+	// Add number of headers
+	expected = append(expected, 2)
+	// Add headers sorted by key
+	expected = append(expected, 1, 'a', 0, 0)
+	expected = append(expected, 2, 'b', 'b', 0, 3, 'x', 'y', 'z')
+	// Add wrapped message
+	expected = append(expected, wrapped...)
+
+	actual := NewHeadersMessage(
+		map[string]string{"bb": "xyz", "a": ""},
+		wrapped,
+	)
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Binary results differ:\n%v\n%v", expected, actual)
+	}
+
+	// Expect messages without headers to remain unwrapped
+	if actual := NewHeadersMessage(nil, wrapped); !reflect.DeepEqual(
+		wrapped,
+		actual,
+	) {
+		t.Fatalf("Binary results differ:\n%v\n%v", wrapped, actual)
+	}
+}
+
+// TestMsgNewGoingAwayMsg tests NewGoingAwayMessage
+func TestMsgNewGoingAwayMsg(t *testing.T) {
+	address := "other.host:8081"
+
+	// Compose encoded message
+	// Add type flag
+	expected := []byte{MsgGoingAway}
+	// Add reconnection delay
+	expected = append(expected, 0, 1, 0x86, 0xa0)
+	// Add address
+	expected = append(expected, []byte(address)...)
+
+	actual := NewGoingAwayMessage(100000, address)
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Binary results differ:\n%v\n%v", expected, actual)
+	}
+}
@@ -76,16 +76,74 @@ const (
 	//  2. message id (8 bytes)
 	MsgMinLenCloseSession = int(9)
 
+	// MsgMinLenCancelRequest represents the minimum request cancelation message length
+	// Request cancelation message structure:
+	//  1. message type (1 byte)
+	//  2. message id (8 bytes)
+	MsgMinLenCancelRequest = int(9)
+
+	// MsgMinLenSubscribe represents the minimum topic subscription request message length
+	// Topic subscription request message structure:
+	//  1. message type (1 byte)
+	//  2. message id (8 bytes)
+	//  3. topic (n bytes, 7-bit ASCII encoded, at least 1 byte)
+	MsgMinLenSubscribe = int(10)
+
+	// MsgMinLenUnsubscribe represents the minimum topic unsubscription request message length
+	// Topic unsubscription request message structure:
+	//  1. message type (1 byte)
+	//  2. message id (8 bytes)
+	//  3. topic (n bytes, 7-bit ASCII encoded, at least 1 byte)
+	MsgMinLenUnsubscribe = int(10)
+
+	// MsgMinLenHeaders represents the minimum headers message length
+	// Headers message structure:
+	//  1. message type (1 byte)
+	//  2. headers section length (2 bytes, big endian)
+	//  3. headers section:
+	//     3.1. number of headers (1 byte, at least 1)
+	//     3.2. headers, each consisting of:
+	//          3.2.1. key length flag (1 byte, at least 1)
+	//          3.2.2. key (n bytes, 7-bit ASCII encoded)
+	//          3.2.3. value length (2 bytes, big endian)
+	//          3.2.4. value (n bytes, UTF8 encoded)
+	//  4. the wrapped signal or request message (n bytes)
+	MsgMinLenHeaders = int(11)
+
+	// MsgMinLenGoingAway represents the minimum going away notification message length
+	// Going away notification message structure:
+	//  1. message type (1 byte)
+	//  2. reconnection delay in milliseconds (4 bytes, big endian)
+	//  3. alternative server address (n bytes, 7-bit ASCII encoded, optional)
+	MsgMinLenGoingAway = int(5)
+
 	// MsgMinLenSessionCreated represents the minimum session creation notification message length
 	// Session creation notification message structure:
 	//  1. message type (1 byte)
 	//  2. session key (n bytes, 7-bit ASCII encoded, at least 1 byte)
 	MsgMinLenSessionCreated = int(2)
 
+	// MsgMinLenSessionUpdated represents the minimum session update notification message length
+	// Session update notification message structure:
+	//  1. message type (1 byte)
+	//  2. JSON encoded session (n bytes, UTF8 encoded, at least 1 byte)
+	MsgMinLenSessionUpdated = int(2)
+
+	// MsgMinLenSessionKeyRotated represents the minimum session key rotation notification message length
+	// Session key rotation notification message structure:
+	//  1. message type (1 byte)
+	//  2. new session key (n bytes, 7-bit ASCII encoded, at least 1 byte)
+	MsgMinLenSessionKeyRotated = int(2)
+
 	// MsgMinLenSessionClosed represents the minimum session creation notification message length
 	// Session destruction notification message structure:
 	//  1. message type (1 byte)
+	//  2. closure reason code (1 byte, optional)
 	MsgMinLenSessionClosed = int(1)
+
+	// MsgMaxLenSessionClosed represents the maximum session destruction
+	// notification message length including the closure reason code
+	MsgMaxLenSessionClosed = int(2)
 )
 
 const (
@@ -127,6 +185,36 @@ const (
 	// to notify the client about the session destruction
 	MsgSessionClosed = byte(22)
 
+	// MsgPublicationBinary is sent by the server
+	// to deliver a message with a binary payload published to a topic
+	// the client is subscribed to.
+	// Publications share the structure of signals
+	// while the name field carries the topic
+	MsgPublicationBinary = byte(23)
+
+	// MsgPublicationUtf8 is sent by the server
+	// to deliver a message with a UTF8 encoded payload published to a topic
+	// the client is subscribed to
+	MsgPublicationUtf8 = byte(24)
+
+	// MsgPublicationUtf16 is sent by the server
+	// to deliver a message with a UTF16 encoded payload published to a topic
+	// the client is subscribed to
+	MsgPublicationUtf16 = byte(25)
+
+	// MsgGoingAway is sent by the server
+	// to notify the client about the server shutting down
+	// advising it to reconnect after a delay, optionally to another address
+	MsgGoingAway = byte(26)
+
+	// MsgSessionUpdated is sent by the server
+	// to notify the client about an update of the session info
+	MsgSessionUpdated = byte(27)
+
+	// MsgSessionKeyRotated is sent by the server
+	// to notify the client about the new key of its session
+	MsgSessionKeyRotated = byte(28)
+
 	// CLIENT
 
 	// MsgCloseSession is sent by the client
@@ -137,6 +225,23 @@ const (
 	// to request session restoration
 	MsgRestoreSession = byte(32)
 
+	// MsgCancelRequest is sent by the client
+	// to notify the server about the cancelation of a previously sent request
+	// that the client is no longer awaiting a reply for
+	MsgCancelRequest = byte(33)
+
+	// MsgSubscribe is sent by the client
+	// to request the subscription to a topic
+	MsgSubscribe = byte(34)
+
+	// MsgUnsubscribe is sent by the client
+	// to request the unsubscription from a topic
+	MsgUnsubscribe = byte(35)
+
+	// MsgHeaders is sent by the client
+	// and wraps a signal or request message prefixing it with headers
+	MsgHeaders = byte(36)
+
 	// SIGNAL
 	// Signals are sent by both the client and the server
 	// and represents a one-way signal message that doesn't require a reply
@@ -182,6 +287,7 @@ type Message struct {
 	Type       byte
 	Identifier [8]byte
 	Name       string
+	Headers    map[string]string
 	Payload    pld.Payload
 }
 
@@ -193,6 +299,10 @@ func (msg *Message) RequiresReply() bool {
 		fallthrough
 	case MsgRestoreSession:
 		fallthrough
+	case MsgSubscribe:
+		fallthrough
+	case MsgUnsubscribe:
+		fallthrough
 	case MsgRequestBinary:
 		fallthrough
 	case MsgRequestUtf8:
@@ -0,0 +1,18 @@
+package message
+
+// NewCancelRequestMessage composes a new request cancelation message
+// referring to the request identified by the given identifier
+// and returns its binary representation
+func NewCancelRequestMessage(reqIdent [8]byte) (msg []byte) {
+	msg = make([]byte, MsgMinLenCancelRequest)
+
+	// Write message type flag
+	msg[0] = MsgCancelRequest
+
+	// Write request identifier
+	for i := 0; i < 8; i++ {
+		msg[1+i] = reqIdent[i]
+	}
+
+	return msg
+}
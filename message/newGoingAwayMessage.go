@@ -0,0 +1,49 @@
+package message
+
+import (
+	"fmt"
+)
+
+// NewGoingAwayMessage composes a new going away notification message
+// advising the client to reconnect after the given delay in milliseconds
+// to the given alternative server address if any
+// and returns its binary representation
+func NewGoingAwayMessage(
+	reconnectDelay uint32,
+	address string,
+) (msg []byte) {
+	msg = make([]byte, MsgMinLenGoingAway+len(address))
+
+	// Write message type flag
+	msg[0] = MsgGoingAway
+
+	// Write reconnection delay
+	msg[1] = byte(reconnectDelay >> 24)
+	msg[2] = byte(reconnectDelay >> 16)
+	msg[3] = byte(reconnectDelay >> 8)
+	msg[4] = byte(reconnectDelay)
+
+	// Write alternative server address
+	for i := 0; i < len(address); i++ {
+		char := address[i]
+		if char < 32 || char > 126 {
+			panic(fmt.Errorf("Unsupported character in address: %s", string(char)))
+		}
+		msg[MsgMinLenGoingAway+i] = char
+	}
+
+	return msg
+}
+
+// GoingAwayReconnectDelay returns the reconnection delay in milliseconds
+// of the given parsed going away notification message
+func GoingAwayReconnectDelay(message *Message) uint32 {
+	data := message.Payload.Data
+	if len(data) < 4 {
+		return 0
+	}
+	return uint32(data[0])<<24 |
+		uint32(data[1])<<16 |
+		uint32(data[2])<<8 |
+		uint32(data[3])
+}
@@ -0,0 +1,102 @@
+package message
+
+import (
+	"fmt"
+	"sort"
+)
+
+// NewHeadersMessage wraps the given binary signal or request message
+// prefixing it with the given headers and returns its binary representation.
+// Returns the given message unchanged if there are no headers
+func NewHeadersMessage(
+	headers map[string]string,
+	message []byte,
+) (msg []byte) {
+	if len(headers) < 1 {
+		return message
+	}
+	if len(headers) > 255 {
+		panic(fmt.Errorf("Unsupported number of headers: %d", len(headers)))
+	}
+	if len(message) < 1 || !isHeadersWrappable(message[0]) {
+		panic(fmt.Errorf("Only signal and request messages can carry headers"))
+	}
+
+	// Write the headers in a deterministic order
+	keys := make([]string, 0, len(headers))
+	sectionSize := 1
+	for key, value := range headers {
+		if len(key) < 1 || len(key) > 255 {
+			panic(fmt.Errorf("Unsupported header key length: %d", len(key)))
+		}
+		if len(value) > 65535 {
+			panic(fmt.Errorf("Unsupported header value length: %d", len(value)))
+		}
+		keys = append(keys, key)
+		sectionSize += 3 + len(key) + len(value)
+	}
+	if sectionSize > 65535 {
+		panic(fmt.Errorf("Unsupported headers section length: %d", sectionSize))
+	}
+	sort.Strings(keys)
+
+	msg = make([]byte, 3+sectionSize+len(message))
+
+	// Write message type flag
+	msg[0] = MsgHeaders
+
+	// Write headers section length
+	msg[1] = byte(sectionSize >> 8)
+	msg[2] = byte(sectionSize)
+
+	// Write number of headers
+	msg[3] = byte(len(keys))
+
+	offset := 4
+	for _, key := range keys {
+		value := headers[key]
+
+		// Write key length flag and key
+		msg[offset] = byte(len(key))
+		offset++
+		for i := 0; i < len(key); i++ {
+			char := key[i]
+			if char < 33 || char > 126 {
+				panic(fmt.Errorf("Unsupported character in header key: %s", string(char)))
+			}
+			msg[offset+i] = char
+		}
+		offset += len(key)
+
+		// Write value length and value
+		msg[offset] = byte(len(value) >> 8)
+		msg[offset+1] = byte(len(value))
+		offset += 2
+		offset += copy(msg[offset:], value)
+	}
+
+	// Write wrapped message
+	copy(msg[offset:], message)
+
+	return msg
+}
+
+// isHeadersWrappable returns true if messages of the given type
+// can be wrapped by a headers message
+func isHeadersWrappable(msgType byte) bool {
+	switch msgType {
+	case MsgSignalBinary:
+		fallthrough
+	case MsgSignalUtf8:
+		fallthrough
+	case MsgSignalUtf16:
+		fallthrough
+	case MsgRequestBinary:
+		fallthrough
+	case MsgRequestUtf8:
+		fallthrough
+	case MsgRequestUtf16:
+		return true
+	}
+	return false
+}
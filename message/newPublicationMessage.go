@@ -0,0 +1,35 @@
+package message
+
+import (
+	"fmt"
+
+	pld "github.com/qbeon/webwire-go/payload"
+)
+
+// NewPublicationMessage composes a new publication message
+// delivering the given payload published to the given topic
+// and returns its binary representation
+func NewPublicationMessage(
+	topic string,
+	payloadEncoding pld.Encoding,
+	payloadData []byte,
+) (msg []byte) {
+	if len(topic) < 1 {
+		panic(fmt.Errorf("Missing publication topic"))
+	}
+
+	// Publications share the structure of signals
+	msg = NewSignalMessage(topic, payloadEncoding, payloadData)
+
+	// Overwrite message type flag
+	switch msg[0] {
+	case MsgSignalBinary:
+		msg[0] = MsgPublicationBinary
+	case MsgSignalUtf8:
+		msg[0] = MsgPublicationUtf8
+	case MsgSignalUtf16:
+		msg[0] = MsgPublicationUtf16
+	}
+
+	return msg
+}
@@ -0,0 +1,17 @@
+package message
+
+// NewSessionClosedMessage composes a new session closure notification message
+// carrying the given closure reason code and returns its binary representation
+func NewSessionClosedMessage(reason byte) []byte {
+	return []byte{MsgSessionClosed, reason}
+}
+
+// SessionClosedReason returns the closure reason code of the given parsed
+// session closure notification message.
+// Returns 0 if the message doesn't carry a reason code
+func SessionClosedReason(message *Message) byte {
+	if len(message.Payload.Data) < 1 {
+		return 0
+	}
+	return message.Payload.Data[0]
+}
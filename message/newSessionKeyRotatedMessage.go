@@ -0,0 +1,33 @@
+package message
+
+import (
+	"fmt"
+)
+
+// NewSessionKeyRotatedMessage composes a new session key rotation
+// notification message carrying the given new session key
+// and returns its binary representation
+func NewSessionKeyRotatedMessage(sessionKey string) (msg []byte) {
+	if len(sessionKey) < 1 {
+		panic(fmt.Errorf("Missing session key"))
+	}
+
+	msg = make([]byte, 1+len(sessionKey))
+
+	// Write message type flag
+	msg[0] = MsgSessionKeyRotated
+
+	// Write new session key
+	for i := 0; i < len(sessionKey); i++ {
+		char := sessionKey[i]
+		if char < 32 || char > 126 {
+			panic(fmt.Errorf(
+				"Unsupported character in session key: %s",
+				string(char),
+			))
+		}
+		msg[1+i] = char
+	}
+
+	return msg
+}
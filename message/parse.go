@@ -26,10 +26,22 @@ func (msg *Message) Parse(message []byte) (parsedMsgType bool, err error) {
 	case MsgSessionCreated:
 		err = msg.parseSessionCreated(message)
 
+	// Session update notification message
+	case MsgSessionUpdated:
+		err = msg.parseSessionUpdated(message)
+
+	// Session key rotation notification message
+	case MsgSessionKeyRotated:
+		err = msg.parseSessionKeyRotated(message)
+
 	// Session closure notification message
 	case MsgSessionClosed:
 		err = msg.parseSessionClosed(message)
 
+	// Going away notification message
+	case MsgGoingAway:
+		err = msg.parseGoingAway(message)
+
 	// Session destruction request message
 	case MsgCloseSession:
 		err = msg.parseCloseSession(message)
@@ -45,6 +57,17 @@ func (msg *Message) Parse(message []byte) (parsedMsgType bool, err error) {
 		payloadEncoding = pld.Utf16
 		err = msg.parseSignalUtf16(message)
 
+	// Publication messages
+	case MsgPublicationBinary:
+		payloadEncoding = pld.Binary
+		err = msg.parseSignal(message)
+	case MsgPublicationUtf8:
+		payloadEncoding = pld.Utf8
+		err = msg.parseSignal(message)
+	case MsgPublicationUtf16:
+		payloadEncoding = pld.Utf16
+		err = msg.parseSignalUtf16(message)
+
 	// Request messages
 	case MsgRequestBinary:
 		payloadEncoding = pld.Binary
@@ -71,6 +94,16 @@ func (msg *Message) Parse(message []byte) (parsedMsgType bool, err error) {
 	case MsgRestoreSession:
 		err = msg.parseRestoreSession(message)
 
+	// Request cancelation message
+	case MsgCancelRequest:
+		err = msg.parseCancelRequest(message)
+
+	// Topic subscription request messages
+	case MsgSubscribe:
+		err = msg.parseSubscribe(message)
+	case MsgUnsubscribe:
+		err = msg.parseUnsubscribe(message)
+
 	// Special reply messages
 	case MsgReplyShutdown:
 		err = msg.parseSpecialReplyMessage(message)
@@ -85,6 +118,10 @@ func (msg *Message) Parse(message []byte) (parsedMsgType bool, err error) {
 	case MsgReplyProtocolError:
 		err = msg.parseSpecialReplyMessage(message)
 
+	// Headers message wrapping a signal or request message
+	case MsgHeaders:
+		return msg.parseHeaders(message)
+
 	// Ignore messages of invalid message type
 	default:
 		return false, nil
@@ -286,6 +323,105 @@ func (msg *Message) parseRequestUtf16(message []byte) error {
 	return nil
 }
 
+// parseHeaders parses the given message assuming it's a headers message
+// and parses the wrapped signal or request message adopting its type.
+// The wrapped message is parsed first to adopt its type and identifier
+// even if the headers section is malformed
+func (msg *Message) parseHeaders(message []byte) (parsedMsgType bool, err error) {
+	msg.Type = MsgHeaders
+	if len(message) < 3 {
+		return true, fmt.Errorf("Invalid headers message, too short")
+	}
+
+	// Locate the wrapped message by the length of the headers section
+	sectionEnd := 3 + (int(message[1])<<8 | int(message[2]))
+	if len(message) < sectionEnd+1 || !isHeadersWrappable(message[sectionEnd]) {
+		return true, fmt.Errorf(
+			"Invalid headers message, missing signal or request message",
+		)
+	}
+
+	// Parse the wrapped message
+	if _, err := msg.Parse(message[sectionEnd:]); err != nil {
+		return true, err
+	}
+
+	if len(message) < MsgMinLenHeaders {
+		return true, fmt.Errorf("Invalid headers message, too short")
+	}
+	headers, err := parseHeadersSection(message[3:sectionEnd])
+	if err != nil {
+		return true, err
+	}
+	msg.Headers = headers
+	return true, nil
+}
+
+// parseHeadersSection parses the headers section of a headers message
+func parseHeadersSection(section []byte) (map[string]string, error) {
+	if len(section) < 1 {
+		return nil, fmt.Errorf("Invalid headers message, empty headers section")
+	}
+
+	// Read number of headers
+	headersNum := int(section[0])
+	if headersNum < 1 {
+		return nil, fmt.Errorf(
+			"Invalid headers message, number of headers is zero",
+		)
+	}
+
+	headers := make(map[string]string, headersNum)
+	offset := 1
+	for i := 0; i < headersNum; i++ {
+		// Verify total section size to prevent segmentation faults caused
+		// by inconsistent flags, this could happen if the specified lengths
+		// don't correspond to the actual lengths of the keys and values
+		if len(section) < offset+1 {
+			return nil, fmt.Errorf(
+				"Invalid headers message, too short for %d headers",
+				headersNum,
+			)
+		}
+
+		// Read key
+		keyLen := int(section[offset])
+		if keyLen < 1 {
+			return nil, fmt.Errorf(
+				"Invalid headers message, key length flag is zero",
+			)
+		}
+		offset++
+		if len(section) < offset+keyLen+2 {
+			return nil, fmt.Errorf(
+				"Invalid headers message, too short for full key (%d)",
+				keyLen,
+			)
+		}
+		key := string(section[offset : offset+keyLen])
+		offset += keyLen
+
+		// Read value
+		valueLen := int(section[offset])<<8 | int(section[offset+1])
+		offset += 2
+		if len(section) < offset+valueLen {
+			return nil, fmt.Errorf(
+				"Invalid headers message, too short for full value (%d)",
+				valueLen,
+			)
+		}
+		headers[key] = string(section[offset : offset+valueLen])
+		offset += valueLen
+	}
+
+	if offset != len(section) {
+		return nil, fmt.Errorf(
+			"Invalid headers message, headers section length mismatch",
+		)
+	}
+	return headers, nil
+}
+
 func (msg *Message) parseReply(message []byte) error {
 	if len(message) < MsgMinLenReply {
 		return fmt.Errorf("Invalid reply message, too short")
@@ -411,6 +547,53 @@ func (msg *Message) parseCloseSession(message []byte) error {
 	return nil
 }
 
+func (msg *Message) parseCancelRequest(message []byte) error {
+	if len(message) != MsgMinLenCancelRequest {
+		return fmt.Errorf("Invalid request cancelation message, invalid length")
+	}
+
+	// Read identifier
+	var id [8]byte
+	copy(id[:], message[1:9])
+	msg.Identifier = id
+
+	return nil
+}
+
+func (msg *Message) parseSubscribe(message []byte) error {
+	if len(message) < MsgMinLenSubscribe {
+		return fmt.Errorf("Invalid topic subscription request message, too short")
+	}
+
+	// Read identifier
+	var id [8]byte
+	copy(id[:], message[1:9])
+	msg.Identifier = id
+
+	// Read topic
+	msg.Payload = pld.Payload{
+		Data: message[9:],
+	}
+	return nil
+}
+
+func (msg *Message) parseUnsubscribe(message []byte) error {
+	if len(message) < MsgMinLenUnsubscribe {
+		return fmt.Errorf("Invalid topic unsubscription request message, too short")
+	}
+
+	// Read identifier
+	var id [8]byte
+	copy(id[:], message[1:9])
+	msg.Identifier = id
+
+	// Read topic
+	msg.Payload = pld.Payload{
+		Data: message[9:],
+	}
+	return nil
+}
+
 func (msg *Message) parseSessionCreated(message []byte) error {
 	if len(message) < MsgMinLenSessionCreated {
 		return fmt.Errorf("Invalid session creation notification message, too short")
@@ -422,9 +605,54 @@ func (msg *Message) parseSessionCreated(message []byte) error {
 	return nil
 }
 
+func (msg *Message) parseSessionUpdated(message []byte) error {
+	if len(message) < MsgMinLenSessionUpdated {
+		return fmt.Errorf("Invalid session update notification message, too short")
+	}
+
+	msg.Payload = pld.Payload{
+		Data: message[1:],
+	}
+	return nil
+}
+
+// parseSessionKeyRotated parses the given message assuming it's a session key
+// rotation notification message parsing the new session key into the name
+func (msg *Message) parseSessionKeyRotated(message []byte) error {
+	if len(message) < MsgMinLenSessionKeyRotated {
+		return fmt.Errorf("Invalid session key rotation notification message, too short")
+	}
+
+	msg.Name = string(message[1:])
+	return nil
+}
+
+// parseSessionClosed parses the given message assuming it's a session closure
+// notification message parsing the optional closure reason code
+// into the payload
 func (msg *Message) parseSessionClosed(message []byte) error {
-	if len(message) != MsgMinLenSessionClosed {
-		return fmt.Errorf("Invalid session closure notification message, too short")
+	if len(message) > MsgMaxLenSessionClosed {
+		return fmt.Errorf("Invalid session closure notification message, too long")
+	}
+	if len(message) > MsgMinLenSessionClosed {
+		msg.Payload = pld.Payload{
+			Data: message[1:2],
+		}
+	}
+	return nil
+}
+
+// parseGoingAway parses the given message assuming it's a going away
+// notification message parsing the alternative server address
+// into the name field and the reconnection delay into the payload
+func (msg *Message) parseGoingAway(message []byte) error {
+	if len(message) < MsgMinLenGoingAway {
+		return fmt.Errorf("Invalid going away notification message, too short")
+	}
+
+	msg.Name = string(message[5:])
+	msg.Payload = pld.Payload{
+		Data: message[1:5],
 	}
 	return nil
 }
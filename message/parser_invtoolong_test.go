@@ -9,7 +9,7 @@ import "testing"
 // TestMsgParseInvalidSessionClosedTooLong tests parsing of an invalid
 // session closed notification message which is too long to be considered valid
 func TestMsgParseInvalidSessionClosedTooLong(t *testing.T) {
-	lenTooLong := MsgMinLenSessionClosed + 1
+	lenTooLong := MsgMaxLenSessionClosed + 1
 	invalidMessage := make([]byte, lenTooLong)
 
 	invalidMessage[0] = MsgSessionClosed
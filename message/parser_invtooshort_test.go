@@ -1,6 +1,10 @@
 package message
 
-import "testing"
+import (
+	"testing"
+
+	pld "github.com/qbeon/webwire-go/payload"
+)
 
 /****************************************************************\
 	Parser - invalid messages (too short)
@@ -124,6 +128,42 @@ func TestMsgParseInvalidSessCloseReqTooShort(t *testing.T) {
 	}
 }
 
+// TestMsgParseInvalidCancelReqTooShort tests parsing of an invalid
+// request cancelation message which is too short
+// to be considered valid
+func TestMsgParseInvalidCancelReqTooShort(t *testing.T) {
+	lenTooShort := MsgMinLenCancelRequest - 1
+	invalidMessage := make([]byte, lenTooShort)
+
+	invalidMessage[0] = MsgCancelRequest
+
+	if _, err := tryParse(t, invalidMessage); err == nil {
+		t.Fatalf(
+			"Expected error while parsing invalid request cancelation "+
+				"message (too short: %d)",
+			lenTooShort,
+		)
+	}
+}
+
+// TestMsgParseInvalidSubscribeReqTooShort tests parsing of an invalid
+// topic subscription request message which is too short
+// to be considered valid
+func TestMsgParseInvalidSubscribeReqTooShort(t *testing.T) {
+	lenTooShort := MsgMinLenSubscribe - 1
+	invalidMessage := make([]byte, lenTooShort)
+
+	invalidMessage[0] = MsgSubscribe
+
+	if _, err := tryParse(t, invalidMessage); err == nil {
+		t.Fatalf(
+			"Expected error while parsing invalid topic subscription "+
+				"request message (too short: %d)",
+			lenTooShort,
+		)
+	}
+}
+
 // TestMsgParseInvalidSessCreatedSigTooShort tests parsing of an invalid
 // session creation notification message which is too short
 // to be considered valid
@@ -142,6 +182,42 @@ func TestMsgParseInvalidSessCreatedSigTooShort(t *testing.T) {
 	}
 }
 
+// TestMsgParseInvalidSessUpdatedSigTooShort tests parsing of an invalid
+// session update notification message which is too short
+// to be considered valid
+func TestMsgParseInvalidSessUpdatedSigTooShort(t *testing.T) {
+	lenTooShort := MsgMinLenSessionUpdated - 1
+	invalidMessage := make([]byte, lenTooShort)
+
+	invalidMessage[0] = MsgSessionUpdated
+
+	if _, err := tryParse(t, invalidMessage); err == nil {
+		t.Fatalf(
+			"Expected error while parsing invalid session update "+
+				"notification message (too short: %d)",
+			lenTooShort,
+		)
+	}
+}
+
+// TestMsgParseInvalidSessKeyRotatedTooShort tests parsing of an invalid
+// session key rotation notification message which is too short
+// to be considered valid
+func TestMsgParseInvalidSessKeyRotatedTooShort(t *testing.T) {
+	lenTooShort := MsgMinLenSessionKeyRotated - 1
+	invalidMessage := make([]byte, lenTooShort)
+
+	invalidMessage[0] = MsgSessionKeyRotated
+
+	if _, err := tryParse(t, invalidMessage); err == nil {
+		t.Fatalf(
+			"Expected error while parsing invalid session key rotation "+
+				"notification message (too short: %d)",
+			lenTooShort,
+		)
+	}
+}
+
 // TestMsgParseInvalidSignalTooShort tests parsing of an invalid
 // binary/UTF8 signal message which is too short to be considered valid
 func TestMsgParseInvalidSignalTooShort(t *testing.T) {
@@ -208,3 +284,53 @@ func TestMsgParseInvalidSpecialReplyTooShort(t *testing.T) {
 		)
 	}
 }
+
+// TestMsgParseInvalidHeadersTooShort tests parsing of an invalid
+// headers message which is too short to be considered valid
+func TestMsgParseInvalidHeadersTooShort(t *testing.T) {
+	lenTooShort := MsgMinLenHeaders - 1
+	invalidMessage := make([]byte, lenTooShort)
+
+	invalidMessage[0] = MsgHeaders
+
+	if _, err := tryParse(t, invalidMessage); err == nil {
+		t.Fatalf(
+			"Expected error while parsing invalid headers message "+
+				"(too short: %d)",
+			lenTooShort,
+		)
+	}
+}
+
+// TestMsgParseInvalidHeadersValueTooLong tests parsing of an invalid
+// headers message with a header value length exceeding the message
+func TestMsgParseInvalidHeadersValueTooLong(t *testing.T) {
+	invalidMessage := []byte{MsgHeaders, 0, 5, 1, 1, 'k', 1, 0}
+	invalidMessage = append(
+		invalidMessage,
+		NewSignalMessage("", pld.Binary, []byte("d"))...,
+	)
+
+	if _, err := tryParse(t, invalidMessage); err == nil {
+		t.Fatal("Expected error while parsing invalid headers message " +
+			"(value too long)")
+	}
+}
+
+// TestMsgParseInvalidGoingAwayTooShort tests parsing of an invalid
+// going away notification message which is too short
+// to be considered valid
+func TestMsgParseInvalidGoingAwayTooShort(t *testing.T) {
+	lenTooShort := MsgMinLenGoingAway - 1
+	invalidMessage := make([]byte, lenTooShort)
+
+	invalidMessage[0] = MsgGoingAway
+
+	if _, err := tryParse(t, invalidMessage); err == nil {
+		t.Fatalf(
+			"Expected error while parsing invalid going away "+
+				"notification message (too short: %d)",
+			lenTooShort,
+		)
+	}
+}
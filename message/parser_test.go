@@ -40,6 +40,38 @@ func TestMsgParseCloseSessReq(t *testing.T) {
 	compareMessages(t, expected, actual)
 }
 
+// TestMsgParseCancelReq tests parsing of a request cancelation message
+func TestMsgParseCancelReq(t *testing.T) {
+	id := genRndMsgIdentifier()
+
+	// Compose encoded message
+	// Add type flag
+	encoded := []byte{MsgCancelRequest}
+	// Add identifier
+	encoded = append(encoded, id[:]...)
+
+	// Initialize expected message
+	expected := Message{
+		Type:       MsgCancelRequest,
+		Identifier: id,
+		Name:       "",
+		Payload: pld.Payload{
+			Encoding: pld.Binary,
+			Data:     nil,
+		},
+	}
+
+	// Parse
+	actual := tryParseNoErr(t, encoded)
+
+	// Compare
+	compareMessages(t, expected, actual)
+
+	if actual.RequiresReply() {
+		t.Errorf("Expected a request cancelation message not to require a reply")
+	}
+}
+
 // TestMsgParseRestrSessReq tests parsing of a session restoration request
 func TestMsgParseRestrSessReq(t *testing.T) {
 	id := genRndMsgIdentifier()
@@ -73,6 +105,41 @@ func TestMsgParseRestrSessReq(t *testing.T) {
 	compareMessages(t, expected, actual)
 }
 
+// TestMsgParseSubscribeReq tests parsing of a topic subscription request
+func TestMsgParseSubscribeReq(t *testing.T) {
+	id := genRndMsgIdentifier()
+	topic := "sample.topic"
+
+	// Compose encoded message
+	// Add type flag
+	encoded := []byte{MsgSubscribe}
+	// Add identifier
+	encoded = append(encoded, id[:]...)
+	// Add topic to payload
+	encoded = append(encoded, topic[:]...)
+
+	// Initialize expected message with the topic in the payload
+	expected := Message{
+		Type:       MsgSubscribe,
+		Identifier: id,
+		Name:       "",
+		Payload: pld.Payload{
+			Encoding: pld.Binary,
+			Data:     []byte(topic),
+		},
+	}
+
+	// Parse
+	actual := tryParseNoErr(t, encoded)
+
+	// Compare
+	compareMessages(t, expected, actual)
+
+	if !actual.RequiresReply() {
+		t.Errorf("Expected a topic subscription request to require a reply")
+	}
+}
+
 // TestMsgParseRequestBinary tests parsing of a named binary encoded request
 func TestMsgParseRequestBinary(t *testing.T) {
 	encoded, id, name, payload := rndRequestMsg(
@@ -250,6 +317,30 @@ func TestMsgParseSignalUtf8(t *testing.T) {
 	compareMessages(t, expected, actual)
 }
 
+// TestMsgParsePublicationBinary tests parsing of a binary encoded publication
+func TestMsgParsePublicationBinary(t *testing.T) {
+	// Publications share the structure of signals
+	encoded, topic, payload := rndSignalMsg(
+		MsgSignalBinary,
+		1, 255,
+		1, 1024*64,
+	)
+	encoded[0] = MsgPublicationBinary
+
+	// Initialize expected message
+	expected := Message{
+		Type:    MsgPublicationBinary,
+		Name:    string(topic),
+		Payload: payload,
+	}
+
+	// Parse
+	actual := tryParseNoErr(t, encoded)
+
+	// Compare
+	compareMessages(t, expected, actual)
+}
+
 // TestMsgParseSignalUtf16 tests parsing of a named UTF16 encoded signal
 func TestMsgParseSignalUtf16(t *testing.T) {
 	encoded, name, payload := rndSignalMsgUtf16(
@@ -315,6 +406,48 @@ func TestMsgParseSessCreatedSig(t *testing.T) {
 	compareMessages(t, expected, actual)
 }
 
+// TestMsgParseSessUpdatedSig tests parsing of a session update
+// notification message
+func TestMsgParseSessUpdatedSig(t *testing.T) {
+	payload := pld.Payload{
+		Encoding: pld.Binary,
+		Data:     []byte(`{"k":"somesamplesessionkey","i":{"role":"admin"}}`),
+	}
+
+	// Compose encoded message
+	encoded := append([]byte{MsgSessionUpdated}, payload.Data...)
+
+	// Initialize expected message
+	expected := Message{
+		Type:    MsgSessionUpdated,
+		Payload: payload,
+	}
+
+	// Parse
+	actual := tryParseNoErr(t, encoded)
+
+	// Compare
+	compareMessages(t, expected, actual)
+}
+
+// TestMsgParseSessKeyRotatedSig tests parsing of a session key rotation
+// notification message
+func TestMsgParseSessKeyRotatedSig(t *testing.T) {
+	encoded := NewSessionKeyRotatedMessage("somesamplesessionkey")
+
+	// Initialize expected message
+	expected := Message{
+		Type: MsgSessionKeyRotated,
+		Name: "somesamplesessionkey",
+	}
+
+	// Parse
+	actual := tryParseNoErr(t, encoded)
+
+	// Compare
+	compareMessages(t, expected, actual)
+}
+
 // TestMsgParseSessClosedSig tests parsing of session sloed signal
 func TestMsgParseSessClosedSig(t *testing.T) {
 	// Compose encoded message
@@ -336,6 +469,31 @@ func TestMsgParseSessClosedSig(t *testing.T) {
 	compareMessages(t, expected, actual)
 }
 
+// TestMsgParseSessClosedReason tests parsing of a session closure
+// notification message carrying a closure reason code
+func TestMsgParseSessClosedReason(t *testing.T) {
+	encoded := NewSessionClosedMessage(3)
+
+	// Initialize expected message
+	expected := Message{
+		Type: MsgSessionClosed,
+		Payload: pld.Payload{
+			Encoding: pld.Binary,
+			Data:     []byte{3},
+		},
+	}
+
+	// Parse
+	actual := tryParseNoErr(t, encoded)
+
+	// Compare
+	compareMessages(t, expected, actual)
+
+	if reason := SessionClosedReason(&actual); reason != 3 {
+		t.Errorf("Expected closure reason 3, got: %d", reason)
+	}
+}
+
 // TestMsgParseUnknownMessageType tests parsing of messages
 // with unknown message type
 func TestMsgParseUnknownMessageType(t *testing.T) {
@@ -347,3 +505,96 @@ func TestMsgParseUnknownMessageType(t *testing.T) {
 		t.Fatalf("Expected type not to be determined")
 	}
 }
+
+// TestMsgParseHeadersRequest tests parsing of a request wrapped
+// in a headers message
+func TestMsgParseHeadersRequest(t *testing.T) {
+	wrapped, id, name, payload := rndRequestMsgUtf16(
+		1, 255,
+		2, 1024*64,
+	)
+	headers := map[string]string{
+		"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"empty":       "",
+	}
+	encoded := NewHeadersMessage(headers, wrapped)
+
+	// Initialize expected message
+	expected := Message{
+		Type:       MsgRequestUtf16,
+		Identifier: id,
+		Name:       string(name),
+		Headers:    headers,
+		Payload:    payload,
+	}
+
+	// Parse
+	actual := tryParseNoErr(t, encoded)
+
+	// Compare
+	compareMessages(t, expected, actual)
+}
+
+// TestMsgParseHeadersInvalidWrapped tests parsing of a headers message
+// wrapping a message that can't carry headers
+func TestMsgParseHeadersInvalidWrapped(t *testing.T) {
+	encoded := []byte{MsgHeaders, 0, 6, 1, 1, 'k', 0, 1, 'v'}
+	encoded = append(encoded, NewCancelRequestMessage(genRndMsgIdentifier())...)
+
+	if _, err := tryParse(t, encoded); err == nil {
+		t.Fatal("Expected error while parsing headers message " +
+			"wrapping a request cancelation message")
+	}
+}
+
+// TestMsgParseHeadersMalformed tests whether parsing a request
+// with a malformed headers section adopts the type and identifier
+// of the wrapped request to allow replying to it
+func TestMsgParseHeadersMalformed(t *testing.T) {
+	id := genRndMsgIdentifier()
+	encoded := []byte{MsgHeaders, 0, 5, 1, 0, 'k', 0, 0}
+	encoded = append(
+		encoded,
+		NewRequestMessage(id, "req", pld.Binary, []byte("d"))...,
+	)
+
+	actual, err := tryParse(t, encoded)
+	if err == nil {
+		t.Fatal("Expected error while parsing headers message " +
+			"with a zero key length")
+	}
+	if actual.Type != MsgRequestBinary {
+		t.Errorf("Expected the wrapped request type, got: %d", actual.Type)
+	}
+	if actual.Identifier != id {
+		t.Errorf(
+			"Expected the wrapped request identifier, got: %v",
+			actual.Identifier,
+		)
+	}
+}
+
+// TestMsgParseGoingAway tests parsing of a going away notification message
+func TestMsgParseGoingAway(t *testing.T) {
+	encoded := NewGoingAwayMessage(2500, "other.host:8081")
+
+	// Initialize expected message
+	expected := Message{
+		Type: MsgGoingAway,
+		Name: "other.host:8081",
+		Payload: pld.Payload{
+			Encoding: pld.Binary,
+			Data:     encoded[1:5],
+		},
+	}
+
+	// Parse
+	actual := tryParseNoErr(t, encoded)
+
+	// Compare
+	compareMessages(t, expected, actual)
+
+	if delay := GoingAwayReconnectDelay(&actual); delay != 2500 {
+		t.Errorf("Expected reconnection delay 2500, got: %d", delay)
+	}
+}
@@ -0,0 +1,62 @@
+package webwire
+
+import (
+	"sync"
+
+	msg "github.com/qbeon/webwire-go/message"
+)
+
+// messageQueue represents a thread safe set of queues of messages
+// awaiting ordered processing.
+// Messages of the same queue are processed sequentially
+// while separate queues are processed concurrently
+type messageQueue struct {
+	lock   sync.Mutex
+	queues map[string][]*msg.Message
+}
+
+// newMessageQueue returns a new empty message queue
+func newMessageQueue() *messageQueue {
+	return &messageQueue{
+		lock:   sync.Mutex{},
+		queues: make(map[string][]*msg.Message),
+	}
+}
+
+// push appends the given message to the queue identified by the given key.
+// If the queue isn't currently being processed a new goroutine is spawned
+// calling process for each queued message in order until the queue is empty
+func (mq *messageQueue) push(
+	key string,
+	message *msg.Message,
+	process func(*msg.Message),
+) {
+	mq.lock.Lock()
+	queue, processing := mq.queues[key]
+	mq.queues[key] = append(queue, message)
+	mq.lock.Unlock()
+
+	if !processing {
+		go mq.process(key, process)
+	}
+}
+
+// process processes the queue identified by the given key
+// until it's empty and removes it afterwards
+func (mq *messageQueue) process(key string, process func(*msg.Message)) {
+	for {
+		mq.lock.Lock()
+		queue := mq.queues[key]
+		if len(queue) < 1 {
+			delete(mq.queues, key)
+			mq.lock.Unlock()
+			return
+		}
+		message := queue[0]
+		queue[0] = nil
+		mq.queues[key] = queue[1:]
+		mq.lock.Unlock()
+
+		process(message)
+	}
+}
@@ -0,0 +1,102 @@
+package webwire
+
+import (
+	"time"
+)
+
+// Metrics defines the interface of a server metrics observer.
+// All methods are called synchronously by the goroutines serving
+// the clients and must therefore be thread safe and return quickly
+type Metrics interface {
+	// ConnectionOpened is called when a client established a connection
+	ConnectionOpened()
+
+	// ConnectionClosed is called when a client connection was closed
+	// given the duration of the connection
+	ConnectionClosed(duration time.Duration)
+
+	// MessageReceived is called for each received message
+	// of a known type given its type and size in bytes
+	MessageReceived(messageType byte, size int)
+
+	// SignalHandled is called when a signal handler returned
+	// given the signal name and the duration of the handler
+	SignalHandled(name string, duration time.Duration)
+
+	// RequestHandled is called when a request handler returned
+	// given the request name and the duration of the handler
+	RequestHandled(name string, duration time.Duration)
+
+	// RequestFulfilled is called when a successful reply was sent
+	RequestFulfilled()
+
+	// RequestFailed is called when an error reply was sent
+	// given the error code, which is either the code of a webwire.ReqErr
+	// or one of the ErrCode constants
+	RequestFailed(errorCode string)
+
+	// SessionCreated is called when a new session was created
+	SessionCreated()
+
+	// SessionRestored is called when a session was restored
+	SessionRestored()
+
+	// SessionClosed is called when a session was closed
+	SessionClosed()
+}
+
+const (
+	// ErrCodeInternal is reported for internal server errors
+	ErrCodeInternal = "INTERNAL_ERROR"
+
+	// ErrCodeShutdown is reported for requests rejected during shutdown
+	ErrCodeShutdown = "SHUTDOWN"
+
+	// ErrCodeProtocol is reported for protocol errors
+	ErrCodeProtocol = "PROTOCOL_ERROR"
+
+	// ErrCodeSessNotFound is reported for failed session restorations
+	ErrCodeSessNotFound = "SESSION_NOT_FOUND"
+
+	// ErrCodeMaxSessConnsReached is reported for session restorations
+	// exceeding the maximum number of concurrent session connections
+	ErrCodeMaxSessConnsReached = "MAX_SESSION_CONNECTIONS_REACHED"
+
+	// ErrCodeSessionsDisabled is reported for session related requests
+	// while sessions are disabled
+	ErrCodeSessionsDisabled = "SESSIONS_DISABLED"
+)
+
+// NopMetrics implements the Metrics interface ignoring all observations.
+// It can be embedded to implement only a subset of the Metrics interface
+type NopMetrics struct{}
+
+// ConnectionOpened implements the Metrics interface
+func (NopMetrics) ConnectionOpened() {}
+
+// ConnectionClosed implements the Metrics interface
+func (NopMetrics) ConnectionClosed(time.Duration) {}
+
+// MessageReceived implements the Metrics interface
+func (NopMetrics) MessageReceived(byte, int) {}
+
+// SignalHandled implements the Metrics interface
+func (NopMetrics) SignalHandled(string, time.Duration) {}
+
+// RequestHandled implements the Metrics interface
+func (NopMetrics) RequestHandled(string, time.Duration) {}
+
+// RequestFulfilled implements the Metrics interface
+func (NopMetrics) RequestFulfilled() {}
+
+// RequestFailed implements the Metrics interface
+func (NopMetrics) RequestFailed(string) {}
+
+// SessionCreated implements the Metrics interface
+func (NopMetrics) SessionCreated() {}
+
+// SessionRestored implements the Metrics interface
+func (NopMetrics) SessionRestored() {}
+
+// SessionClosed implements the Metrics interface
+func (NopMetrics) SessionClosed() {}
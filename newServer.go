@@ -1,12 +1,13 @@
 package webwire
 
 import (
+	"compress/flate"
+	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
 	"sync"
-
-	"golang.org/x/sync/semaphore"
 )
 
 // NewServer creates a new headed WebWire server instance
@@ -27,8 +28,15 @@ func NewServer(
 
 	// Initialize HTTP server
 	srv.httpServer = &http.Server{
-		Addr:    opts.Address,
-		Handler: srv,
+		Addr:      opts.Address,
+		Handler:   srv,
+		TLSConfig: opts.TLSConfig,
+
+		// Disable HTTP/2 since websocket connections
+		// can only be upgraded from HTTP/1.1
+		TLSNextProto: make(
+			map[string]func(*http.Server, *tls.Conn, http.Handler),
+		),
 	}
 
 	// Determine final address
@@ -59,35 +67,77 @@ func NewHeadlessServer(
 
 	opts.SetDefaults()
 
+	if opts.CompressionLevel < flate.HuffmanOnly ||
+		opts.CompressionLevel > flate.BestCompression {
+		return nil, fmt.Errorf(
+			"Invalid compression level: %d",
+			opts.CompressionLevel,
+		)
+	}
+
 	sessionsEnabled := false
 	if opts.Sessions == Enabled {
 		sessionsEnabled = true
 	}
 
-	return &server{
+	connUpgrader := opts.ConnUpgrader
+	if connUpgrader == nil {
+		connUpgrader = newConnUpgrader(
+			opts.Compression == Enabled,
+			opts.CompressionLevel,
+			opts.CompressionThreshold,
+		)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	srv := &server{
 		impl:              implementation,
 		sessionManager:    opts.SessionManager,
 		sessionKeyGen:     opts.SessionKeyGenerator,
 		sessionInfoParser: opts.SessionInfoParser,
 
 		// State
+		ctx:         ctx,
+		cancel:      cancel,
 		addr:        nil,
 		options:     opts,
 		shutdown:    false,
 		shutdownRdy: make(chan bool),
 		currentOps:  0,
 		opsLock:     &sync.Mutex{},
-		handlerSlots: semaphore.NewWeighted(
-			int64(opts.MaxConcurrentHandlers),
+		handlerScheduler: newHandlerScheduler(
+			opts.MaxConcurrentHandlers,
+			opts.MaxConnectionHandlers,
+			opts.MaxSessionHandlers,
+		),
+		connectionRegistry: newConnectionRegistry(),
+		sessionsEnabled:    sessionsEnabled,
+		sessionRegistry:    newSessionRegistry(opts.MaxSessionConnections),
+		sessionOpsLock:     &sync.Mutex{},
+		topicRegistry:      newTopicRegistry(),
+		rawListeners:       make(map[net.Listener]struct{}),
+		pollRegistry:       newPollRegistry(),
+		originPolicy: newOriginPolicy(
+			opts.AllowedOrigins,
+			opts.OriginFilter,
 		),
-		connections:     make([]*connection, 0),
-		connectionsLock: &sync.Mutex{},
-		sessionsEnabled: sessionsEnabled,
-		sessionRegistry: newSessionRegistry(opts.MaxSessionConnections),
 
 		// Internals
-		connUpgrader: newConnUpgrader(),
-		warnLog:      opts.WarnLog,
-		errorLog:     opts.ErrorLog,
-	}, nil
+		requestHandler: chainRequestInterceptors(
+			opts.RequestInterceptors,
+			implementation.OnRequest,
+		),
+		signalHandler: chainSignalInterceptors(
+			opts.SignalInterceptors,
+			implementation.OnSignal,
+		),
+		connUpgrader: connUpgrader,
+		metrics:      opts.Metrics,
+		logger:       opts.Logger,
+	}
+	srv.sessionRegistry.onExpire = srv.expireSession
+	srv.sessionRegistry.onActivityChanged = srv.sessionActivityChanged
+
+	return srv, nil
 }
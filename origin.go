@@ -0,0 +1,132 @@
+package webwire
+
+import (
+	"net/http"
+	"strings"
+)
+
+// originWildcard represents an allowed origin pattern
+// matching all subdomains of a domain
+type originWildcard struct {
+	prefix string
+	suffix string
+}
+
+// matches returns true if the given normalized origin
+// is a subdomain matching the wildcard
+func (wc originWildcard) matches(origin string) bool {
+	if len(origin) <= len(wc.prefix)+len(wc.suffix) ||
+		!strings.HasPrefix(origin, wc.prefix) ||
+		!strings.HasSuffix(origin, wc.suffix) {
+		return false
+	}
+	subdomain := origin[len(wc.prefix) : len(origin)-len(wc.suffix)]
+	return !strings.ContainsAny(subdomain, "/:@")
+}
+
+// originPolicy decides which origins browser clients
+// are allowed to connect from
+type originPolicy struct {
+	allowAll  bool
+	exact     map[string]struct{}
+	wildcards []originWildcard
+	filter    OriginFilter
+}
+
+// newOriginPolicy creates a new origin policy from the given list
+// of allowed origins and the optional origin filter.
+// All origins are allowed if neither is defined
+func newOriginPolicy(allowed []string, filter OriginFilter) *originPolicy {
+	policy := &originPolicy{
+		allowAll:  len(allowed) < 1 && filter == nil,
+		exact:     make(map[string]struct{}),
+		wildcards: nil,
+		filter:    filter,
+	}
+	for _, origin := range allowed {
+		origin = normalizeOrigin(origin)
+		if origin == "*" {
+			policy.allowAll = true
+			continue
+		}
+		if idx := strings.Index(origin, "://*."); idx > -1 {
+			policy.wildcards = append(policy.wildcards, originWildcard{
+				prefix: origin[:idx+3],
+				suffix: origin[idx+4:],
+			})
+			continue
+		}
+		policy.exact[origin] = struct{}{}
+	}
+	return policy
+}
+
+// normalizeOrigin normalizes the given origin for comparison
+func normalizeOrigin(origin string) string {
+	return strings.TrimSuffix(strings.ToLower(origin), "/")
+}
+
+// allows returns true if the given origin is allowed.
+// Requests without an origin are issued by non-browser clients
+// and are always allowed
+func (policy *originPolicy) allows(origin string, req *http.Request) bool {
+	if origin == "" || policy.allowAll {
+		return true
+	}
+	normalized := normalizeOrigin(origin)
+	if _, exists := policy.exact[normalized]; exists {
+		return true
+	}
+	for _, wildcard := range policy.wildcards {
+		if wildcard.matches(normalized) {
+			return true
+		}
+	}
+	return policy.filter != nil && policy.filter(origin, req)
+}
+
+// setCORSHeaders sets the CORS headers of a response
+// to a request from the given allowed origin
+func (policy *originPolicy) setCORSHeaders(header http.Header, origin string) {
+	if policy.allowAll {
+		header.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	if origin == "" {
+		header.Del("Access-Control-Allow-Origin")
+		return
+	}
+	header.Set("Access-Control-Allow-Origin", origin)
+	header.Add("Vary", "Origin")
+}
+
+// corsResponseWriter wraps a response writer enforcing the CORS headers
+// of the origin policy on the response regardless of the headers
+// set by the ServerImplementation.OnOptions hook
+type corsResponseWriter struct {
+	http.ResponseWriter
+	policy  *originPolicy
+	origin  string
+	applied bool
+}
+
+// apply sets the CORS headers unless they're already written
+func (cw *corsResponseWriter) apply() {
+	if cw.applied {
+		return
+	}
+	cw.applied = true
+	cw.policy.setCORSHeaders(cw.ResponseWriter.Header(), cw.origin)
+}
+
+// WriteHeader implements the http.ResponseWriter interface
+func (cw *corsResponseWriter) WriteHeader(statusCode int) {
+	cw.apply()
+	cw.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write implements the http.ResponseWriter interface
+func (cw *corsResponseWriter) Write(data []byte) (int, error) {
+	cw.apply()
+	return cw.ResponseWriter.Write(data)
+}
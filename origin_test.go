@@ -0,0 +1,61 @@
+package webwire
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestOriginPolicy tests matching origins against exact origins,
+// wildcard subdomains and the origin filter
+func TestOriginPolicy(t *testing.T) {
+	policy := newOriginPolicy(
+		[]string{"https://example.com/", "https://*.example.org"},
+		func(origin string, _ *http.Request) bool {
+			return strings.HasSuffix(origin, ".internal")
+		},
+	)
+
+	allowed := []string{
+		"",
+		"https://example.com",
+		"HTTPS://EXAMPLE.COM",
+		"https://app.example.org",
+		"https://a.b.example.org",
+		"http://service.internal",
+	}
+	for _, origin := range allowed {
+		if !policy.allows(origin, nil) {
+			t.Errorf("Expected origin %q to be allowed", origin)
+		}
+	}
+
+	rejected := []string{
+		"http://example.com",
+		"https://example.com:8080",
+		"https://evil-example.com",
+		"https://example.org",
+		"https://evil.com/.example.org",
+		"http://app.example.org",
+		"null",
+	}
+	for _, origin := range rejected {
+		if policy.allows(origin, nil) {
+			t.Errorf("Expected origin %q to be rejected", origin)
+		}
+	}
+}
+
+// TestOriginPolicyDefault tests the default policy allowing all origins
+func TestOriginPolicyDefault(t *testing.T) {
+	policy := newOriginPolicy(nil, nil)
+	if !policy.allows("https://example.com", nil) {
+		t.Error("Expected all origins to be allowed by default")
+	}
+
+	header := http.Header{}
+	policy.setCORSHeaders(header, "https://example.com")
+	if value := header.Get("Access-Control-Allow-Origin"); value != "*" {
+		t.Errorf("Expected wildcard CORS origin, got: %q", value)
+	}
+}
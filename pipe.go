@@ -0,0 +1,512 @@
+package webwire
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// pipeFrameType represents the type of a frame sent through a pipe
+type pipeFrameType = byte
+
+const (
+	pipeFrameData pipeFrameType = iota
+	pipeFramePing
+	pipeFramePong
+)
+
+// pipeFrame represents a frame sent through a pipe
+type pipeFrame struct {
+	frameType pipeFrameType
+	data      []byte
+}
+
+// pipeQueue represents the unbounded queue of frames
+// awaiting to be read by one side of a pipe
+type pipeQueue struct {
+	lock   sync.Mutex
+	frames []pipeFrame
+	notify chan struct{}
+}
+
+// newPipeQueue creates a new empty pipe queue
+func newPipeQueue() *pipeQueue {
+	return &pipeQueue{
+		lock:   sync.Mutex{},
+		frames: nil,
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// push appends the given frame to the queue
+// notifying the reader awaiting it
+func (queue *pipeQueue) push(frame pipeFrame) {
+	queue.lock.Lock()
+	queue.frames = append(queue.frames, frame)
+	queue.lock.Unlock()
+	select {
+	case queue.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pop removes and returns the oldest frame of the queue.
+// Returns false if the queue is empty
+func (queue *pipeQueue) pop() (pipeFrame, bool) {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+	if len(queue.frames) < 1 {
+		return pipeFrame{}, false
+	}
+	frame := queue.frames[0]
+	queue.frames[0] = pipeFrame{}
+	queue.frames = queue.frames[1:]
+	return frame, true
+}
+
+// pipe represents the state shared by both sides of a pipe
+type pipe struct {
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// close closes both sides of the pipe
+func (p *pipe) close() {
+	p.closeOnce.Do(func() {
+		close(p.closed)
+	})
+}
+
+// pipeAddr implements the net.Addr interface for pipes
+type pipeAddr struct{}
+
+// Network implements the net.Addr interface
+func (pipeAddr) Network() string { return "pipe" }
+
+// String implements the net.Addr interface
+func (pipeAddr) String() string { return "pipe" }
+
+// pipeReadErr implements the webwire.SockReadErr interface for pipes
+type pipeReadErr struct {
+	cause error
+}
+
+// Error implements the Go error interface
+func (err pipeReadErr) Error() string {
+	return fmt.Sprintf("Reading pipe failed: %s", err.cause)
+}
+
+// IsAbnormalCloseErr implements the webwire.SockReadErr interface.
+// Pipes are always closed normally
+func (err pipeReadErr) IsAbnormalCloseErr() bool {
+	return false
+}
+
+// pipeSocket implements the webwire.Socket interface
+// on top of an in-memory pipe
+type pipeSocket struct {
+	transport *PipeTransport
+
+	lock         sync.RWMutex
+	connected    bool
+	pipe         *pipe
+	incoming     *pipeQueue
+	outgoing     *pipeQueue
+	remoteAddr   net.Addr
+	readDeadline time.Time
+	onPing       func(string) error
+	onPong       func(string) error
+
+	// upgraded is closed when the server side of the pipe is upgraded
+	upgraded     chan struct{}
+	upgradedOnce sync.Once
+}
+
+// newPipeSockets creates the two connected sides of a new pipe
+func newPipeSockets() (*pipeSocket, *pipeSocket) {
+	p := &pipe{closed: make(chan struct{})}
+	first := newPipeQueue()
+	second := newPipeQueue()
+	firstSock := &pipeSocket{
+		connected: true,
+		pipe:      p,
+		incoming:  first,
+		outgoing:  second,
+	}
+	secondSock := &pipeSocket{
+		connected: true,
+		pipe:      p,
+		incoming:  second,
+		outgoing:  first,
+	}
+	return firstSock, secondSock
+}
+
+// Dial implements the webwire.Socket interface
+func (sock *pipeSocket) Dial(serverAddr string) error {
+	handler := sock.transport.getHandler()
+	if handler == nil {
+		return NewDisconnectedErr(fmt.Errorf(
+			"Dial failure: no server bound to the pipe transport",
+		))
+	}
+
+	// Create a new pipe replacing the current one if any
+	clientSock, serverSock := newPipeSockets()
+	serverSock.upgraded = make(chan struct{})
+	p := clientSock.pipe
+
+	sock.lock.Lock()
+	if sock.connected {
+		sock.pipe.close()
+	}
+	sock.connected = false
+	sock.pipe = p
+	sock.incoming = clientSock.incoming
+	sock.outgoing = clientSock.outgoing
+	sock.lock.Unlock()
+
+	// Let the server handle the connection request
+	req, err := http.NewRequest("GET", "http://"+serverAddr+"/", nil)
+	if err != nil {
+		return NewDisconnectedErr(fmt.Errorf("Dial failure: %s", err))
+	}
+	req.RemoteAddr = pipeAddr{}.String()
+	req = req.WithContext(context.WithValue(
+		req.Context(),
+		ctxKeyPipeSocket,
+		serverSock,
+	))
+	resp := newPipeResponseWriter()
+	handled := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(resp, req)
+		close(handled)
+	}()
+
+	select {
+	case <-serverSock.upgraded:
+	case <-handled:
+		select {
+		case <-serverSock.upgraded:
+		default:
+			return NewDisconnectedErr(fmt.Errorf(
+				"Dial failure: connection rejected: %d %s",
+				resp.status,
+				bytes.TrimSpace(resp.body.Bytes()),
+			))
+		}
+	}
+
+	sock.lock.Lock()
+	if sock.pipe == p {
+		sock.connected = true
+	}
+	sock.lock.Unlock()
+	return nil
+}
+
+// ReadMetadata implements the webwire.MetadataReader interface
+func (sock *pipeSocket) ReadMetadata(
+	serverAddr string,
+) (EndpointMetadata, error) {
+	var metadata EndpointMetadata
+	handler := sock.transport.getHandler()
+	if handler == nil {
+		return metadata, NewDisconnectedErr(fmt.Errorf(
+			"Endpoint metadata request failed: " +
+				"no server bound to the pipe transport",
+		))
+	}
+
+	req, err := http.NewRequest("WEBWIRE", "http://"+serverAddr+"/", nil)
+	if err != nil {
+		return metadata, NewDisconnectedErr(fmt.Errorf(
+			"Endpoint metadata request failed: %s", err,
+		))
+	}
+	req.RemoteAddr = pipeAddr{}.String()
+	resp := newPipeResponseWriter()
+	handler.ServeHTTP(resp, req)
+
+	if resp.status == http.StatusServiceUnavailable {
+		return metadata, NewDisconnectedErr(fmt.Errorf(
+			"Endpoint unavailable: %d", resp.status,
+		))
+	}
+	if err := json.Unmarshal(resp.body.Bytes(), &metadata); err != nil {
+		return metadata, NewProtocolErr(fmt.Errorf(
+			"Couldn't parse metadata response ('%s'): %s",
+			resp.body.String(),
+			err,
+		))
+	}
+	return metadata, nil
+}
+
+// Write implements the webwire.Socket interface
+func (sock *pipeSocket) Write(data []byte) error {
+	return sock.writeFrame(pipeFrameData, data)
+}
+
+// writeFrame writes a frame of the given type to the other side
+func (sock *pipeSocket) writeFrame(frameType pipeFrameType, data []byte) error {
+	sock.lock.Lock()
+	defer sock.lock.Unlock()
+	if !sock.connected {
+		return NewDisconnectedErr(fmt.Errorf("Can't write to a closed pipe"))
+	}
+	select {
+	case <-sock.pipe.closed:
+		return NewDisconnectedErr(fmt.Errorf("Can't write to a closed pipe"))
+	default:
+	}
+
+	// Copy the data since the caller is free to reuse the buffer
+	frame := pipeFrame{frameType: frameType, data: make([]byte, len(data))}
+	copy(frame.data, data)
+	sock.outgoing.push(frame)
+	return nil
+}
+
+// Read implements the webwire.Socket interface
+func (sock *pipeSocket) Read() ([]byte, SockReadErr) {
+	for {
+		sock.lock.RLock()
+		connected := sock.connected
+		p := sock.pipe
+		incoming := sock.incoming
+		deadline := sock.readDeadline
+		sock.lock.RUnlock()
+
+		if !connected {
+			return nil, pipeReadErr{cause: fmt.Errorf("pipe closed")}
+		}
+
+		if frame, ok := incoming.pop(); ok {
+			if frame.frameType == pipeFrameData {
+				return frame.data, nil
+			}
+			if err := sock.handleControlFrame(frame); err != nil {
+				return nil, pipeReadErr{cause: err}
+			}
+			continue
+		}
+
+		// Report the closure only after all frames were read
+		select {
+		case <-p.closed:
+			return nil, pipeReadErr{cause: fmt.Errorf("pipe closed")}
+		default:
+		}
+
+		var timeout <-chan time.Time
+		var timer *time.Timer
+		if !deadline.IsZero() {
+			timer = time.NewTimer(time.Until(deadline))
+			timeout = timer.C
+		}
+		select {
+		case <-incoming.notify:
+		case <-p.closed:
+		case <-timeout:
+			return nil, pipeReadErr{cause: fmt.Errorf("read deadline exceeded")}
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// handleControlFrame invokes the ping and pong handlers.
+// Pings are answered with pongs if there's no ping handler
+func (sock *pipeSocket) handleControlFrame(frame pipeFrame) error {
+	sock.lock.RLock()
+	onPing := sock.onPing
+	onPong := sock.onPong
+	sock.lock.RUnlock()
+
+	switch frame.frameType {
+	case pipeFramePing:
+		if onPing == nil {
+			sock.writeFrame(pipeFramePong, frame.data)
+			return nil
+		}
+		return onPing(string(frame.data))
+	case pipeFramePong:
+		if onPong == nil {
+			return nil
+		}
+		return onPong(string(frame.data))
+	}
+	return nil
+}
+
+// IsConnected implements the webwire.Socket interface
+func (sock *pipeSocket) IsConnected() bool {
+	sock.lock.RLock()
+	defer sock.lock.RUnlock()
+	if !sock.connected {
+		return false
+	}
+	select {
+	case <-sock.pipe.closed:
+		return false
+	default:
+		return true
+	}
+}
+
+// RemoteAddr implements the webwire.Socket interface
+func (sock *pipeSocket) RemoteAddr() net.Addr {
+	sock.lock.RLock()
+	defer sock.lock.RUnlock()
+	if sock.pipe == nil {
+		return nil
+	}
+	if sock.remoteAddr != nil {
+		return sock.remoteAddr
+	}
+	return pipeAddr{}
+}
+
+// Close implements the webwire.Socket interface
+func (sock *pipeSocket) Close() error {
+	sock.lock.Lock()
+	defer sock.lock.Unlock()
+	sock.connected = false
+	if sock.pipe != nil {
+		sock.pipe.close()
+	}
+	return nil
+}
+
+// SetReadDeadline implements the webwire.Socket interface
+func (sock *pipeSocket) SetReadDeadline(deadline time.Time) error {
+	sock.lock.Lock()
+	sock.readDeadline = deadline
+	sock.lock.Unlock()
+	return nil
+}
+
+// OnPong implements the webwire.Socket interface
+func (sock *pipeSocket) OnPong(handler func(string) error) {
+	sock.lock.Lock()
+	sock.onPong = handler
+	sock.lock.Unlock()
+}
+
+// OnPing implements the webwire.Socket interface
+func (sock *pipeSocket) OnPing(handler func(string) error) {
+	sock.lock.Lock()
+	sock.onPing = handler
+	sock.lock.Unlock()
+}
+
+// WritePing implements the webwire.Socket interface
+func (sock *pipeSocket) WritePing(data []byte, deadline time.Time) error {
+	return sock.writeFrame(pipeFramePing, data)
+}
+
+// pipeResponseWriter implements the http.ResponseWriter interface
+// recording the response to a request sent through a pipe transport
+type pipeResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// newPipeResponseWriter creates a new empty response recorder
+func newPipeResponseWriter() *pipeResponseWriter {
+	return &pipeResponseWriter{
+		header: make(http.Header),
+		status: 0,
+	}
+}
+
+// Header implements the http.ResponseWriter interface
+func (resp *pipeResponseWriter) Header() http.Header {
+	return resp.header
+}
+
+// Write implements the http.ResponseWriter interface
+func (resp *pipeResponseWriter) Write(data []byte) (int, error) {
+	if resp.status == 0 {
+		resp.status = http.StatusOK
+	}
+	return resp.body.Write(data)
+}
+
+// WriteHeader implements the http.ResponseWriter interface
+func (resp *pipeResponseWriter) WriteHeader(statusCode int) {
+	if resp.status == 0 {
+		resp.status = statusCode
+	}
+}
+
+// PipeTransport implements an in-memory transport connecting clients
+// to a server running in the same process without any network.
+// It implements the ConnUpgrader interface to be used as
+// ServerOptions.ConnUpgrader while its NewSocket method
+// is to be used as the client socket factory
+type PipeTransport struct {
+	lock    sync.RWMutex
+	handler http.Handler
+}
+
+// NewPipeTransport creates a new in-memory pipe transport.
+// The server must be bound to the transport before clients can connect
+func NewPipeTransport() *PipeTransport {
+	return &PipeTransport{
+		lock:    sync.RWMutex{},
+		handler: nil,
+	}
+}
+
+// Bind binds the given server to the transport
+// making it handle the connections dialed through the transport
+func (pt *PipeTransport) Bind(server http.Handler) {
+	pt.lock.Lock()
+	pt.handler = server
+	pt.lock.Unlock()
+}
+
+// getHandler returns the bound server if any
+func (pt *PipeTransport) getHandler() http.Handler {
+	pt.lock.RLock()
+	defer pt.lock.RUnlock()
+	return pt.handler
+}
+
+// NewSocket creates a new disconnected client socket dialing
+// the server bound to the transport. The socket options are ignored
+// since pipes are neither encrypted nor compressed.
+// It implements the SocketFactory type
+func (pt *PipeTransport) NewSocket(_ SocketOptions) Socket {
+	return &pipeSocket{
+		transport: pt,
+		lock:      sync.RWMutex{},
+	}
+}
+
+// Upgrade implements the ConnUpgrader interface
+// accepting only connections dialed through the transport
+func (pt *PipeTransport) Upgrade(
+	resp http.ResponseWriter,
+	req *http.Request,
+) (Socket, error) {
+	sock, ok := req.Context().Value(ctxKeyPipeSocket).(*pipeSocket)
+	if !ok {
+		http.Error(resp, "Not a pipe connection", http.StatusBadRequest)
+		return nil, fmt.Errorf("Not a pipe connection")
+	}
+	sock.upgradedOnce.Do(func() {
+		close(sock.upgraded)
+	})
+	return sock, nil
+}
@@ -0,0 +1,480 @@
+package webwire
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// pollFrame represents a frame received over a long polling connection
+type pollFrame struct {
+	frameType rawFrameType
+	payload   []byte
+}
+
+// pollSocket implements the webwire.Socket interface on top of
+// HTTP long polling sending messages in POST requests
+// and receiving them in long polling GET requests
+type pollSocket struct {
+	options    SocketOptions
+	httpClient *http.Client
+
+	lock         sync.Mutex
+	connected    bool
+	serverAddr   string
+	connURL      string
+	ctx          context.Context
+	cancel       context.CancelFunc
+	readDeadline time.Time
+
+	// writeLock serializes the POST requests to preserve the order
+	// of the sent frames without holding the state lock
+	writeLock sync.Mutex
+
+	// pending keeps the received frames awaiting to be read
+	// and received is the sequence number of the last received frame
+	// acknowledged by the next poll, they're only accessed by the reader
+	pending  []pollFrame
+	received uint64
+
+	handlersLock sync.RWMutex
+	onPing       func(string) error
+	onPong       func(string) error
+}
+
+// NewPollingSocket creates a new disconnected socket connecting to
+// the server over HTTP long polling, which must be enabled on the server.
+// It's dialed over TLS if the TLS option is enabled,
+// the compression options are ignored.
+// It implements the SocketFactory type
+func NewPollingSocket(opts SocketOptions) Socket {
+	transport := &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: opts.TLSConfig,
+	}
+	return &pollSocket{
+		options:      opts,
+		httpClient:   &http.Client{Transport: transport},
+		lock:         sync.Mutex{},
+		writeLock:    sync.Mutex{},
+		handlersLock: sync.RWMutex{},
+	}
+}
+
+// pollURL returns the long polling URL of the given server address
+// and token
+func (sock *pollSocket) pollURL(serverAddr, token string) string {
+	scheme := "http"
+	if sock.options.TLS {
+		scheme = "https"
+	}
+	connURL := url.URL{
+		Scheme:   scheme,
+		Host:     serverAddr,
+		Path:     "/",
+		RawQuery: url.Values{pollParam: []string{token}}.Encode(),
+	}
+	return connURL.String()
+}
+
+// do performs the given request returning the response body
+// and the status code
+func (sock *pollSocket) do(
+	ctx context.Context,
+	method string,
+	connURL string,
+	body []byte,
+) ([]byte, int, error) {
+	data, resp, err := sock.doResponse(ctx, method, connURL, body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, resp.StatusCode, nil
+}
+
+// doResponse performs the given request returning the response body
+// and the response
+func (sock *pollSocket) doResponse(
+	ctx context.Context,
+	method string,
+	connURL string,
+	body []byte,
+) ([]byte, *http.Response, error) {
+	req, err := http.NewRequest(method, connURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := sock.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, resp, nil
+}
+
+// Dial implements the webwire.Socket interface
+func (sock *pollSocket) Dial(serverAddr string) error {
+	sock.lock.Lock()
+	defer sock.lock.Unlock()
+	if sock.connected {
+		sock.cancel()
+		sock.connected = false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	token, status, err := sock.do(
+		ctx,
+		"POST",
+		sock.pollURL(serverAddr, ""),
+		nil,
+	)
+	if err != nil {
+		return NewDisconnectedErr(fmt.Errorf("Dial failure: %s", err))
+	}
+	if status != http.StatusOK {
+		return NewDisconnectedErr(fmt.Errorf(
+			"Dial failure: connection rejected: %d %s",
+			status,
+			bytes.TrimSpace(token),
+		))
+	}
+
+	sock.ctx, sock.cancel = context.WithCancel(context.Background())
+	sock.serverAddr = serverAddr
+	sock.connURL = sock.pollURL(serverAddr, string(token))
+	sock.pending = nil
+	sock.received = 0
+	sock.connected = true
+	return nil
+}
+
+// current returns the current connection state
+func (sock *pollSocket) current() (
+	connected bool,
+	ctx context.Context,
+	connURL string,
+	deadline time.Time,
+) {
+	sock.lock.Lock()
+	defer sock.lock.Unlock()
+	return sock.connected, sock.ctx, sock.connURL, sock.readDeadline
+}
+
+// Write implements the webwire.Socket interface
+func (sock *pollSocket) Write(data []byte) error {
+	return sock.writeFrame(rawFrameData, data)
+}
+
+// writeFrame sends a frame in a POST request.
+// Frames are sent sequentially to preserve their order
+func (sock *pollSocket) writeFrame(frameType rawFrameType, data []byte) error {
+	sock.writeLock.Lock()
+	defer sock.writeLock.Unlock()
+
+	connected, ctx, connURL, _ := sock.current()
+	if !connected {
+		return DisconnectedErr{
+			Cause: fmt.Errorf("Can't write to a socket"),
+		}
+	}
+
+	body := &bytes.Buffer{}
+	writeRawFrame(body, frameType, data)
+	_, status, err := sock.do(ctx, "POST", connURL, body.Bytes())
+	if err != nil {
+		return NewDisconnectedErr(fmt.Errorf("Write failure: %s", err))
+	}
+	if status != http.StatusNoContent {
+		return NewDisconnectedErr(fmt.Errorf("Write failure: %d", status))
+	}
+	return nil
+}
+
+// poll awaits the next frames in a long polling GET request
+// acknowledging the frames received by the previous one.
+// Frames received again because of a lost acknowledgement are skipped
+func (sock *pollSocket) poll() SockReadErr {
+	connected, ctx, connURL, deadline := sock.current()
+	if !connected {
+		return rawReadErr{cause: fmt.Errorf("socket not connected")}
+	}
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	ackURL := connURL + "&" + url.Values{
+		pollAckParam: []string{strconv.FormatUint(sock.received, 10)},
+	}.Encode()
+	body, resp, err := sock.doResponse(ctx, "GET", ackURL, nil)
+	if err != nil {
+		select {
+		case <-ctx.Done():
+			// Either closed or timed out
+			sock.lock.Lock()
+			closed := !sock.connected
+			sock.lock.Unlock()
+			return rawReadErr{cause: ctx.Err(), abnormal: !closed}
+		default:
+			return rawReadErr{cause: err, abnormal: true}
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		seq, err := strconv.ParseUint(
+			resp.Header.Get(pollSequenceHeader),
+			10,
+			64,
+		)
+		if err != nil {
+			return rawReadErr{
+				cause:    fmt.Errorf("invalid poll sequence number: %s", err),
+				abnormal: true,
+			}
+		}
+		reader := bufio.NewReader(bytes.NewReader(body))
+		for ; ; seq++ {
+			frameType, payload, err := readRawFrame(reader)
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return rawReadErr{cause: err, abnormal: true}
+			}
+			if seq <= sock.received {
+				// Already received
+				continue
+			}
+			sock.received = seq
+			sock.pending = append(sock.pending, pollFrame{
+				frameType: frameType,
+				payload:   payload,
+			})
+		}
+	case http.StatusNoContent:
+		return nil
+	case http.StatusGone, http.StatusNotFound:
+		sock.lock.Lock()
+		sock.connected = false
+		sock.lock.Unlock()
+		return rawReadErr{cause: fmt.Errorf("connection closed by the server")}
+	}
+	return rawReadErr{
+		cause:    fmt.Errorf("unexpected poll response: %d", resp.StatusCode),
+		abnormal: true,
+	}
+}
+
+// Read implements the webwire.Socket interface
+func (sock *pollSocket) Read() ([]byte, SockReadErr) {
+	for {
+		if len(sock.pending) < 1 {
+			if err := sock.poll(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		frame := sock.pending[0]
+		sock.pending = sock.pending[1:]
+
+		switch frame.frameType {
+		case rawFrameData:
+			return frame.payload, nil
+		case rawFramePing:
+			sock.handlersLock.RLock()
+			handler := sock.onPing
+			sock.handlersLock.RUnlock()
+			if handler == nil {
+				sock.writeFrame(rawFramePong, frame.payload)
+				continue
+			}
+			if err := handler(string(frame.payload)); err != nil {
+				return nil, rawReadErr{cause: err, abnormal: true}
+			}
+		case rawFramePong:
+			sock.handlersLock.RLock()
+			handler := sock.onPong
+			sock.handlersLock.RUnlock()
+			if handler == nil {
+				continue
+			}
+			if err := handler(string(frame.payload)); err != nil {
+				return nil, rawReadErr{cause: err, abnormal: true}
+			}
+		}
+	}
+}
+
+// IsConnected implements the webwire.Socket interface
+func (sock *pollSocket) IsConnected() bool {
+	sock.lock.Lock()
+	defer sock.lock.Unlock()
+	return sock.connected
+}
+
+// RemoteAddr implements the webwire.Socket interface
+func (sock *pollSocket) RemoteAddr() net.Addr {
+	sock.lock.Lock()
+	defer sock.lock.Unlock()
+	if sock.serverAddr == "" {
+		return nil
+	}
+	return pollAddr(sock.serverAddr)
+}
+
+// Close implements the webwire.Socket interface
+func (sock *pollSocket) Close() error {
+	sock.lock.Lock()
+	if !sock.connected {
+		sock.lock.Unlock()
+		return nil
+	}
+	sock.connected = false
+	sock.cancel()
+	connURL := sock.connURL
+	sock.lock.Unlock()
+
+	// Notify the server
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, _, err := sock.do(ctx, "DELETE", connURL, nil)
+	return err
+}
+
+// SetReadDeadline implements the webwire.Socket interface
+func (sock *pollSocket) SetReadDeadline(deadline time.Time) error {
+	sock.lock.Lock()
+	sock.readDeadline = deadline
+	sock.lock.Unlock()
+	return nil
+}
+
+// OnPong implements the webwire.Socket interface
+func (sock *pollSocket) OnPong(handler func(string) error) {
+	sock.handlersLock.Lock()
+	sock.onPong = handler
+	sock.handlersLock.Unlock()
+}
+
+// OnPing implements the webwire.Socket interface
+func (sock *pollSocket) OnPing(handler func(string) error) {
+	sock.handlersLock.Lock()
+	sock.onPing = handler
+	sock.handlersLock.Unlock()
+}
+
+// WritePing implements the webwire.Socket interface
+func (sock *pollSocket) WritePing(data []byte, deadline time.Time) error {
+	return sock.writeFrame(rawFramePing, data)
+}
+
+// fallbackSocket implements the webwire.Socket interface
+// dialing over WebSockets and falling back
+// to HTTP long polling if that fails
+type fallbackSocket struct {
+	lock      sync.RWMutex
+	websocket Socket
+	polling   Socket
+	active    Socket
+}
+
+// newFallbackSocket creates a new disconnected fallback socket
+func newFallbackSocket(websocket, polling Socket) *fallbackSocket {
+	return &fallbackSocket{
+		lock:      sync.RWMutex{},
+		websocket: websocket,
+		polling:   polling,
+		active:    websocket,
+	}
+}
+
+// current returns the currently active socket
+func (sock *fallbackSocket) current() Socket {
+	sock.lock.RLock()
+	defer sock.lock.RUnlock()
+	return sock.active
+}
+
+// Dial implements the webwire.Socket interface
+func (sock *fallbackSocket) Dial(serverAddr string) error {
+	sock.lock.Lock()
+	defer sock.lock.Unlock()
+
+	// Always try WebSockets first
+	err := sock.websocket.Dial(serverAddr)
+	if err == nil {
+		sock.active = sock.websocket
+		return nil
+	}
+	pollErr := sock.polling.Dial(serverAddr)
+	if pollErr == nil {
+		sock.active = sock.polling
+		return nil
+	}
+	return NewDisconnectedErr(fmt.Errorf(
+		"%s, long polling fallback failed: %s", err, pollErr,
+	))
+}
+
+// Write implements the webwire.Socket interface
+func (sock *fallbackSocket) Write(data []byte) error {
+	return sock.current().Write(data)
+}
+
+// Read implements the webwire.Socket interface
+func (sock *fallbackSocket) Read() ([]byte, SockReadErr) {
+	return sock.current().Read()
+}
+
+// IsConnected implements the webwire.Socket interface
+func (sock *fallbackSocket) IsConnected() bool {
+	return sock.current().IsConnected()
+}
+
+// RemoteAddr implements the webwire.Socket interface
+func (sock *fallbackSocket) RemoteAddr() net.Addr {
+	return sock.current().RemoteAddr()
+}
+
+// Close implements the webwire.Socket interface
+func (sock *fallbackSocket) Close() error {
+	return sock.current().Close()
+}
+
+// SetReadDeadline implements the webwire.Socket interface
+func (sock *fallbackSocket) SetReadDeadline(deadline time.Time) error {
+	return sock.current().SetReadDeadline(deadline)
+}
+
+// OnPong implements the webwire.Socket interface
+func (sock *fallbackSocket) OnPong(handler func(string) error) {
+	sock.websocket.OnPong(handler)
+	sock.polling.OnPong(handler)
+}
+
+// OnPing implements the webwire.Socket interface
+func (sock *fallbackSocket) OnPing(handler func(string) error) {
+	sock.websocket.OnPing(handler)
+	sock.polling.OnPing(handler)
+}
+
+// WritePing implements the webwire.Socket interface
+func (sock *fallbackSocket) WritePing(data []byte, deadline time.Time) error {
+	return sock.current().WritePing(data, deadline)
+}
@@ -0,0 +1,382 @@
+package webwire
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	msg "github.com/qbeon/webwire-go/message"
+)
+
+// DefaultDurationBuckets defines the default upper bounds in seconds
+// of the handler duration histogram buckets
+var DefaultDurationBuckets = []float64{
+	.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10,
+}
+
+// maxHistogramNames limits the number of distinct names
+// of the signal and request duration histograms each.
+// The names are chosen by the clients, durations of handlers
+// with names beyond the limit are recorded in a separate histogram
+const maxHistogramNames = 100
+
+// histogram represents a cumulative histogram of observed durations
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// observe records the given duration
+func (h *histogram) observe(buckets []float64, duration time.Duration) {
+	seconds := duration.Seconds()
+	for i, bound := range buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// handlerHistograms represents the duration histograms of handlers by name
+// and the histogram of the handlers with names beyond the limit
+type handlerHistograms struct {
+	named map[string]*histogram
+	other *histogram
+}
+
+// PrometheusMetrics implements the Metrics interface collecting the server
+// metrics in memory and the http.Handler interface exposing them
+// in the Prometheus text exposition format.
+// It's safe for concurrent use
+type PrometheusMetrics struct {
+	lock    sync.Mutex
+	buckets []float64
+
+	connectionsActive    int64
+	connectionsTotal     uint64
+	connectionDurationS  float64
+	connectionsClosed    uint64
+	messagesReceived     map[string]uint64
+	messageBytesReceived uint64
+	signals              *handlerHistograms
+	requests             *handlerHistograms
+	repliesFulfilled     uint64
+	repliesFailed        map[string]uint64
+	sessionsCreated      uint64
+	sessionsRestored     uint64
+	sessionsClosed       uint64
+}
+
+// NewPrometheusMetrics returns a new Prometheus metrics collector
+// using the DefaultDurationBuckets
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{
+		lock:             sync.Mutex{},
+		buckets:          DefaultDurationBuckets,
+		messagesReceived: make(map[string]uint64),
+		signals:          &handlerHistograms{named: make(map[string]*histogram)},
+		requests:         &handlerHistograms{named: make(map[string]*histogram)},
+		repliesFailed:    make(map[string]uint64),
+	}
+}
+
+// messageTypeLabel returns the metric label of the given message type
+func messageTypeLabel(messageType byte) string {
+	switch messageType {
+	case msg.MsgSignalBinary:
+		fallthrough
+	case msg.MsgSignalUtf8:
+		fallthrough
+	case msg.MsgSignalUtf16:
+		return "signal"
+	case msg.MsgRequestBinary:
+		fallthrough
+	case msg.MsgRequestUtf8:
+		fallthrough
+	case msg.MsgRequestUtf16:
+		return "request"
+	case msg.MsgReplyBinary:
+		fallthrough
+	case msg.MsgReplyUtf8:
+		fallthrough
+	case msg.MsgReplyUtf16:
+		return "reply"
+	case msg.MsgRestoreSession:
+		return "restore_session"
+	case msg.MsgCloseSession:
+		return "close_session"
+	case msg.MsgCancelRequest:
+		return "cancel_request"
+	case msg.MsgSubscribe:
+		return "subscribe"
+	case msg.MsgUnsubscribe:
+		return "unsubscribe"
+	}
+	return "other"
+}
+
+// ConnectionOpened implements the Metrics interface
+func (pm *PrometheusMetrics) ConnectionOpened() {
+	pm.lock.Lock()
+	pm.connectionsActive++
+	pm.connectionsTotal++
+	pm.lock.Unlock()
+}
+
+// ConnectionClosed implements the Metrics interface
+func (pm *PrometheusMetrics) ConnectionClosed(duration time.Duration) {
+	pm.lock.Lock()
+	pm.connectionsActive--
+	pm.connectionsClosed++
+	pm.connectionDurationS += duration.Seconds()
+	pm.lock.Unlock()
+}
+
+// MessageReceived implements the Metrics interface
+func (pm *PrometheusMetrics) MessageReceived(messageType byte, size int) {
+	pm.lock.Lock()
+	pm.messagesReceived[messageTypeLabel(messageType)]++
+	pm.messageBytesReceived += uint64(size)
+	pm.lock.Unlock()
+}
+
+// SignalHandled implements the Metrics interface
+func (pm *PrometheusMetrics) SignalHandled(name string, duration time.Duration) {
+	pm.lock.Lock()
+	pm.observe(pm.signals, name, duration)
+	pm.lock.Unlock()
+}
+
+// RequestHandled implements the Metrics interface
+func (pm *PrometheusMetrics) RequestHandled(
+	name string,
+	duration time.Duration,
+) {
+	pm.lock.Lock()
+	pm.observe(pm.requests, name, duration)
+	pm.lock.Unlock()
+}
+
+// RequestFulfilled implements the Metrics interface
+func (pm *PrometheusMetrics) RequestFulfilled() {
+	pm.lock.Lock()
+	pm.repliesFulfilled++
+	pm.lock.Unlock()
+}
+
+// RequestFailed implements the Metrics interface
+func (pm *PrometheusMetrics) RequestFailed(errorCode string) {
+	pm.lock.Lock()
+	pm.repliesFailed[errorCode]++
+	pm.lock.Unlock()
+}
+
+// SessionCreated implements the Metrics interface
+func (pm *PrometheusMetrics) SessionCreated() {
+	pm.lock.Lock()
+	pm.sessionsCreated++
+	pm.lock.Unlock()
+}
+
+// SessionRestored implements the Metrics interface
+func (pm *PrometheusMetrics) SessionRestored() {
+	pm.lock.Lock()
+	pm.sessionsRestored++
+	pm.lock.Unlock()
+}
+
+// SessionClosed implements the Metrics interface
+func (pm *PrometheusMetrics) SessionClosed() {
+	pm.lock.Lock()
+	pm.sessionsClosed++
+	pm.lock.Unlock()
+}
+
+// observe records the given handler duration in the histogram
+// of the given name falling back to the other histogram
+// when the number of distinct names is exhausted.
+// The lock must be held by the caller
+func (pm *PrometheusMetrics) observe(
+	histograms *handlerHistograms,
+	name string,
+	duration time.Duration,
+) {
+	hist, exists := histograms.named[name]
+	if !exists {
+		if len(histograms.named) >= maxHistogramNames {
+			if histograms.other == nil {
+				histograms.other = pm.newHistogram()
+			}
+			hist = histograms.other
+		} else {
+			hist = pm.newHistogram()
+			histograms.named[name] = hist
+		}
+	}
+	hist.observe(pm.buckets, duration)
+}
+
+// newHistogram returns a new empty histogram
+func (pm *PrometheusMetrics) newHistogram() *histogram {
+	return &histogram{counts: make([]uint64, len(pm.buckets))}
+}
+
+// ServeHTTP implements the http.Handler interface
+// writing the collected metrics in the Prometheus text exposition format
+func (pm *PrometheusMetrics) ServeHTTP(
+	resp http.ResponseWriter,
+	req *http.Request,
+) {
+	buf := &bytes.Buffer{}
+	pm.writeExposition(buf)
+	resp.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	resp.Write(buf.Bytes())
+}
+
+// writeExposition writes the collected metrics to the given buffer
+// in the Prometheus text exposition format
+func (pm *PrometheusMetrics) writeExposition(buf *bytes.Buffer) {
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+
+	writeHeader(buf, "webwire_connections_active", "gauge",
+		"Number of currently active connections.")
+	fmt.Fprintf(buf, "webwire_connections_active %d\n", pm.connectionsActive)
+
+	writeHeader(buf, "webwire_connections_total", "counter",
+		"Total number of accepted connections.")
+	fmt.Fprintf(buf, "webwire_connections_total %d\n", pm.connectionsTotal)
+
+	writeHeader(buf, "webwire_connection_duration_seconds", "summary",
+		"Duration of closed connections.")
+	fmt.Fprintf(buf, "webwire_connection_duration_seconds_sum %s\n",
+		formatFloat(pm.connectionDurationS))
+	fmt.Fprintf(buf, "webwire_connection_duration_seconds_count %d\n",
+		pm.connectionsClosed)
+
+	writeHeader(buf, "webwire_messages_received_total", "counter",
+		"Total number of received messages by type.")
+	for _, label := range sortedKeys(pm.messagesReceived) {
+		fmt.Fprintf(buf, "webwire_messages_received_total{type=\"%s\"} %d\n",
+			label, pm.messagesReceived[label])
+	}
+
+	writeHeader(buf, "webwire_message_bytes_received_total", "counter",
+		"Total number of received message bytes.")
+	fmt.Fprintf(buf, "webwire_message_bytes_received_total %d\n",
+		pm.messageBytesReceived)
+
+	pm.writeHistograms(buf, "webwire_signal_duration_seconds",
+		"Duration of signal handlers by signal name.", pm.signals)
+	pm.writeHistograms(buf, "webwire_request_duration_seconds",
+		"Duration of request handlers by request name.", pm.requests)
+
+	writeHeader(buf, "webwire_replies_total", "counter",
+		"Total number of successful replies.")
+	fmt.Fprintf(buf, "webwire_replies_total %d\n", pm.repliesFulfilled)
+
+	writeHeader(buf, "webwire_reply_errors_total", "counter",
+		"Total number of error replies by error code.")
+	for _, code := range sortedKeys(pm.repliesFailed) {
+		fmt.Fprintf(buf, "webwire_reply_errors_total{code=\"%s\"} %d\n",
+			escapeLabel(code), pm.repliesFailed[code])
+	}
+
+	writeHeader(buf, "webwire_sessions_created_total", "counter",
+		"Total number of created sessions.")
+	fmt.Fprintf(buf, "webwire_sessions_created_total %d\n", pm.sessionsCreated)
+
+	writeHeader(buf, "webwire_sessions_restored_total", "counter",
+		"Total number of restored sessions.")
+	fmt.Fprintf(buf, "webwire_sessions_restored_total %d\n",
+		pm.sessionsRestored)
+
+	writeHeader(buf, "webwire_sessions_closed_total", "counter",
+		"Total number of closed sessions.")
+	fmt.Fprintf(buf, "webwire_sessions_closed_total %d\n", pm.sessionsClosed)
+}
+
+// writeHistograms writes the given histograms labeled by name
+// followed by the histogram of the handlers with names beyond the limit
+// labeled as other, which can't collide with any handler name.
+// The lock must be held by the caller
+func (pm *PrometheusMetrics) writeHistograms(
+	buf *bytes.Buffer,
+	metric string,
+	help string,
+	histograms *handlerHistograms,
+) {
+	writeHeader(buf, metric, "histogram", help)
+	names := make([]string, 0, len(histograms.named))
+	for name := range histograms.named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		pm.writeHistogram(
+			buf,
+			metric,
+			fmt.Sprintf("name=\"%s\"", escapeLabel(name)),
+			histograms.named[name],
+		)
+	}
+	if histograms.other != nil {
+		pm.writeHistogram(buf, metric, `other="true"`, histograms.other)
+	}
+}
+
+// writeHistogram writes the given histogram labeled by the given labels
+func (pm *PrometheusMetrics) writeHistogram(
+	buf *bytes.Buffer,
+	metric string,
+	labels string,
+	hist *histogram,
+) {
+	for i, bound := range pm.buckets {
+		fmt.Fprintf(buf, "%s_bucket{%s,le=\"%s\"} %d\n",
+			metric, labels, formatFloat(bound), hist.counts[i])
+	}
+	fmt.Fprintf(buf, "%s_bucket{%s,le=\"+Inf\"} %d\n",
+		metric, labels, hist.count)
+	fmt.Fprintf(buf, "%s_sum{%s} %s\n",
+		metric, labels, formatFloat(hist.sum))
+	fmt.Fprintf(buf, "%s_count{%s} %d\n",
+		metric, labels, hist.count)
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric
+func writeHeader(buf *bytes.Buffer, metric, metricType, help string) {
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n",
+		metric, help, metric, metricType)
+}
+
+// formatFloat formats the given float value for the exposition format
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// labelEscaper escapes label values as required by the exposition format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// escapeLabel escapes the given label value
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+// sortedKeys returns the sorted keys of the given counter map
+func sortedKeys(counters map[string]uint64) []string {
+	keys := make([]string, 0, len(counters))
+	for key := range counters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
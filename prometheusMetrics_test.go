@@ -0,0 +1,109 @@
+package webwire
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	msg "github.com/qbeon/webwire-go/message"
+)
+
+// TestPrometheusMetrics tests the text exposition
+// of the collected metrics
+func TestPrometheusMetrics(t *testing.T) {
+	metrics := NewPrometheusMetrics()
+	metrics.ConnectionOpened()
+	metrics.ConnectionOpened()
+	metrics.ConnectionClosed(2 * time.Second)
+	metrics.MessageReceived(msg.MsgRequestUtf8, 10)
+	metrics.MessageReceived(msg.MsgRequestBinary, 5)
+	metrics.RequestHandled("login", 20*time.Millisecond)
+	metrics.RequestHandled("login", 3*time.Second)
+	metrics.RequestFulfilled()
+	metrics.RequestFailed(`BAD "CODE"`)
+	metrics.SessionCreated()
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	output := recorder.Body.String()
+
+	expectedLines := []string{
+		"# TYPE webwire_connections_active gauge",
+		"webwire_connections_active 1",
+		"webwire_connections_total 2",
+		"webwire_connection_duration_seconds_sum 2",
+		"webwire_connection_duration_seconds_count 1",
+		`webwire_messages_received_total{type="request"} 2`,
+		"webwire_message_bytes_received_total 15",
+		"# TYPE webwire_request_duration_seconds histogram",
+		`webwire_request_duration_seconds_bucket{name="login",le="0.01"} 0`,
+		`webwire_request_duration_seconds_bucket{name="login",le="0.025"} 1`,
+		`webwire_request_duration_seconds_bucket{name="login",le="5"} 2`,
+		`webwire_request_duration_seconds_bucket{name="login",le="+Inf"} 2`,
+		`webwire_request_duration_seconds_count{name="login"} 2`,
+		"webwire_replies_total 1",
+		`webwire_reply_errors_total{code="BAD \"CODE\""} 1`,
+		"webwire_sessions_created_total 1",
+		"webwire_sessions_closed_total 0",
+	}
+	lines := strings.Split(output, "\n")
+	for _, expected := range expectedLines {
+		found := false
+		for _, line := range lines {
+			if line == expected {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("Missing line %q in output:\n%s", expected, output)
+		}
+	}
+
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(
+		contentType,
+		"text/plain",
+	) {
+		t.Errorf("Unexpected content type: %s", contentType)
+	}
+}
+
+// TestPrometheusMetricsNameLimit tests whether handler durations are folded
+// into the other histogram once the number of distinct names is exhausted
+// without colliding with handlers named other
+func TestPrometheusMetricsNameLimit(t *testing.T) {
+	metrics := NewPrometheusMetrics()
+	metrics.RequestHandled("other", time.Millisecond)
+	for i := 1; i < maxHistogramNames+10; i++ {
+		metrics.RequestHandled(strconv.Itoa(i), time.Millisecond)
+	}
+	metrics.RequestHandled("1", time.Millisecond)
+
+	if len(metrics.requests.named) != maxHistogramNames {
+		t.Fatalf(
+			"Expected %d histograms, got: %d",
+			maxHistogramNames,
+			len(metrics.requests.named),
+		)
+	}
+	if count := metrics.requests.other.count; count != 10 {
+		t.Errorf("Expected 10 folded observations, got: %d", count)
+	}
+	if count := metrics.requests.named["1"].count; count != 2 {
+		t.Errorf("Expected 2 observations of a known name, got: %d", count)
+	}
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	output := recorder.Body.String()
+	for _, expected := range []string{
+		`webwire_request_duration_seconds_count{name="other"} 1`,
+		`webwire_request_duration_seconds_count{other="true"} 10`,
+	} {
+		if !strings.Contains(output, expected+"\n") {
+			t.Errorf("Missing line %q in output:\n%s", expected, output)
+		}
+	}
+}
@@ -0,0 +1,416 @@
+package webwire
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rawFrameType represents the type of a raw transport frame
+type rawFrameType = byte
+
+const (
+	// rawFrameData carries an encoded webwire message
+	rawFrameData rawFrameType = iota
+
+	// rawFramePing and rawFramePong carry heartbeat control data
+	rawFramePing
+	rawFramePong
+
+	// rawFrameConnect opens a connection carrying the client agent string
+	rawFrameConnect
+
+	// rawFrameMetadata requests the endpoint metadata
+	// and carries the JSON encoded metadata in the response
+	rawFrameMetadata
+
+	// rawFrameAccept and rawFrameReject respond to a connection request,
+	// the rejection carries the reason
+	rawFrameAccept
+	rawFrameReject
+)
+
+const (
+	// rawFrameHeaderLen defines the length of a raw frame header
+	// consisting of the 4 byte big endian payload length
+	// followed by the frame type
+	rawFrameHeaderLen = 5
+
+	// rawMaxFrameSize defines the maximum accepted frame payload size
+	rawMaxFrameSize = 64 << 20
+
+	// rawHandshakeTimeout defines the maximum duration
+	// of the connection handshake
+	rawHandshakeTimeout = 10 * time.Second
+
+	// rawUserAgent defines the client agent string
+	// of connections opened by raw sockets
+	rawUserAgent = "webwire-go raw"
+)
+
+// writeRawFrame writes a frame of the given type and payload
+// to the given writer
+func writeRawFrame(
+	writer io.Writer,
+	frameType rawFrameType,
+	payload []byte,
+) error {
+	frame := make([]byte, rawFrameHeaderLen+len(payload))
+	binary.BigEndian.PutUint32(frame[:4], uint32(len(payload)))
+	frame[4] = frameType
+	copy(frame[rawFrameHeaderLen:], payload)
+	_, err := writer.Write(frame)
+	return err
+}
+
+// readRawFrame reads the next frame from the given reader
+func readRawFrame(reader io.Reader) (rawFrameType, []byte, error) {
+	var header [rawFrameHeaderLen]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	if length > rawMaxFrameSize {
+		return 0, nil, fmt.Errorf(
+			"Frame of %d bytes exceeds the maximum size", length,
+		)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return 0, nil, err
+	}
+	return header[4], payload, nil
+}
+
+// splitRawAddress returns the network and the address
+// of the given raw transport server address.
+// Addresses prefixed by "unix:" refer to Unix domain sockets,
+// any other address refers to a TCP endpoint
+func splitRawAddress(serverAddr string) (string, string) {
+	if strings.HasPrefix(serverAddr, "unix:") {
+		return "unix", strings.TrimPrefix(
+			strings.TrimPrefix(serverAddr, "unix:"),
+			"//",
+		)
+	}
+	return "tcp", strings.TrimPrefix(serverAddr, "tcp://")
+}
+
+// rawReadErr implements the webwire.SockReadErr interface
+// for raw sockets
+type rawReadErr struct {
+	cause    error
+	abnormal bool
+}
+
+// Error implements the Go error interface
+func (err rawReadErr) Error() string {
+	return fmt.Sprintf("Reading raw socket failed: %s", err.cause)
+}
+
+// IsAbnormalCloseErr implements the webwire.SockReadErr interface
+func (err rawReadErr) IsAbnormalCloseErr() bool {
+	return err.abnormal
+}
+
+// rawSocket implements the webwire.Socket interface framing messages
+// directly over a stream connection without HTTP and WebSockets
+type rawSocket struct {
+	options SocketOptions
+
+	lock      sync.Mutex
+	connected bool
+	closed    bool
+	conn      net.Conn
+	reader    *bufio.Reader
+
+	handlersLock sync.RWMutex
+	onPing       func(string) error
+	onPong       func(string) error
+}
+
+// NewRawSocket creates a new disconnected raw socket dialing
+// TCP or Unix domain socket endpoints served by Server.ServeRaw.
+// Server addresses prefixed by "unix:" refer to Unix domain sockets.
+// It's dialed over TLS if the TLS option is enabled,
+// the compression options are ignored.
+// It implements the SocketFactory type
+func NewRawSocket(opts SocketOptions) Socket {
+	return &rawSocket{
+		options:      opts,
+		lock:         sync.Mutex{},
+		handlersLock: sync.RWMutex{},
+	}
+}
+
+// newAcceptedRawSocket creates a new raw socket for the given
+// accepted connection reading the frames through the given reader
+func newAcceptedRawSocket(conn net.Conn, reader *bufio.Reader) *rawSocket {
+	return &rawSocket{
+		lock:         sync.Mutex{},
+		connected:    true,
+		conn:         conn,
+		reader:       reader,
+		handlersLock: sync.RWMutex{},
+	}
+}
+
+// dial opens a new connection to the given server address
+func (sock *rawSocket) dial(serverAddr string) (net.Conn, error) {
+	network, address := splitRawAddress(serverAddr)
+	dialer := &net.Dialer{Timeout: rawHandshakeTimeout}
+	if sock.options.TLS {
+		return tls.DialWithDialer(
+			dialer,
+			network,
+			address,
+			sock.options.TLSConfig,
+		)
+	}
+	return dialer.Dial(network, address)
+}
+
+// handshake sends the given request frame over the given connection
+// and returns the response frame
+func handshake(
+	conn net.Conn,
+	reader *bufio.Reader,
+	frameType rawFrameType,
+	payload []byte,
+) (rawFrameType, []byte, error) {
+	conn.SetDeadline(time.Now().Add(rawHandshakeTimeout))
+	if err := writeRawFrame(conn, frameType, payload); err != nil {
+		return 0, nil, err
+	}
+	responseType, response, err := readRawFrame(reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return responseType, response, nil
+}
+
+// Dial implements the webwire.Socket interface
+func (sock *rawSocket) Dial(serverAddr string) error {
+	sock.lock.Lock()
+	defer sock.lock.Unlock()
+	if sock.connected {
+		sock.conn.Close()
+		sock.conn = nil
+		sock.connected = false
+	}
+
+	conn, err := sock.dial(serverAddr)
+	if err != nil {
+		return NewDisconnectedErr(fmt.Errorf("Dial failure: %s", err))
+	}
+	reader := bufio.NewReader(conn)
+	responseType, response, err := handshake(
+		conn,
+		reader,
+		rawFrameConnect,
+		[]byte(rawUserAgent),
+	)
+	if err != nil {
+		conn.Close()
+		return NewDisconnectedErr(fmt.Errorf("Dial failure: %s", err))
+	}
+	if responseType != rawFrameAccept {
+		conn.Close()
+		return NewDisconnectedErr(fmt.Errorf(
+			"Dial failure: connection rejected: %s", response,
+		))
+	}
+
+	sock.conn = conn
+	sock.reader = reader
+	sock.connected = true
+	sock.closed = false
+	return nil
+}
+
+// ReadMetadata implements the webwire.MetadataReader interface
+func (sock *rawSocket) ReadMetadata(
+	serverAddr string,
+) (EndpointMetadata, error) {
+	var metadata EndpointMetadata
+	conn, err := sock.dial(serverAddr)
+	if err != nil {
+		return metadata, NewDisconnectedErr(fmt.Errorf(
+			"Endpoint metadata request failed: %s", err,
+		))
+	}
+	defer conn.Close()
+
+	responseType, response, err := handshake(
+		conn,
+		bufio.NewReader(conn),
+		rawFrameMetadata,
+		nil,
+	)
+	if err != nil {
+		return metadata, NewDisconnectedErr(fmt.Errorf(
+			"Endpoint metadata request failed: %s", err,
+		))
+	}
+	if responseType != rawFrameMetadata {
+		return metadata, NewDisconnectedErr(fmt.Errorf(
+			"Endpoint unavailable: %s", response,
+		))
+	}
+	if err := json.Unmarshal(response, &metadata); err != nil {
+		return metadata, NewProtocolErr(fmt.Errorf(
+			"Couldn't parse metadata response ('%s'): %s",
+			string(response),
+			err,
+		))
+	}
+	return metadata, nil
+}
+
+// Write implements the webwire.Socket interface
+func (sock *rawSocket) Write(data []byte) error {
+	return sock.writeFrame(rawFrameData, data)
+}
+
+// writeFrame writes a frame while protecting the connection
+// from concurrent writes
+func (sock *rawSocket) writeFrame(frameType rawFrameType, data []byte) error {
+	sock.lock.Lock()
+	defer sock.lock.Unlock()
+	if !sock.connected {
+		return DisconnectedErr{
+			Cause: fmt.Errorf("Can't write to a socket"),
+		}
+	}
+	return writeRawFrame(sock.conn, frameType, data)
+}
+
+// Read implements the webwire.Socket interface
+func (sock *rawSocket) Read() ([]byte, SockReadErr) {
+	sock.lock.Lock()
+	reader := sock.reader
+	sock.lock.Unlock()
+	if reader == nil {
+		return nil, rawReadErr{cause: fmt.Errorf("socket not connected")}
+	}
+
+	for {
+		frameType, payload, err := readRawFrame(reader)
+		if err != nil {
+			sock.lock.Lock()
+			closed := sock.closed
+			sock.lock.Unlock()
+			return nil, rawReadErr{
+				cause:    err,
+				abnormal: !closed && err != io.EOF,
+			}
+		}
+
+		switch frameType {
+		case rawFrameData:
+			return payload, nil
+		case rawFramePing:
+			sock.handlersLock.RLock()
+			handler := sock.onPing
+			sock.handlersLock.RUnlock()
+			if handler == nil {
+				sock.writeFrame(rawFramePong, payload)
+				continue
+			}
+			if err := handler(string(payload)); err != nil {
+				return nil, rawReadErr{cause: err, abnormal: true}
+			}
+		case rawFramePong:
+			sock.handlersLock.RLock()
+			handler := sock.onPong
+			sock.handlersLock.RUnlock()
+			if handler == nil {
+				continue
+			}
+			if err := handler(string(payload)); err != nil {
+				return nil, rawReadErr{cause: err, abnormal: true}
+			}
+		default:
+			return nil, rawReadErr{
+				cause:    fmt.Errorf("Unexpected frame type: %d", frameType),
+				abnormal: true,
+			}
+		}
+	}
+}
+
+// IsConnected implements the webwire.Socket interface
+func (sock *rawSocket) IsConnected() bool {
+	sock.lock.Lock()
+	defer sock.lock.Unlock()
+	return sock.connected
+}
+
+// RemoteAddr implements the webwire.Socket interface
+func (sock *rawSocket) RemoteAddr() net.Addr {
+	sock.lock.Lock()
+	defer sock.lock.Unlock()
+	if sock.conn == nil {
+		return nil
+	}
+	return sock.conn.RemoteAddr()
+}
+
+// Close implements the webwire.Socket interface
+func (sock *rawSocket) Close() error {
+	sock.lock.Lock()
+	defer sock.lock.Unlock()
+	sock.connected = false
+	sock.closed = true
+	if sock.conn == nil {
+		return nil
+	}
+	return sock.conn.Close()
+}
+
+// SetReadDeadline implements the webwire.Socket interface
+func (sock *rawSocket) SetReadDeadline(deadline time.Time) error {
+	sock.lock.Lock()
+	conn := sock.conn
+	sock.lock.Unlock()
+	if conn == nil {
+		return fmt.Errorf("socket not connected")
+	}
+	return conn.SetReadDeadline(deadline)
+}
+
+// OnPong implements the webwire.Socket interface
+func (sock *rawSocket) OnPong(handler func(string) error) {
+	sock.handlersLock.Lock()
+	sock.onPong = handler
+	sock.handlersLock.Unlock()
+}
+
+// OnPing implements the webwire.Socket interface
+func (sock *rawSocket) OnPing(handler func(string) error) {
+	sock.handlersLock.Lock()
+	sock.onPing = handler
+	sock.handlersLock.Unlock()
+}
+
+// WritePing implements the webwire.Socket interface
+func (sock *rawSocket) WritePing(data []byte, deadline time.Time) error {
+	sock.lock.Lock()
+	defer sock.lock.Unlock()
+	if !sock.connected {
+		return DisconnectedErr{
+			Cause: fmt.Errorf("Can't write to a socket"),
+		}
+	}
+	sock.conn.SetWriteDeadline(deadline)
+	defer sock.conn.SetWriteDeadline(time.Time{})
+	return writeRawFrame(sock.conn, rawFramePing, data)
+}
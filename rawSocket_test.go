@@ -0,0 +1,63 @@
+package webwire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// TestRawFrame tests writing and reading raw transport frames
+func TestRawFrame(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := writeRawFrame(buf, rawFramePing, []byte("data")); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := writeRawFrame(buf, rawFrameAccept, nil); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	frameType, payload, err := readRawFrame(buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if frameType != rawFramePing || string(payload) != "data" {
+		t.Errorf("Unexpected frame: %d %q", frameType, payload)
+	}
+
+	frameType, payload, err = readRawFrame(buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if frameType != rawFrameAccept || len(payload) != 0 {
+		t.Errorf("Unexpected frame: %d %q", frameType, payload)
+	}
+}
+
+// TestRawFrameTooLarge tests rejecting frames exceeding the maximum size
+func TestRawFrameTooLarge(t *testing.T) {
+	header := make([]byte, rawFrameHeaderLen)
+	binary.BigEndian.PutUint32(header, rawMaxFrameSize+1)
+	if _, _, err := readRawFrame(bytes.NewReader(header)); err == nil {
+		t.Error("Expected an error for a frame exceeding the maximum size")
+	}
+}
+
+// TestSplitRawAddress tests determining the network of raw addresses
+func TestSplitRawAddress(t *testing.T) {
+	cases := map[string][2]string{
+		"127.0.0.1:80":           {"tcp", "127.0.0.1:80"},
+		"tcp://127.0.0.1:80":     {"tcp", "127.0.0.1:80"},
+		"unix:/tmp/wwr.sock":     {"unix", "/tmp/wwr.sock"},
+		"unix:///tmp/wwr.sock":   {"unix", "/tmp/wwr.sock"},
+		"unix:relative/wwr.sock": {"unix", "relative/wwr.sock"},
+	}
+	for addr, expected := range cases {
+		network, address := splitRawAddress(addr)
+		if network != expected[0] || address != expected[1] {
+			t.Errorf(
+				"Unexpected split of %q: %s %s",
+				addr, network, address,
+			)
+		}
+	}
+}
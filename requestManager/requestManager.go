@@ -3,21 +3,28 @@ package requestmanager
 import (
 	"context"
 	"encoding/binary"
-	"fmt"
 	"sync"
 	"time"
 
-	webwire "github.com/qbeon/webwire-go"
 	pld "github.com/qbeon/webwire-go/payload"
 )
 
 // RequestIdentifier represents the universally unique minified UUIDv4 identifier of a request.
 type RequestIdentifier = [8]byte
 
+// TimeoutErr represents the error returned by Request.AwaitReply
+// when the request timed out
+type TimeoutErr struct{}
+
+// Error implements the error interface
+func (err TimeoutErr) Error() string {
+	return "timed out"
+}
+
 // reply is used by the request manager to represent the results
 // of a request (both failed and succeeded)
 type reply struct {
-	Reply webwire.Payload
+	Reply pld.Payload
 	Error error
 }
 
@@ -45,7 +52,11 @@ func (req *Request) Identifier() RequestIdentifier {
 // until either the reply is fulfilled or failed, the request timed out
 // a user-defined deadline was exceeded or the request was prematurely canceled.
 // The timer is started when AwaitReply is called.
-func (req *Request) AwaitReply(ctx context.Context) (webwire.Payload, error) {
+//
+// The context error is returned if the context was canceled
+// or its deadline was exceeded, a TimeoutErr is returned if the
+// request timed out and otherwise the error the request was failed with
+func (req *Request) AwaitReply(ctx context.Context) (pld.Payload, error) {
 	// Start timeout timer
 	timeoutTimer := time.NewTimer(req.timeout)
 	defer timeoutTimer.Stop()
@@ -55,24 +66,15 @@ func (req *Request) AwaitReply(ctx context.Context) (webwire.Payload, error) {
 	select {
 	case <-ctx.Done():
 		req.manager.deregister(req.identifier)
-		return nil, webwire.TranslateContextError(ctx.Err())
+		return pld.Payload{}, ctx.Err()
 	case <-timeoutTimer.C:
 		req.manager.deregister(req.identifier)
-		return &webwire.EncodedPayload{},
-			webwire.NewTimeoutErr(fmt.Errorf("timed out"))
+		return pld.Payload{}, TimeoutErr{}
 	case reply := <-req.reply:
 		timeoutTimer.Stop()
 		if reply.Error != nil {
-			return nil, reply.Error
+			return pld.Payload{}, reply.Error
 		}
-
-		// Don't return nil even if the reply is empty
-		// to prevent invalid memory access attempts
-		// caused by forgetting to check for != nil
-		if reply.Reply == nil {
-			return &webwire.EncodedPayload{}, nil
-		}
-
 		return reply.Reply, nil
 	}
 }
@@ -144,9 +146,7 @@ func (manager *RequestManager) Fulfill(
 	}
 
 	req.reply <- reply{
-		Reply: &webwire.EncodedPayload{
-			Payload: payload,
-		},
+		Reply: payload,
 		Error: nil,
 	}
 	manager.deregister(identifier)
@@ -163,7 +163,7 @@ func (manager *RequestManager) Fail(identifier RequestIdentifier, err error) boo
 		return false
 	}
 	req.reply <- reply{
-		Reply: nil,
+		Reply: pld.Payload{},
 		Error: err,
 	}
 	manager.deregister(identifier)
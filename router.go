@@ -0,0 +1,234 @@
+package webwire
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RequestHandler represents the type of a named request handler function
+type RequestHandler func(
+	ctx context.Context,
+	connection Connection,
+	message Message,
+) (response Payload, err error)
+
+// SignalHandler represents the type of a named signal handler function
+type SignalHandler func(
+	ctx context.Context,
+	connection Connection,
+	message Message,
+)
+
+// ServerHooks defines the connection lifecycle hooks
+// of a server implementation excluding the message handlers.
+// Every ServerImplementation also implements the ServerHooks interface
+type ServerHooks interface {
+	OnOptions(resp http.ResponseWriter)
+	BeforeUpgrade(resp http.ResponseWriter, req *http.Request) bool
+	OnClientConnected(client Connection)
+	OnClientDisconnected(client Connection)
+}
+
+// messageRoute represents a registered request or signal route
+type messageRoute struct {
+	prefix  string
+	request RequestHandler
+	signal  SignalHandler
+}
+
+// routeTable represents a set of routes of a single message kind
+type routeTable struct {
+	exact    map[string]messageRoute
+	prefixes []messageRoute
+	fallback *messageRoute
+}
+
+func newRouteTable() routeTable {
+	return routeTable{
+		exact:    make(map[string]messageRoute),
+		prefixes: make([]messageRoute, 0),
+		fallback: nil,
+	}
+}
+
+// register registers the given route by pattern.
+// "*" defines the fallback route, "ns.*" defines a namespace wildcard route
+// matching any name prefixed with "ns." and any other pattern
+// is considered an exact name
+func (tbl *routeTable) register(pattern string, route messageRoute) {
+	if pattern == "*" {
+		tbl.fallback = &route
+		return
+	}
+
+	if strings.HasSuffix(pattern, "*") {
+		route.prefix = pattern[:len(pattern)-1]
+
+		// Replace the route if the prefix is already registered
+		for index, existing := range tbl.prefixes {
+			if existing.prefix == route.prefix {
+				tbl.prefixes[index] = route
+				return
+			}
+		}
+		tbl.prefixes = append(tbl.prefixes, route)
+
+		// Keep the longest prefixes first so the most specific one wins
+		sort.SliceStable(tbl.prefixes, func(i, j int) bool {
+			return len(tbl.prefixes[i].prefix) > len(tbl.prefixes[j].prefix)
+		})
+		return
+	}
+
+	route.prefix = pattern
+	tbl.exact[pattern] = route
+}
+
+// lookup returns the most specific route matching the given name.
+// Returns false if no route matched
+func (tbl *routeTable) lookup(name string) (messageRoute, bool) {
+	if route, exists := tbl.exact[name]; exists {
+		return route, true
+	}
+	for _, route := range tbl.prefixes {
+		if strings.HasPrefix(name, route.prefix) {
+			return route, true
+		}
+	}
+	if tbl.fallback != nil {
+		return *tbl.fallback, true
+	}
+	return messageRoute{}, false
+}
+
+// Router is a name-based message dispatcher implementing
+// the ServerImplementation interface.
+// Request and signal handlers are registered by name patterns,
+// where a pattern is either an exact message name, a namespace wildcard
+// like "user.*" matching all names prefixed with "user."
+// or a single "*" matching any name not matched by any other route.
+// The most specific route always wins.
+//
+// All lifecycle hooks are forwarded to the optional delegate
+type Router struct {
+	lock     sync.RWMutex
+	delegate ServerHooks
+	requests routeTable
+	signals  routeTable
+}
+
+// NewRouter constructs a new router instance.
+// The lifecycle hooks are forwarded to the given delegate
+// which is optional and may be nil
+func NewRouter(delegate ServerHooks) *Router {
+	return &Router{
+		lock:     sync.RWMutex{},
+		delegate: delegate,
+		requests: newRouteTable(),
+		signals:  newRouteTable(),
+	}
+}
+
+// Request registers a request handler for the given name pattern.
+// Registering a handler for an already registered pattern replaces it
+func (rtr *Router) Request(pattern string, handler RequestHandler) {
+	if handler == nil {
+		panic(fmt.Errorf("Router requires a request handler, got nil"))
+	}
+	rtr.lock.Lock()
+	rtr.requests.register(pattern, messageRoute{request: handler})
+	rtr.lock.Unlock()
+}
+
+// Signal registers a signal handler for the given name pattern.
+// Registering a handler for an already registered pattern replaces it
+func (rtr *Router) Signal(pattern string, handler SignalHandler) {
+	if handler == nil {
+		panic(fmt.Errorf("Router requires a signal handler, got nil"))
+	}
+	rtr.lock.Lock()
+	rtr.signals.register(pattern, messageRoute{signal: handler})
+	rtr.lock.Unlock()
+}
+
+// OnOptions implements the ServerImplementation interface.
+// Forwards the hook to the delegate if any
+func (rtr *Router) OnOptions(resp http.ResponseWriter) {
+	if rtr.delegate != nil {
+		rtr.delegate.OnOptions(resp)
+	}
+}
+
+// BeforeUpgrade implements the ServerImplementation interface.
+// Forwards the hook to the delegate if any,
+// otherwise accepts all incoming connections
+func (rtr *Router) BeforeUpgrade(
+	resp http.ResponseWriter,
+	req *http.Request,
+) bool {
+	if rtr.delegate != nil {
+		return rtr.delegate.BeforeUpgrade(resp, req)
+	}
+	return true
+}
+
+// OnClientConnected implements the ServerImplementation interface.
+// Forwards the hook to the delegate if any
+func (rtr *Router) OnClientConnected(client Connection) {
+	if rtr.delegate != nil {
+		rtr.delegate.OnClientConnected(client)
+	}
+}
+
+// OnClientDisconnected implements the ServerImplementation interface.
+// Forwards the hook to the delegate if any
+func (rtr *Router) OnClientDisconnected(client Connection) {
+	if rtr.delegate != nil {
+		rtr.delegate.OnClientDisconnected(client)
+	}
+}
+
+// OnSignal implements the ServerImplementation interface.
+// Dispatches the signal to the matching handler,
+// signals not matching any route are dropped
+func (rtr *Router) OnSignal(
+	ctx context.Context,
+	client Connection,
+	message Message,
+) {
+	rtr.lock.RLock()
+	route, found := rtr.signals.lookup(message.Name())
+	rtr.lock.RUnlock()
+	if !found {
+		return
+	}
+	route.signal(ctx, client, message)
+}
+
+// OnRequest implements the ServerImplementation interface.
+// Dispatches the request to the matching handler,
+// requests not matching any route are failed with a ReqErr
+// of code "UNSUPPORTED_REQUEST"
+func (rtr *Router) OnRequest(
+	ctx context.Context,
+	client Connection,
+	message Message,
+) (response Payload, err error) {
+	rtr.lock.RLock()
+	route, found := rtr.requests.lookup(message.Name())
+	rtr.lock.RUnlock()
+	if !found {
+		return nil, ReqErr{
+			Code: "UNSUPPORTED_REQUEST",
+			Message: fmt.Sprintf(
+				"Unsupported request name: '%s'",
+				message.Name(),
+			),
+		}
+	}
+	return route.request(ctx, client, message)
+}
@@ -0,0 +1,102 @@
+package webwire
+
+import (
+	"context"
+	"testing"
+
+	msg "github.com/qbeon/webwire-go/message"
+)
+
+// routedMessage constructs a named test message
+func routedMessage(name string) Message {
+	return &MessageWrapper{
+		actual: &msg.Message{
+			Type: msg.MsgRequestBinary,
+			Name: name,
+		},
+	}
+}
+
+// routeReplier returns a request handler replying with the given route name
+func routeReplier(route string) RequestHandler {
+	return func(
+		_ context.Context,
+		_ Connection,
+		_ Message,
+	) (Payload, error) {
+		return NewPayload(EncodingUtf8, []byte(route)), nil
+	}
+}
+
+// TestRouterRequestDispatch tests the dispatching of requests
+// to exact, namespace wildcard and fallback routes
+func TestRouterRequestDispatch(t *testing.T) {
+	ctx := context.Background()
+	router := NewRouter(nil)
+	router.Request("user.get", routeReplier("exact"))
+	router.Request("user.*", routeReplier("user"))
+	router.Request("user.admin.*", routeReplier("admin"))
+
+	expectations := map[string]string{
+		"user.get":        "exact",
+		"user.set":        "user",
+		"user.admin.ban":  "admin",
+		"user.admin.kick": "admin",
+	}
+	for name, expected := range expectations {
+		reply, err := router.OnRequest(ctx, nil, routedMessage(name))
+		if err != nil {
+			t.Fatalf("Unexpected error for '%s': %s", name, err)
+		}
+		if string(reply.Data()) != expected {
+			t.Errorf(
+				"Request '%s' dispatched to the wrong route: %s (expected %s)",
+				name,
+				string(reply.Data()),
+				expected,
+			)
+		}
+	}
+
+	// Expect unknown names to be rejected with a request error
+	_, err := router.OnRequest(ctx, nil, routedMessage("unknown"))
+	reqErr, isReqErr := err.(ReqErr)
+	if !isReqErr {
+		t.Fatalf("Expected a ReqErr, got: %v", err)
+	}
+	if reqErr.Code != "UNSUPPORTED_REQUEST" {
+		t.Errorf("Unexpected error code: %s", reqErr.Code)
+	}
+
+	// Expect the fallback to catch unknown names once registered
+	router.Request("*", routeReplier("fallback"))
+	reply, err := router.OnRequest(ctx, nil, routedMessage("unknown"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if string(reply.Data()) != "fallback" {
+		t.Errorf("Expected fallback route, got: %s", string(reply.Data()))
+	}
+}
+
+// TestRouterSignalDispatch tests the dispatching of signals
+func TestRouterSignalDispatch(t *testing.T) {
+	ctx := context.Background()
+	router := NewRouter(nil)
+	var dispatched string
+	router.Signal("chat.*", func(_ context.Context, _ Connection, m Message) {
+		dispatched = m.Name()
+	})
+
+	router.OnSignal(ctx, nil, routedMessage("chat.msg"))
+	if dispatched != "chat.msg" {
+		t.Errorf("Expected signal to be dispatched, got: '%s'", dispatched)
+	}
+
+	// Expect unmatched signals to be dropped silently
+	dispatched = ""
+	router.OnSignal(ctx, nil, routedMessage("other"))
+	if dispatched != "" {
+		t.Errorf("Expected signal to be dropped, got: '%s'", dispatched)
+	}
+}
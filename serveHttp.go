@@ -1,6 +1,7 @@
 package webwire
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"time"
@@ -12,21 +13,48 @@ func (srv *server) ServeHTTP(
 	resp http.ResponseWriter,
 	req *http.Request,
 ) {
-	// Reject incoming connections during shutdown, pretend the server is temporarily unavailable
+	// Reject incoming connections during shutdown, pretend the server is temporarily unavailable.
+	// Existing long polling connections are still served to deliver
+	// the going away notice and the replies of the remaining handlers
+	token, isPoll := pollToken(req)
 	srv.opsLock.Lock()
-	if srv.shutdown {
+	if srv.shutdown && (!isPoll || token == "") {
 		srv.opsLock.Unlock()
 		http.Error(resp, "Server shutting down", http.StatusServiceUnavailable)
 		return
 	}
 	srv.opsLock.Unlock()
 
+	// Reject requests from disallowed origins
+	origin := req.Header.Get("Origin")
+	if !srv.originPolicy.allows(origin, req) {
+		srv.logger.Warn(
+			"Rejected request from disallowed origin",
+			"remoteAddr", req.RemoteAddr,
+			"method", req.Method,
+			"origin", origin,
+		)
+		http.Error(resp, "Origin not allowed", http.StatusForbidden)
+		return
+	}
+
 	switch req.Method {
 	case "OPTIONS":
-		srv.impl.OnOptions(resp)
+		corsResp := &corsResponseWriter{
+			ResponseWriter: resp,
+			policy:         srv.originPolicy,
+			origin:         origin,
+		}
+		srv.impl.OnOptions(corsResp)
+		corsResp.apply()
 		return
 	case "WEBWIRE":
-		srv.handleMetadata(resp)
+		srv.handleMetadata(resp, req, origin)
+		return
+	}
+
+	if isPoll {
+		srv.servePoll(resp, req, token)
 		return
 	}
 
@@ -37,9 +65,30 @@ func (srv *server) ServeHTTP(
 	// Establish connection
 	conn, err := srv.connUpgrader.Upgrade(resp, req)
 	if err != nil {
-		srv.errorLog.Print("Upgrade failed:", err)
+		srv.logger.Error(
+			"Upgrade failed",
+			"remoteAddr", req.RemoteAddr,
+			"error", err,
+		)
 		return
 	}
+
+	srv.serveConnection(
+		conn,
+		req.Header.Get("User-Agent"),
+		req.TLS,
+		isLegacyClient(req),
+	)
+}
+
+// serveConnection serves the given established connection
+// blocking the calling goroutine until it's closed
+func (srv *server) serveConnection(
+	conn Socket,
+	userAgent string,
+	tlsState *tls.ConnectionState,
+	legacy bool,
+) {
 	defer conn.Close()
 
 	// Set ping/pong handlers
@@ -68,16 +117,21 @@ func (srv *server) ServeHTTP(
 	if err := conn.SetReadDeadline(
 		time.Now().Add(srv.options.HeartbeatTimeout),
 	); err != nil {
-		srv.errorLog.Printf("Couldn't set read deadline: %s", err)
+		srv.logger.Error(
+			"Couldn't set read deadline",
+			"remoteAddr", conn.RemoteAddr(),
+			"error", err,
+		)
 		return
 	}
 
 	// Register connected client
-	connection := newConnection(conn, req.Header.Get("User-Agent"), srv)
+	connection := newConnection(conn, userAgent, srv)
+	connection.info.setTLS(tlsState)
+	connection.legacy = legacy
 
-	srv.connectionsLock.Lock()
-	srv.connections = append(srv.connections, connection)
-	srv.connectionsLock.Unlock()
+	srv.connectionRegistry.register(connection)
+	srv.metrics.ConnectionOpened()
 
 	// Call hook on successful connection
 	srv.impl.OnClientConnected(connection)
@@ -85,7 +139,7 @@ func (srv *server) ServeHTTP(
 	// Start heartbeat sender (if enabled)
 	stopHeartbeat := make(chan struct{}, 1)
 	if srv.options.Heartbeat == Enabled {
-		go srv.heartbeat(conn, stopHeartbeat)
+		go srv.heartbeat(connection, stopHeartbeat)
 	}
 
 	for {
@@ -93,16 +147,22 @@ func (srv *server) ServeHTTP(
 		message, err := conn.Read()
 		if err != nil {
 			if err.IsAbnormalCloseErr() {
-				srv.warnLog.Printf("Abnormal closure error: %s", err)
+				srv.logger.Warn(
+					"Abnormal closure",
+					connection.logFields("error", err)...,
+				)
 			}
 
 			connection.unlink()
+			srv.metrics.ConnectionClosed(
+				time.Since(connection.info.ConnectionTime),
+			)
 			srv.impl.OnClientDisconnected(connection)
 			break
 		}
 
 		// Parse & handle the message
-		go srv.handleMessage(connection, message)
+		srv.dispatchMessage(connection, message)
 	}
 
 	// Connection closed
@@ -0,0 +1,343 @@
+package webwire
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// pollParam defines the URL query parameter
+	// carrying the token of a long polling connection.
+	// An empty token opens a new connection
+	pollParam = "wwr-poll"
+
+	// pollAckParam defines the URL query parameter of a long polling
+	// receive request carrying the sequence number of the last frame
+	// the client received, all frames up to it are acknowledged
+	pollAckParam = "wwr-ack"
+
+	// pollSequenceHeader defines the response header carrying
+	// the sequence number of the first frame of a long polling response,
+	// the following frames are numbered consecutively
+	pollSequenceHeader = "Webwire-Poll-Sequence"
+
+	// pollTimeout defines the maximum duration a long polling
+	// receive request is held open while there's nothing to receive
+	pollTimeout = 20 * time.Second
+
+	// pollMaxBodySize defines the maximum size of a long polling
+	// send request body
+	pollMaxBodySize = rawMaxFrameSize + rawFrameHeaderLen
+)
+
+// pollAddr implements the net.Addr interface
+// for long polling connections
+type pollAddr string
+
+// Network implements the net.Addr interface
+func (pollAddr) Network() string { return "tcp" }
+
+// String implements the net.Addr interface
+func (addr pollAddr) String() string { return string(addr) }
+
+// pollConn represents a long polling connection. The server is connected
+// to it through a pipe, the connection keeps the side of the pipe
+// that's served over HTTP and the frames sent to the client
+// that weren't yet acknowledged
+type pollConn struct {
+	sock *pipeSocket
+
+	lock sync.Mutex
+
+	// unacked keeps the encoded frames sent to the client until
+	// a subsequent poll acknowledges them, first is the sequence number
+	// of the oldest one. Sequence numbers start at 1
+	unacked [][]byte
+	first   uint64
+}
+
+// acknowledge discards all unacknowledged frames
+// up to the given sequence number.
+// The lock must be held by the caller
+func (conn *pollConn) acknowledge(seq uint64) {
+	if seq < conn.first {
+		return
+	}
+	acked := seq - conn.first + 1
+	if acked > uint64(len(conn.unacked)) {
+		acked = uint64(len(conn.unacked))
+	}
+	conn.unacked = conn.unacked[acked:]
+	conn.first += acked
+}
+
+// collect moves all frames sent by the server
+// to the unacknowledged frames.
+// The lock must be held by the caller
+func (conn *pollConn) collect() {
+	for {
+		frame, ok := conn.sock.incoming.pop()
+		if !ok {
+			return
+		}
+		frameType := rawFrameData
+		switch frame.frameType {
+		case pipeFramePing:
+			frameType = rawFramePing
+		case pipeFramePong:
+			frameType = rawFramePong
+		}
+		encoded := &bytes.Buffer{}
+		writeRawFrame(encoded, frameType, frame.data)
+		conn.unacked = append(conn.unacked, encoded.Bytes())
+	}
+}
+
+// pollRegistry keeps track of the open long polling connections
+// by their tokens
+type pollRegistry struct {
+	lock  sync.RWMutex
+	conns map[string]*pollConn
+}
+
+// newPollRegistry creates a new empty long polling connection registry
+func newPollRegistry() *pollRegistry {
+	return &pollRegistry{
+		lock:  sync.RWMutex{},
+		conns: make(map[string]*pollConn),
+	}
+}
+
+// register registers the given connection returning its new token
+func (reg *pollRegistry) register(sock *pipeSocket) (string, error) {
+	var token [24]byte
+	if _, err := rand.Read(token[:]); err != nil {
+		return "", fmt.Errorf("Couldn't generate long polling token: %s", err)
+	}
+	encoded := hex.EncodeToString(token[:])
+	reg.lock.Lock()
+	reg.conns[encoded] = &pollConn{
+		sock:  sock,
+		lock:  sync.Mutex{},
+		first: 1,
+	}
+	reg.lock.Unlock()
+	return encoded, nil
+}
+
+// deregister removes the connection of the given token
+func (reg *pollRegistry) deregister(token string) {
+	reg.lock.Lock()
+	delete(reg.conns, token)
+	reg.lock.Unlock()
+}
+
+// connection returns the connection of the given token
+// or nil if there's none
+func (reg *pollRegistry) connection(token string) *pollConn {
+	reg.lock.RLock()
+	defer reg.lock.RUnlock()
+	return reg.conns[token]
+}
+
+// pollToken returns the long polling token of the given request.
+// Returns false if the request isn't a long polling request
+func pollToken(req *http.Request) (string, bool) {
+	values, isPoll := req.URL.Query()[pollParam]
+	if !isPoll {
+		return "", false
+	}
+	if len(values) < 1 {
+		return "", true
+	}
+	return values[0], true
+}
+
+// servePoll handles long polling requests
+func (srv *server) servePoll(
+	resp http.ResponseWriter,
+	req *http.Request,
+	token string,
+) {
+	if srv.options.LongPolling != Enabled {
+		http.Error(resp, "Long polling disabled", http.StatusNotFound)
+		return
+	}
+
+	if token == "" {
+		if req.Method != "POST" {
+			http.Error(resp, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		srv.openPoll(resp, req)
+		return
+	}
+
+	conn := srv.pollRegistry.connection(token)
+	if conn == nil {
+		http.Error(resp, "Connection not found", http.StatusNotFound)
+		return
+	}
+
+	switch req.Method {
+	case "POST":
+		srv.receivePoll(resp, req, conn.sock)
+	case "GET":
+		srv.sendPoll(resp, req, token, conn)
+	case "DELETE":
+		conn.sock.Close()
+		resp.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(resp, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// openPoll opens a new long polling connection
+// responding with its token
+func (srv *server) openPoll(resp http.ResponseWriter, req *http.Request) {
+	if !srv.impl.BeforeUpgrade(resp, req) {
+		return
+	}
+
+	pollSock, serverSock := newPipeSockets()
+	serverSock.remoteAddr = pollAddr(req.RemoteAddr)
+	token, err := srv.pollRegistry.register(pollSock)
+	if err != nil {
+		srv.logger.Error(
+			"Couldn't open long polling connection",
+			"remoteAddr", req.RemoteAddr,
+			"error", err,
+		)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// Forget the connection once it's closed leaving the client
+	// a poll timeout to receive the frames sent right before the closure
+	go func() {
+		<-pollSock.pipe.closed
+		time.AfterFunc(pollTimeout, func() {
+			srv.pollRegistry.deregister(token)
+		})
+	}()
+
+	go srv.serveConnection(
+		serverSock,
+		req.Header.Get("User-Agent"),
+		req.TLS,
+		false,
+	)
+
+	resp.Header().Set("Content-Type", "text/plain")
+	io.WriteString(resp, token)
+}
+
+// receivePoll forwards the frames sent by the client to the server
+func (srv *server) receivePoll(
+	resp http.ResponseWriter,
+	req *http.Request,
+	sock *pipeSocket,
+) {
+	reader := bufio.NewReader(http.MaxBytesReader(
+		resp,
+		req.Body,
+		pollMaxBodySize,
+	))
+	for {
+		frameType, payload, err := readRawFrame(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(resp, "Invalid frame", http.StatusBadRequest)
+			return
+		}
+
+		switch frameType {
+		case rawFrameData:
+			err = sock.writeFrame(pipeFrameData, payload)
+		case rawFramePing:
+			err = sock.writeFrame(pipeFramePing, payload)
+		case rawFramePong:
+			err = sock.writeFrame(pipeFramePong, payload)
+		default:
+			http.Error(resp, "Unexpected frame type", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(resp, "Connection closed", http.StatusGone)
+			return
+		}
+	}
+	resp.WriteHeader(http.StatusNoContent)
+}
+
+// sendPoll awaits the frames sent by the server and responds with
+// all unacknowledged frames at once. Frames are kept until a subsequent
+// poll acknowledges them and are sent again otherwise.
+// Responds with no content if nothing was sent until the poll timeout
+func (srv *server) sendPoll(
+	resp http.ResponseWriter,
+	req *http.Request,
+	token string,
+	conn *pollConn,
+) {
+	var ack uint64
+	if param := req.URL.Query().Get(pollAckParam); param != "" {
+		parsed, err := strconv.ParseUint(param, 10, 64)
+		if err != nil {
+			http.Error(resp, "Invalid acknowledgement", http.StatusBadRequest)
+			return
+		}
+		ack = parsed
+	}
+
+	timeout := time.NewTimer(pollTimeout)
+	defer timeout.Stop()
+
+	closed := false
+	for {
+		// Collect all pending frames
+		conn.lock.Lock()
+		conn.acknowledge(ack)
+		conn.collect()
+		first := conn.first
+		body := bytes.Join(conn.unacked, nil)
+		conn.lock.Unlock()
+
+		if len(body) > 0 {
+			resp.Header().Set("Content-Type", "application/octet-stream")
+			resp.Header().Set(
+				pollSequenceHeader,
+				strconv.FormatUint(first, 10),
+			)
+			resp.Write(body)
+			return
+		}
+		if closed {
+			srv.pollRegistry.deregister(token)
+			http.Error(resp, "Connection closed", http.StatusGone)
+			return
+		}
+
+		select {
+		case <-conn.sock.incoming.notify:
+		case <-conn.sock.pipe.closed:
+			// Deliver the frames sent right before the closure
+			closed = true
+		case <-timeout.C:
+			resp.WriteHeader(http.StatusNoContent)
+			return
+		case <-req.Context().Done():
+			return
+		}
+	}
+}
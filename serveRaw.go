@@ -0,0 +1,206 @@
+package webwire
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ServeRaw implements the Server interface
+func (srv *server) ServeRaw(listener net.Listener) error {
+	srv.opsLock.Lock()
+	if srv.shutdown {
+		srv.opsLock.Unlock()
+		listener.Close()
+		return nil
+	}
+	srv.rawListeners[listener] = struct{}{}
+	srv.opsLock.Unlock()
+
+	defer func() {
+		srv.opsLock.Lock()
+		delete(srv.rawListeners, listener)
+		srv.opsLock.Unlock()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			srv.opsLock.Lock()
+			shutdown := srv.shutdown
+			srv.opsLock.Unlock()
+			if shutdown {
+				return nil
+			}
+			if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			return fmt.Errorf("Raw listener failure: %s", err)
+		}
+		go srv.serveRawConn(conn)
+	}
+}
+
+// closeRawListeners closes all listeners served by ServeRaw.
+// The ops lock must be held by the caller
+func (srv *server) closeRawListeners() {
+	for listener := range srv.rawListeners {
+		if err := listener.Close(); err != nil {
+			srv.logger.Warn(
+				"Couldn't close raw listener",
+				"addr", listener.Addr(),
+				"error", err,
+			)
+		}
+	}
+}
+
+// serveRawConn performs the handshake of the given raw connection
+// and serves it if the client asks for a connection
+func (srv *server) serveRawConn(conn net.Conn) {
+	// Complete the TLS handshake to expose the connection state
+	var tlsState *tls.ConnectionState
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		tlsConn.SetDeadline(time.Now().Add(rawHandshakeTimeout))
+		if err := tlsConn.Handshake(); err != nil {
+			srv.logger.Warn(
+				"Raw TLS handshake failed",
+				"remoteAddr", conn.RemoteAddr(),
+				"error", err,
+			)
+			conn.Close()
+			return
+		}
+		state := tlsConn.ConnectionState()
+		tlsState = &state
+	}
+
+	// Await the request frame
+	reader := bufio.NewReader(conn)
+	conn.SetDeadline(time.Now().Add(rawHandshakeTimeout))
+	frameType, payload, err := readRawFrame(reader)
+	if err != nil {
+		srv.logger.Warn(
+			"Raw handshake failed",
+			"remoteAddr", conn.RemoteAddr(),
+			"error", err,
+		)
+		conn.Close()
+		return
+	}
+
+	// Reject incoming connections during shutdown
+	srv.opsLock.Lock()
+	shutdown := srv.shutdown
+	srv.opsLock.Unlock()
+	if shutdown {
+		writeRawFrame(conn, rawFrameReject, []byte("Server shutting down"))
+		conn.Close()
+		return
+	}
+
+	switch frameType {
+	case rawFrameMetadata:
+		encoded, _ := json.Marshal(EndpointMetadata{
+			ProtocolVersion: protocolVersion,
+		})
+		writeRawFrame(conn, rawFrameMetadata, encoded)
+		conn.Close()
+		return
+	case rawFrameConnect:
+		if reason, admitted := srv.admitRawConn(
+			conn,
+			string(payload),
+			tlsState,
+		); !admitted {
+			writeRawFrame(conn, rawFrameReject, []byte(reason))
+			conn.Close()
+			return
+		}
+		if err := writeRawFrame(conn, rawFrameAccept, nil); err != nil {
+			conn.Close()
+			return
+		}
+		conn.SetDeadline(time.Time{})
+		srv.serveConnection(
+			newAcceptedRawSocket(conn, reader),
+			string(payload),
+			tlsState,
+			false,
+		)
+	default:
+		writeRawFrame(conn, rawFrameReject, []byte("Unexpected handshake"))
+		conn.Close()
+	}
+}
+
+// admitRawConn invokes the BeforeUpgrade hook of the server implementation
+// for the given raw connection passing it a request synthesized
+// from the handshake, which carries the remote address,
+// the user agent string and the TLS connection state.
+// Returns the reason of the rejection if the connection isn't admitted
+func (srv *server) admitRawConn(
+	conn net.Conn,
+	userAgent string,
+	tlsState *tls.ConnectionState,
+) (string, bool) {
+	req := &http.Request{
+		Method:     "GET",
+		URL:        &url.URL{Path: "/"},
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"User-Agent": []string{userAgent}},
+		Host:       conn.LocalAddr().String(),
+		RemoteAddr: conn.RemoteAddr().String(),
+		TLS:        tlsState,
+	}
+	resp := &rawAdmissionResponse{header: http.Header{}}
+	if srv.impl.BeforeUpgrade(resp, req) {
+		return "", true
+	}
+
+	if reason := strings.TrimSpace(resp.body.String()); reason != "" {
+		return reason, false
+	}
+	if resp.status != 0 {
+		return http.StatusText(resp.status), false
+	}
+	return "Connection rejected", false
+}
+
+// rawAdmissionResponse records the response written by the BeforeUpgrade
+// hook of the server implementation for a raw connection
+type rawAdmissionResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// Header implements the http.ResponseWriter interface
+func (resp *rawAdmissionResponse) Header() http.Header {
+	return resp.header
+}
+
+// Write implements the http.ResponseWriter interface
+func (resp *rawAdmissionResponse) Write(data []byte) (int, error) {
+	if resp.status == 0 {
+		resp.status = http.StatusOK
+	}
+	return resp.body.Write(data)
+}
+
+// WriteHeader implements the http.ResponseWriter interface
+func (resp *rawAdmissionResponse) WriteHeader(status int) {
+	if resp.status == 0 {
+		resp.status = status
+	}
+}
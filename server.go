@@ -3,15 +3,21 @@ package webwire
 import (
 	"context"
 	"fmt"
-	"log"
 	"net"
 	"net/http"
 	"sync"
+	"time"
 
-	"golang.org/x/sync/semaphore"
+	msg "github.com/qbeon/webwire-go/message"
 )
 
-const protocolVersion = "1.4"
+const protocolVersion = "1.5"
+
+// legacyProtocolVersion represents the previous protocol version
+// which is still supported for clients not announcing their version.
+// Legacy clients aren't notified about session updates, key rotations
+// and the server going away and can't be sent requests
+const legacyProtocolVersion = "1.4"
 
 // server represents a headless WebWire server instance,
 // where headless means there's no HTTP server that's hosting it
@@ -24,29 +30,39 @@ type server struct {
 	sessionInfoParser SessionInfoParser
 
 	// State
-	addr            net.Addr
-	options         ServerOptions
-	shutdown        bool
-	shutdownRdy     chan bool
-	currentOps      uint32
-	opsLock         *sync.Mutex
-	connectionsLock *sync.Mutex
-	handlerSlots    *semaphore.Weighted
-	connections     []*connection
-	sessionsEnabled bool
-	sessionRegistry *sessionRegistry
+	ctx                context.Context
+	cancel             context.CancelFunc
+	addr               net.Addr
+	options            ServerOptions
+	shutdown           bool
+	shutdownRdy        chan bool
+	currentOps         uint32
+	opsLock            *sync.Mutex
+	handlerScheduler   *handlerScheduler
+	connectionRegistry *connectionRegistry
+	sessionsEnabled    bool
+	sessionRegistry    *sessionRegistry
+	sessionOpsLock     *sync.Mutex
+	topicRegistry      *topicRegistry
+	originPolicy       *originPolicy
+	rawListeners       map[net.Listener]struct{}
+	pollRegistry       *pollRegistry
 
 	// Internals
-	connUpgrader ConnUpgrader
-	warnLog      *log.Logger
-	errorLog     *log.Logger
+	requestHandler RequestHandler
+	signalHandler  SignalHandler
+	connUpgrader   ConnUpgrader
+	metrics        Metrics
+	logger         Logger
 }
 
-func (srv *server) shutdownHTTPServer() error {
+func (srv *server) shutdownHTTPServer(ctx context.Context) error {
 	if srv.httpServer == nil {
 		return nil
 	}
-	if err := srv.httpServer.Shutdown(context.Background()); err != nil {
+	if err := srv.httpServer.Shutdown(ctx); err != nil {
+		// Close the HTTP server immediately if the deadline is exceeded
+		srv.httpServer.Close()
 		return fmt.Errorf("Couldn't properly shutdown HTTP server: %s", err)
 	}
 	return nil
@@ -54,6 +70,11 @@ func (srv *server) shutdownHTTPServer() error {
 
 // Run implements the Server interface
 func (srv *server) Run() error {
+	// Serve TLS if configured
+	if srv.options.TLSConfig != nil || srv.options.TLSCertFile != "" {
+		return srv.RunTLS(srv.options.TLSCertFile, srv.options.TLSKeyFile)
+	}
+
 	// Launch HTTP server
 	if err := srv.httpServer.Serve(
 		tcpKeepAliveListener{srv.listener.(*net.TCPListener)},
@@ -64,24 +85,134 @@ func (srv *server) Run() error {
 	return nil
 }
 
+// RunTLS implements the Server interface
+func (srv *server) RunTLS(certFile, keyFile string) error {
+	// Launch HTTPS server
+	if err := srv.httpServer.ServeTLS(
+		tcpKeepAliveListener{srv.listener.(*net.TCPListener)},
+		certFile,
+		keyFile,
+	); err != http.ErrServerClosed {
+		return fmt.Errorf("HTTPS Server failure: %s", err)
+	}
+
+	return nil
+}
+
 // Addr implements the Server interface
 func (srv *server) Addr() net.Addr {
 	return srv.addr
 }
 
 // Shutdown implements the Server interface
-func (srv *server) Shutdown() error {
+func (srv *server) Shutdown(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
 	srv.opsLock.Lock()
 	srv.shutdown = true
-	// Don't block if there's no currently processed operations
-	if srv.currentOps < 1 {
-		srv.opsLock.Unlock()
-		return srv.shutdownHTTPServer()
-	}
+
+	// Stop accepting raw connections
+	srv.closeRawListeners()
+
+	// Cancel the contexts of all currently running handlers
+	srv.cancel()
+
+	pendingOps := srv.currentOps > 0
 	srv.opsLock.Unlock()
-	<-srv.shutdownRdy
 
-	return srv.shutdownHTTPServer()
+	// Advise all connected clients to reconnect later
+	srv.notifyGoingAway()
+
+	// Await all currently processed operations
+	if pendingOps {
+		select {
+		case <-srv.shutdownRdy:
+		case <-ctx.Done():
+			// Abandon the remaining handlers
+			srv.closeConnections()
+			srv.shutdownHTTPServer(ctx)
+			return TranslateContextError(ctx.Err())
+		}
+	}
+
+	srv.closeConnections()
+	return srv.shutdownHTTPServer(ctx)
+}
+
+// notifyGoingAway notifies all connected clients about the shutdown
+// advising them to reconnect after the configured delay
+func (srv *server) notifyGoingAway() {
+	message := newPreparedMessage(msg.NewGoingAwayMessage(
+		uint32(srv.options.ReconnectDelay/time.Millisecond),
+		srv.options.ReconnectAddress,
+	))
+	for conn, err := range srv.deliver(
+		srv.connectionRegistry.connections(func(conn Connection) bool {
+			// Legacy clients don't support going away notifications
+			return !conn.(*connection).legacy
+		}),
+		message,
+	) {
+		srv.logger.Warn(
+			"Couldn't deliver going away notification",
+			conn.(*connection).logFields("error", err)...,
+		)
+	}
+}
+
+// closeConnections closes the sockets of all connected clients
+func (srv *server) closeConnections() {
+	for _, conn := range srv.connectionRegistry.connections(nil) {
+		if err := conn.sock.Close(); err != nil {
+			srv.logger.Warn(
+				"Couldn't close connection",
+				conn.logFields("error", err)...,
+			)
+		}
+	}
+}
+
+// Connection implements the Server interface
+func (srv *server) Connection(id uint64) Connection {
+	connection := srv.connectionRegistry.connection(id)
+	if connection == nil {
+		return nil
+	}
+	return connection
+}
+
+// Connections implements the Server interface
+func (srv *server) Connections() []Connection {
+	connections := srv.connectionRegistry.connections(nil)
+	list := make([]Connection, len(connections))
+	for i, connection := range connections {
+		list[i] = connection
+	}
+	return list
+}
+
+// ConnectionsNum implements the Server interface
+func (srv *server) ConnectionsNum() int {
+	return srv.connectionRegistry.connectionsNum()
+}
+
+// ForEachConnection implements the Server interface
+func (srv *server) ForEachConnection(
+	filter ConnectionFilter,
+	fn func(Connection) bool,
+) {
+	for _, connection := range srv.connectionRegistry.connections(filter) {
+		if !fn(connection) {
+			return
+		}
+	}
+}
+
+// HandlerStats implements the Server interface
+func (srv *server) HandlerStats() HandlerStats {
+	return srv.handlerScheduler.snapshot()
 }
 
 // ActiveSessionsNum implements the Server interface
@@ -109,6 +240,14 @@ func (srv *server) SessionConnections(sessionKey string) []Connection {
 
 // CloseSession implements the Server interface
 func (srv *server) CloseSession(sessionKey string) int {
+	if !srv.sessionsEnabled {
+		return -1
+	}
+	return srv.detachSession(sessionKey, SessionCloseServer)
+}
+
+// CloseSessionConnections implements the Server interface
+func (srv *server) CloseSessionConnections(sessionKey string) int {
 	connections := srv.sessionRegistry.sessionConnections(sessionKey)
 	if connections == nil {
 		return -1
@@ -118,3 +257,184 @@ func (srv *server) CloseSession(sessionKey string) int {
 	}
 	return len(connections)
 }
+
+// UpdateSessionInfo implements the Server interface
+func (srv *server) UpdateSessionInfo(sessionKey string, info SessionInfo) error {
+	if !srv.sessionsEnabled {
+		return SessionsDisabledErr{}
+	}
+
+	// Serialize session updates to ensure the clients receive
+	// the updates in the order they're persisted in
+	srv.sessionOpsLock.Lock()
+	defer srv.sessionOpsLock.Unlock()
+
+	if err := srv.sessionManager.OnSessionUpdated(sessionKey, info); err != nil {
+		return err
+	}
+
+	for _, con := range srv.sessionRegistry.sessionConnections(sessionKey) {
+		updated := con.updateSessionInfo(sessionKey, info)
+		if updated == nil {
+			continue
+		}
+		if err := con.notifySessionUpdated(updated); err != nil {
+			srv.logger.Warn(
+				"Couldn't notify client about the session update",
+				con.logFields("error", err)...,
+			)
+		}
+	}
+	return nil
+}
+
+// rotateSessionKey replaces the key of the session identified by the given
+// key with a newly generated one renaming it through the session manager
+// and notifies all connections of the session about the new key.
+// Returns the new session key
+func (srv *server) rotateSessionKey(oldKey string) (string, error) {
+	srv.sessionOpsLock.Lock()
+	defer srv.sessionOpsLock.Unlock()
+
+	newKey := srv.sessionKeyGen.Generate()
+	if !isValidSessionKey(newKey) {
+		return "", fmt.Errorf(
+			"Invalid session key returned by the session key generator: %q",
+			newKey,
+		)
+	}
+
+	// Invalidate the old key permanently before any connection is migrated
+	if err := srv.sessionManager.OnSessionKeyRotated(
+		oldKey,
+		newKey,
+	); err != nil {
+		return "", err
+	}
+
+	// Lock all connections of the session while migrating
+	// to ensure they're always registered by the key of their session
+	connections := srv.sessionRegistry.sessionConnections(oldKey)
+	for _, con := range connections {
+		con.sessionLock.Lock()
+	}
+	srv.sessionRegistry.rename(oldKey, newKey)
+	for _, con := range connections {
+		if con.session != nil && con.session.Key == oldKey {
+			rotated := con.session.Clone()
+			rotated.Key = newKey
+			con.session = rotated
+		}
+		con.sessionLock.Unlock()
+	}
+
+	message := msg.NewSessionKeyRotatedMessage(newKey)
+	for _, con := range connections {
+		if con.legacy {
+			// Legacy clients don't support key rotations
+			continue
+		}
+		if err := con.sock.Write(message); err != nil {
+			srv.logger.Warn(
+				"Couldn't notify client about the session key rotation",
+				con.logFields("error", err)...,
+			)
+		}
+	}
+	return newKey, nil
+}
+
+// Publish implements the Server interface
+func (srv *server) Publish(topic string, payload Payload) error {
+	if err := verifyTopic(topic); err != nil {
+		return err
+	}
+	if payload == nil || len(payload.Data()) < 1 {
+		return fmt.Errorf("Invalid publication, missing payload")
+	}
+
+	subscribers := srv.topicRegistry.subscribers(topic)
+	if len(subscribers) < 1 {
+		return nil
+	}
+
+	// Encode the publication once for all subscribers
+	message := newPreparedMessage(msg.NewPublicationMessage(
+		topic,
+		payload.Encoding(),
+		payload.Data(),
+	))
+
+	for conn, err := range srv.deliver(subscribers, message) {
+		srv.logger.Warn(
+			"Couldn't deliver publication",
+			conn.(*connection).logFields("topic", topic, "error", err)...,
+		)
+	}
+	return nil
+}
+
+// TopicSubscribersNum implements the Server interface
+func (srv *server) TopicSubscribersNum(topic string) int {
+	return srv.topicRegistry.subscribersNum(topic)
+}
+
+// SignalSession implements the Server interface
+func (srv *server) SignalSession(
+	sessionKey string,
+	name string,
+	payload Payload,
+) map[Connection]error {
+	connections := srv.sessionRegistry.sessionConnections(sessionKey)
+	if len(connections) < 1 {
+		return nil
+	}
+	return srv.deliver(connections, newSignalMessage(name, payload))
+}
+
+// Broadcast implements the Server interface
+func (srv *server) Broadcast(
+	name string,
+	payload Payload,
+	filter ConnectionFilter,
+) map[Connection]error {
+	connections := srv.connectionRegistry.connections(filter)
+	if len(connections) < 1 {
+		return nil
+	}
+	return srv.deliver(connections, newSignalMessage(name, payload))
+}
+
+// newSignalMessage encodes and prepares a signal message
+// to be written to multiple connections
+func newSignalMessage(name string, payload Payload) *preparedMessage {
+	var encoding PayloadEncoding
+	var data []byte
+	if payload != nil {
+		encoding = payload.Encoding()
+		data = payload.Data()
+	}
+	return newPreparedMessage(msg.NewSignalMessage(name, encoding, data))
+}
+
+// deliver writes the given prepared message to all active connections
+// of the given list and returns the errors of failed deliveries
+// mapped by the connections. Returns nil if all deliveries succeeded
+func (srv *server) deliver(
+	connections []*connection,
+	message *preparedMessage,
+) map[Connection]error {
+	var failures map[Connection]error
+	for _, connection := range connections {
+		if !connection.IsActive() {
+			continue
+		}
+		if err := writePreparedMessage(connection.sock, message); err != nil {
+			if failures == nil {
+				failures = make(map[Connection]error)
+			}
+			failures[connection] = err
+		}
+	}
+	return failures
+}
@@ -1,6 +1,7 @@
 package webwire
 
 import (
+	"crypto/tls"
 	"log"
 	"os"
 	"time"
@@ -20,20 +21,152 @@ const (
 	Enabled
 )
 
+// MessageOrder represents the order in which
+// the messages received on a connection are processed
+type MessageOrder int32
+
+const (
+	// Unordered processes all messages of a connection concurrently
+	Unordered MessageOrder = iota
+
+	// OrderedPerConnection processes the messages of a connection
+	// sequentially in the order of their arrival
+	OrderedPerConnection
+
+	// OrderedPerName processes the messages of a connection
+	// sharing the same name sequentially in the order of their arrival
+	// while messages of different names are processed concurrently
+	OrderedPerName
+)
+
 // ServerOptions represents the options used during the creation of a new WebWire server instance
 type ServerOptions struct {
-	Address               string
-	Sessions              OptionValue
-	SessionManager        SessionManager
-	SessionKeyGenerator   SessionKeyGenerator
-	SessionInfoParser     SessionInfoParser
+	Address             string
+	Sessions            OptionValue
+	SessionManager      SessionManager
+	SessionKeyGenerator SessionKeyGenerator
+	SessionInfoParser   SessionInfoParser
+
+	// SessionExpiration defines the expiration policy of sessions created
+	// by Connection.CreateSession. Sessions never expire by default
+	SessionExpiration SessionExpiration
+
 	MaxConcurrentHandlers uint32
 	MaxSessionConnections uint
 	Heartbeat             OptionValue
 	HeartbeatTimeout      time.Duration
 	HeartbeatInterval     time.Duration
+	DefaultRequestTimeout time.Duration
 	WarnLog               *log.Logger
 	ErrorLog              *log.Logger
+
+	// Logger defines the structured logger used by the server.
+	// If undefined then WarnLog and ErrorLog are used through a StdLogger
+	Logger Logger
+
+	// RequestInterceptors defines the chain of interceptors
+	// wrapping ServerImplementation.OnRequest.
+	// The first interceptor is the outermost one
+	RequestInterceptors []RequestInterceptor
+
+	// SignalInterceptors defines the chain of interceptors
+	// wrapping ServerImplementation.OnSignal.
+	// The first interceptor is the outermost one
+	SignalInterceptors []SignalInterceptor
+
+	// MaxConnectionHandlers limits the number of concurrently executed
+	// handlers of a single connection, zero stands for unlimited.
+	// The handler slots limited by MaxConcurrentHandlers are shared among
+	// the connections in a round-robin fashion
+	MaxConnectionHandlers uint32
+
+	// MaxSessionHandlers limits the number of concurrently executed
+	// handlers of all connections of a single session,
+	// zero stands for unlimited
+	MaxSessionHandlers uint32
+
+	// MessageOrder defines the order in which the signals and requests
+	// received on a single connection are processed.
+	// Messages of different connections are always processed concurrently.
+	// Request cancelations and replies to server-side requests
+	// are never ordered. Defaults to Unordered
+	MessageOrder MessageOrder
+
+	// ReconnectAddress defines the alternative server address
+	// the clients are advised to reconnect to when the server shuts down.
+	// Clients reconnect to the same address if it's empty
+	ReconnectAddress string
+
+	// ReconnectDelay defines the duration the clients are advised to wait
+	// before reconnecting when the server shuts down
+	ReconnectDelay time.Duration
+
+	// Propagator defines the optional propagator extracting
+	// the values carried by the message headers into the handler contexts
+	Propagator Propagator
+
+	// Metrics defines the observer of the server metrics.
+	// Metrics aren't collected if it's nil
+	Metrics Metrics
+
+	// SubscriptionFilter decides whether clients are allowed to subscribe
+	// to the requested topics. All subscriptions are allowed if it's nil.
+	// Subscriptions made through Connection.Subscribe are not filtered
+	SubscriptionFilter SubscriptionFilter
+
+	// AllowedOrigins defines the origins browser clients are allowed
+	// to connect from such as "https://example.com".
+	// A wildcard subdomain such as "https://*.example.com" allows
+	// all subdomains of the domain and "*" allows any origin.
+	// Requests without an Origin header are always allowed.
+	// All origins are allowed if neither AllowedOrigins
+	// nor OriginFilter is defined
+	AllowedOrigins []string
+
+	// OriginFilter defines the optional predicate deciding
+	// whether requests from origins not listed in AllowedOrigins
+	// are allowed
+	OriginFilter OriginFilter
+
+	// Compression enables the negotiation of permessage-deflate
+	// compression with clients offering it. Disabled by default
+	Compression OptionValue
+
+	// CompressionLevel defines the flate compression level
+	// ranging from -2 (flate.HuffmanOnly) to 9 (flate.BestCompression).
+	// Zero is treated as unset and applies the default level 1
+	// (flate.BestSpeed) rather than flate.NoCompression,
+	// compression is disabled by the Compression option instead
+	CompressionLevel int
+
+	// CompressionThreshold defines the minimum size in bytes
+	// of compressed messages, smaller messages are sent uncompressed
+	CompressionThreshold int
+
+	// LongPolling enables the HTTP long polling fallback transport
+	// for clients that can't establish WebSocket connections.
+	// Disabled by default
+	LongPolling OptionValue
+
+	// ConnUpgrader defines the optional upgrader of incoming connections
+	// replacing the default WebSocket upgrader.
+	// The compression options don't apply to custom upgraders
+	ConnUpgrader ConnUpgrader
+
+	// TLSConfig defines the optional TLS configuration of a headed server.
+	// Server.Run serves TLS connections if either TLSConfig
+	// or TLSCertFile is defined.
+	// Set ClientAuth and ClientCAs to require client certificates
+	TLSConfig *tls.Config
+
+	// TLSCertFile defines the path to the PEM encoded certificate file
+	// used by Server.Run, it can be omitted if TLSConfig
+	// already provides a certificate
+	TLSCertFile string
+
+	// TLSKeyFile defines the path to the PEM encoded private key file
+	// matching TLSCertFile
+	TLSKeyFile string
 }
 
 // SetDefaults sets the defaults for undefined required values
@@ -61,6 +194,11 @@ func (srvOpt *ServerOptions) SetDefaults() {
 		srvOpt.Heartbeat = Disabled
 	}
 
+	// Disable long polling by default
+	if srvOpt.LongPolling == OptionUnset {
+		srvOpt.LongPolling = Disabled
+	}
+
 	// Use a default 60 seconds heartbeat timeout
 	// if the specified timeout is below 2 seconds
 	if srvOpt.HeartbeatTimeout < 2*time.Second {
@@ -73,6 +211,15 @@ func (srvOpt *ServerOptions) SetDefaults() {
 		srvOpt.HeartbeatInterval = 30 * time.Second
 	}
 
+	// Use a default 60 seconds timeout for requests sent to clients
+	if srvOpt.DefaultRequestTimeout < 1 {
+		srvOpt.DefaultRequestTimeout = 60 * time.Second
+	}
+
+	if srvOpt.Metrics == nil {
+		srvOpt.Metrics = NopMetrics{}
+	}
+
 	// Create default loggers to std-out/err when no loggers are specified
 	if srvOpt.WarnLog == nil {
 		srvOpt.WarnLog = log.New(
@@ -88,6 +235,9 @@ func (srvOpt *ServerOptions) SetDefaults() {
 			log.Ldate|log.Ltime|log.Lshortfile,
 		)
 	}
+	if srvOpt.Logger == nil {
+		srvOpt.Logger = NewStdLogger(srvOpt.WarnLog, srvOpt.ErrorLog)
+	}
 }
 
 // IsConcurrentHandlersLimited returns true if the number of
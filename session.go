@@ -35,6 +35,20 @@ func generateSessionKey() string {
 	return base64.URLEncoding.EncodeToString(bytes)
 }
 
+// isValidSessionKey returns true if the given session key is non-empty
+// and consists of printable 7-bit ASCII characters only
+func isValidSessionKey(key string) bool {
+	if len(key) < 1 {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 32 || key[i] > 126 {
+			return false
+		}
+	}
+	return true
+}
+
 // JSONEncodedSession represents a JSON encoded session object.
 // This structure is used during session restoration for unmarshalling
 // TODO: move to internal shared package
@@ -42,6 +56,7 @@ type JSONEncodedSession struct {
 	Key        string                 `json:"k"`
 	Creation   time.Time              `json:"c"`
 	LastLookup time.Time              `json:"l"`
+	Expiration SessionExpiration      `json:"x"`
 	Info       map[string]interface{} `json:"i,omitempty"`
 }
 
@@ -52,9 +67,16 @@ type Session struct {
 	Key        string
 	Creation   time.Time
 	LastLookup time.Time
+	Expiration SessionExpiration
 	Info       SessionInfo
 }
 
+// ExpiresAt returns the time the session expires at
+// or the zero time if it never expires
+func (s *Session) ExpiresAt() time.Time {
+	return s.Expiration.ExpiresAt(s.Creation, s.LastLookup)
+}
+
 // Clone returns an exact copy of the session object
 func (s *Session) Clone() *Session {
 	if s == nil {
@@ -70,6 +92,7 @@ func (s *Session) Clone() *Session {
 		Key:        s.Key,
 		Creation:   s.Creation,
 		LastLookup: s.LastLookup,
+		Expiration: s.Expiration,
 		Info:       info,
 	}
 }
@@ -83,10 +106,10 @@ func NewSession(info SessionInfo, generator func() string) Session {
 	}
 	timeNow := time.Now()
 	return Session{
-		key,
-		timeNow,
-		timeNow,
-		info,
+		Key:        key,
+		Creation:   timeNow,
+		LastLookup: timeNow,
+		Info:       info,
 	}
 }
 
@@ -0,0 +1,36 @@
+package webwire
+
+// SessionCloseReason represents the reason of a session closure
+// reported to the client
+type SessionCloseReason byte
+
+const (
+	// SessionCloseUnspecified represents an unknown closure reason,
+	// it's reported by servers not providing a closure reason
+	SessionCloseUnspecified SessionCloseReason = iota
+
+	// SessionCloseClient represents a session closed
+	// on request of the client
+	SessionCloseClient
+
+	// SessionCloseServer represents a session closed
+	// by the server implementation
+	SessionCloseServer
+
+	// SessionCloseExpired represents a session closed
+	// because of its expiration
+	SessionCloseExpired
+)
+
+// String stringifies the closure reason
+func (reason SessionCloseReason) String() string {
+	switch reason {
+	case SessionCloseClient:
+		return "client"
+	case SessionCloseServer:
+		return "server"
+	case SessionCloseExpired:
+		return "expired"
+	}
+	return "unspecified"
+}
@@ -0,0 +1,55 @@
+package webwire
+
+import "time"
+
+// SessionExpiration defines the expiration policy of a session.
+// A zero duration disables the according policy,
+// the zero value thus represents a session that never expires
+type SessionExpiration struct {
+	// MaxAge defines the absolute lifetime of the session
+	// counted from its creation
+	MaxAge time.Duration `json:"a,omitempty"`
+
+	// IdleTimeout defines the sliding lifetime of the session
+	// counted from its last lookup
+	IdleTimeout time.Duration `json:"i,omitempty"`
+}
+
+// IsZero returns true if neither of the expiration policies is enabled
+func (exp SessionExpiration) IsZero() bool {
+	return exp.MaxAge <= 0 && exp.IdleTimeout <= 0
+}
+
+// ExpiresAt returns the time the session created and last looked up
+// at the given times expires at.
+// Returns the zero time if the session never expires
+func (exp SessionExpiration) ExpiresAt(
+	creation time.Time,
+	lastLookup time.Time,
+) time.Time {
+	var expiresAt time.Time
+	if exp.MaxAge > 0 {
+		expiresAt = creation.Add(exp.MaxAge)
+	}
+	if exp.IdleTimeout > 0 {
+		if lastLookup.Before(creation) {
+			lastLookup = creation
+		}
+		idleExpiry := lastLookup.Add(exp.IdleTimeout)
+		if expiresAt.IsZero() || idleExpiry.Before(expiresAt) {
+			expiresAt = idleExpiry
+		}
+	}
+	return expiresAt
+}
+
+// Expired returns true if the session created and last looked up
+// at the given times is expired at the given point in time
+func (exp SessionExpiration) Expired(
+	creation time.Time,
+	lastLookup time.Time,
+	now time.Time,
+) bool {
+	expiresAt := exp.ExpiresAt(creation, lastLookup)
+	return !expiresAt.IsZero() && !now.Before(expiresAt)
+}
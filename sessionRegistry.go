@@ -3,6 +3,7 @@ package webwire
 import (
 	"fmt"
 	"sync"
+	"time"
 )
 
 // sessionRegistry represents a thread safe registry of all currently active sessions
@@ -10,6 +11,19 @@ type sessionRegistry struct {
 	lock     sync.RWMutex
 	maxConns uint
 	registry map[string][]*connection
+
+	// timers holds the expiry timers of the active sessions
+	// with a limited lifetime
+	timers map[string]*time.Timer
+
+	// onExpire is invoked in a separate goroutine
+	// when the lifetime of an active session is over
+	onExpire func(sessionKey string)
+
+	// onActivityChanged is invoked after the registry is unlocked
+	// when a session is either attached to its first connection
+	// or detached from its last connection
+	onActivityChanged func(sessionKey string, active bool)
 }
 
 // newSessionRegistry returns a new instance of a session registry.
@@ -20,6 +34,7 @@ func newSessionRegistry(maxConns uint) *sessionRegistry {
 		lock:     sync.RWMutex{},
 		maxConns: maxConns,
 		registry: make(map[string][]*connection),
+		timers:   make(map[string]*time.Timer),
 	}
 }
 
@@ -27,26 +42,66 @@ func newSessionRegistry(maxConns uint) *sessionRegistry {
 // Returns an error if the given clients session already reached
 // the maximum number of concurrent connections
 func (asr *sessionRegistry) register(con *connection) error {
+	key := con.session.Key
+
 	asr.lock.Lock()
-	defer asr.lock.Unlock()
-	if connList, exists := asr.registry[con.session.Key]; exists {
+	if connList, exists := asr.registry[key]; exists {
 		// Ensure max connections isn't exceeded
 		if asr.maxConns > 0 && uint(len(connList)+1) > asr.maxConns {
+			asr.lock.Unlock()
 			return fmt.Errorf(
 				"Max conns (%d) reached for session %s",
 				asr.maxConns,
-				con.session.Key,
+				key,
 			)
 		}
 		// Overwrite the current entry incrementing the number of connections
-		asr.registry[con.session.Key] = append(connList, con)
+		asr.registry[key] = append(connList, con)
+		asr.lock.Unlock()
 		return nil
 	}
 	newList := []*connection{con}
-	asr.registry[con.session.Key] = newList
+	asr.registry[key] = newList
+	asr.startTimer(key, con.session)
+	asr.lock.Unlock()
+
+	asr.activityChanged(key, true)
 	return nil
 }
 
+// startTimer starts the expiry timer of the session identified
+// by the given key if its lifetime is limited.
+// Only the absolute lifetime is enforced on active sessions
+// because sessions in use are never idle.
+// The lock must be held by the caller
+func (asr *sessionRegistry) startTimer(key string, session *Session) {
+	if session.Expiration.MaxAge <= 0 || asr.onExpire == nil {
+		return
+	}
+	expiresAt := session.Creation.Add(session.Expiration.MaxAge)
+	asr.timers[key] = time.AfterFunc(time.Until(expiresAt), func() {
+		asr.onExpire(key)
+	})
+}
+
+// activityChanged reports the activity change of the given session if
+// there's a listener. The lock must not be held by the caller
+// to allow the listener to access the registry
+func (asr *sessionRegistry) activityChanged(sessionKey string, active bool) {
+	if asr.onActivityChanged != nil {
+		asr.onActivityChanged(sessionKey, active)
+	}
+}
+
+// stopTimer stops and removes the expiry timer of the given session if any.
+// The lock must be held by the caller
+func (asr *sessionRegistry) stopTimer(sessionKey string) {
+	if timer, exists := asr.timers[sessionKey]; exists {
+		timer.Stop()
+		delete(asr.timers, sessionKey)
+	}
+}
+
 // deregister removes a connection from the list of connections of a session
 // returns the number of connections left.
 // If there's only one connection left then the entire session will be removed
@@ -56,27 +111,56 @@ func (asr *sessionRegistry) deregister(con *connection) int {
 	if con.session == nil {
 		return -1
 	}
+	key := con.session.Key
 
 	asr.lock.Lock()
-	defer asr.lock.Unlock()
-	if connList, exists := asr.registry[con.session.Key]; exists {
-		// If a single connection is left then remove the session
-		if len(connList) < 2 {
-			delete(asr.registry, con.session.Key)
-			return 0
-		}
-		// Find and remove the client from the connections list
-		for index, conn := range connList {
-			if conn == con {
-				asr.registry[con.session.Key] = append(
-					connList[:index],
-					connList[index+1:]...,
-				)
-			}
+	connList, exists := asr.registry[key]
+	if !exists {
+		asr.lock.Unlock()
+		return -1
+	}
+
+	// If a single connection is left then remove the session
+	if len(connList) < 2 {
+		delete(asr.registry, key)
+		asr.stopTimer(key)
+		asr.lock.Unlock()
+
+		asr.activityChanged(key, false)
+		return 0
+	}
+
+	// Find and remove the client from the connections list
+	for index, conn := range connList {
+		if conn == con {
+			asr.registry[key] = append(
+				connList[:index],
+				connList[index+1:]...,
+			)
 		}
-		return len(connList) - 1
 	}
-	return -1
+	asr.lock.Unlock()
+	return len(connList) - 1
+}
+
+// rename moves the connections and the expiry timer of the session
+// identified by the old key to the new key.
+// The sessions of the connections must be updated by the caller
+func (asr *sessionRegistry) rename(oldKey, newKey string) {
+	asr.lock.Lock()
+	defer asr.lock.Unlock()
+	connList, exists := asr.registry[oldKey]
+	if !exists {
+		return
+	}
+	delete(asr.registry, oldKey)
+	asr.registry[newKey] = append(asr.registry[newKey], connList...)
+
+	if _, hasTimer := asr.timers[oldKey]; hasTimer {
+		asr.stopTimer(oldKey)
+		asr.stopTimer(newKey)
+		asr.startTimer(newKey, connList[0].session)
+	}
 }
 
 // activeSessionsNum returns the number of currently active sessions
@@ -102,7 +186,9 @@ func (asr *sessionRegistry) sessionConnections(sessionKey string) []*connection
 	asr.lock.RLock()
 	defer asr.lock.RUnlock()
 	if connList, exists := asr.registry[sessionKey]; exists {
-		return connList
+		list := make([]*connection, len(connList))
+		copy(list, connList)
+		return list
 	}
 	return nil
 }
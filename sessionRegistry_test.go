@@ -4,6 +4,7 @@ import (
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // TestSessRegRegisteration tests registeration
@@ -390,3 +391,118 @@ func TestSessRegSessionConnections(t *testing.T) {
 		t.Fatal("Expected cltA2 to be in the list of active connections")
 	}
 }
+
+// TestSessRegExpiryTimer tests the expiry timers of sessions
+// with a limited lifetime
+func TestSessRegExpiryTimer(t *testing.T) {
+	reg := newSessionRegistry(0)
+	expired := make(chan string, 2)
+	reg.onExpire = func(sessionKey string) {
+		expired <- sessionKey
+	}
+
+	// Register a short-lived session A
+	cltA := newConnection(nil, "A", nil)
+	sessA := NewSession(nil, func() string { return "testkey_A" })
+	sessA.Expiration.MaxAge = 20 * time.Millisecond
+	cltA.session = &sessA
+
+	// Register a short-lived session B and deregister it before it expires
+	cltB := newConnection(nil, "B", nil)
+	sessB := NewSession(nil, func() string { return "testkey_B" })
+	sessB.Expiration.MaxAge = 20 * time.Millisecond
+	cltB.session = &sessB
+
+	for _, clt := range []*connection{cltA, cltB} {
+		if err := reg.register(clt); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+	reg.deregister(cltB)
+
+	select {
+	case key := <-expired:
+		if key != "testkey_A" {
+			t.Fatalf("Unexpected expired session: %s", key)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected session testkey_A to expire")
+	}
+
+	select {
+	case key := <-expired:
+		t.Fatalf("Unexpected expiry of deregistered session: %s", key)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestSessRegRename tests renaming a session
+func TestSessRegRename(t *testing.T) {
+	reg := newSessionRegistry(0)
+	expired := make(chan string, 1)
+	reg.onExpire = func(sessionKey string) {
+		expired <- sessionKey
+	}
+
+	clt := newConnection(nil, "A", nil)
+	sess := NewSession(nil, func() string { return "testkey_A" })
+	sess.Expiration.MaxAge = 20 * time.Millisecond
+	clt.session = &sess
+
+	if err := reg.register(clt); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	reg.rename("testkey_A", "testkey_B")
+
+	if reg.sessionConnectionsNum("testkey_A") != -1 {
+		t.Fatal("Expected the old session key to be removed")
+	}
+	list := reg.sessionConnections("testkey_B")
+	if len(list) != 1 || list[0] != clt {
+		t.Fatal("Expected the connection to be moved to the new session key")
+	}
+
+	// Expect the expiry timer to be moved along
+	select {
+	case key := <-expired:
+		if key != "testkey_B" {
+			t.Fatalf("Unexpected expired session: %s", key)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected session testkey_B to expire")
+	}
+}
+
+// TestSessRegActivity tests the reporting of sessions becoming
+// active and inactive after the registry is unlocked
+func TestSessRegActivity(t *testing.T) {
+	reg := newSessionRegistry(0)
+	changes := []bool{}
+	reg.onActivityChanged = func(sessionKey string, active bool) {
+		if sessionKey != "testkey_A" {
+			t.Errorf("Unexpected session: %s", sessionKey)
+		}
+		// Access the registry from within the listener
+		reg.sessionConnectionsNum(sessionKey)
+		changes = append(changes, active)
+	}
+
+	sess := NewSession(nil, func() string { return "testkey_A" })
+	clt1 := newConnection(nil, "A1", nil)
+	clt1.session = &sess
+	clt2 := newConnection(nil, "A2", nil)
+	clt2.session = &sess
+
+	for _, clt := range []*connection{clt1, clt2} {
+		if err := reg.register(clt); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+	reg.deregister(clt1)
+	reg.deregister(clt2)
+
+	if len(changes) != 2 || !changes[0] || changes[1] {
+		t.Fatalf("Unexpected activity changes: %v", changes)
+	}
+}
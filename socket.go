@@ -1,6 +1,7 @@
 package webwire
 
 import (
+	"crypto/tls"
 	"net"
 	"net/http"
 	"time"
@@ -55,3 +56,56 @@ type Socket interface {
 type ConnUpgrader interface {
 	Upgrade(resp http.ResponseWriter, req *http.Request) (Socket, error)
 }
+
+// SocketOptions represents the options of client sockets
+type SocketOptions struct {
+	// TLS enables dialing over TLS (wss) using the TLSConfig
+	TLS bool
+
+	// TLSConfig defines the optional TLS configuration,
+	// the default configuration is used if it's nil
+	TLSConfig *tls.Config
+
+	// Compression enables the negotiation
+	// of permessage-deflate compression. Disabled by default
+	Compression OptionValue
+
+	// CompressionLevel defines the flate compression level
+	// ranging from -2 (flate.HuffmanOnly) to 9 (flate.BestCompression).
+	// Zero is treated as unset and applies the default level 1
+	// (flate.BestSpeed) rather than flate.NoCompression,
+	// compression is disabled by the Compression option instead
+	CompressionLevel int
+
+	// CompressionThreshold defines the minimum size in bytes
+	// of compressed messages, smaller messages are sent uncompressed
+	CompressionThreshold int
+
+	// PollingFallback enables falling back to HTTP long polling
+	// if the WebSocket connection can't be established.
+	// Disabled by default
+	PollingFallback OptionValue
+}
+
+// SocketFactory represents the type of a function
+// creating disconnected client sockets using the given options
+type SocketFactory func(opts SocketOptions) Socket
+
+// ProtocolVersionHeader defines the HTTP header carrying the protocol version
+// of the client in metadata and upgrade requests.
+// Clients not sending it are considered to run the legacy protocol version
+const ProtocolVersionHeader = "Webwire-Protocol-Version"
+
+// EndpointMetadata represents the metadata of a webwire server endpoint
+type EndpointMetadata struct {
+	ProtocolVersion string `json:"protocol-version"`
+}
+
+// MetadataReader defines the optional interface of client sockets
+// reading the endpoint metadata through their own transport
+// instead of an HTTP request
+type MetadataReader interface {
+	// ReadMetadata must return the metadata of the server endpoint
+	// at the given address
+	ReadMetadata(serverAddr string) (EndpointMetadata, error)
+}
@@ -1,10 +1,12 @@
 package webwire
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,18 +16,28 @@ import (
 // connUpgrader implements the webwire.ConnUpgrader interface using
 // the gorilla/websocket library
 type connUpgrader struct {
-	gorillaWsUpgrader websocket.Upgrader
+	gorillaWsUpgrader    websocket.Upgrader
+	compressionLevel     int
+	compressionThreshold int
 }
 
 // newConnUpgrader constructs a new default HTTP connection upgrader
-// based on gorilla/websocket
-func newConnUpgrader() *connUpgrader {
+// based on gorilla/websocket negotiating compression if enabled
+func newConnUpgrader(
+	compression bool,
+	compressionLevel int,
+	compressionThreshold int,
+) *connUpgrader {
 	return &connUpgrader{
 		gorillaWsUpgrader: websocket.Upgrader{
+			// Origins are checked by the server before upgrading
 			CheckOrigin: func(_ *http.Request) bool {
 				return true
 			},
+			EnableCompression: compression,
 		},
+		compressionLevel:     compressionLevel,
+		compressionThreshold: compressionThreshold,
 	}
 }
 
@@ -38,7 +50,20 @@ func (upgrader *connUpgrader) Upgrade(
 	if err != nil {
 		return nil, err
 	}
-	return newConnectedSocket(conn), nil
+	sock := newConnectedSocket(conn)
+
+	// Compression is negotiated if the client offers it
+	if upgrader.gorillaWsUpgrader.EnableCompression &&
+		offersCompression(req.Header) {
+		if err := sock.enableCompression(
+			upgrader.compressionLevel,
+			upgrader.compressionThreshold,
+		); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return sock, nil
 }
 
 // sockReadErr implements the webwire.SockReadErr interface using
@@ -67,10 +92,16 @@ type socket struct {
 	connected bool
 	lock      sync.RWMutex
 	conn      *websocket.Conn
+	options   SocketOptions
+
+	// compressed is true if compression was negotiated on the connection,
+	// messages smaller than the compressionThreshold are sent uncompressed
+	compressed           bool
+	compressionThreshold int
 }
 
 // newConnectedSocket creates a new gorilla/websocket based socket instance
-func newConnectedSocket(conn *websocket.Conn) Socket {
+func newConnectedSocket(conn *websocket.Conn) *socket {
 	connected := false
 	if conn != nil {
 		connected = true
@@ -84,30 +115,98 @@ func newConnectedSocket(conn *websocket.Conn) Socket {
 
 // NewSocket creates a new disconnected gorilla/websocket based socket instance
 func NewSocket() Socket {
-	connected := false
-	return &socket{
-		connected: connected,
+	return NewSocketWithOptions(SocketOptions{})
+}
+
+// NewTLSSocket creates a new disconnected gorilla/websocket based socket
+// instance dialing over TLS (wss) using the given TLS configuration.
+// The default TLS configuration is used if config is nil
+func NewTLSSocket(config *tls.Config) Socket {
+	return NewSocketWithOptions(SocketOptions{
+		TLS:       true,
+		TLSConfig: config,
+	})
+}
+
+// NewSocketWithOptions creates a new disconnected gorilla/websocket
+// based socket instance using the given options.
+// The socket falls back to HTTP long polling if the PollingFallback option
+// is enabled and the WebSocket connection can't be established
+func NewSocketWithOptions(opts SocketOptions) Socket {
+	sock := &socket{
+		connected: false,
 		lock:      sync.RWMutex{},
+		options:   opts,
+	}
+	if opts.PollingFallback == Enabled {
+		return newFallbackSocket(sock, NewPollingSocket(opts))
 	}
+	return sock
 }
 
 // Dial implements the webwire.Socket interface
 func (sock *socket) Dial(serverAddr string) (err error) {
 	connURL := url.URL{Scheme: "ws", Host: serverAddr, Path: "/"}
+	dialer := &websocket.Dialer{
+		Proxy:             http.ProxyFromEnvironment,
+		HandshakeTimeout:  websocket.DefaultDialer.HandshakeTimeout,
+		EnableCompression: sock.options.Compression == Enabled,
+	}
+	if sock.options.TLS {
+		connURL.Scheme = "wss"
+		dialer.TLSClientConfig = sock.options.TLSConfig
+	}
 	sock.lock.Lock()
 	defer sock.lock.Unlock()
 	if sock.connected {
 		sock.conn.Close()
 		sock.conn = nil
 	}
-	sock.conn, _, err = websocket.DefaultDialer.Dial(connURL.String(), nil)
+	sock.compressed = false
+	var resp *http.Response
+	sock.conn, resp, err = dialer.Dial(connURL.String(), http.Header{
+		ProtocolVersionHeader: []string{protocolVersion},
+	})
 	if err != nil {
 		return NewDisconnectedErr(fmt.Errorf("Dial failure: %s", err))
 	}
+
+	// Determine whether the server accepted the compression offer
+	if dialer.EnableCompression && strings.Contains(
+		resp.Header.Get("Sec-Websocket-Extensions"),
+		"permessage-deflate",
+	) {
+		if err := sock.enableCompression(
+			sock.options.CompressionLevel,
+			sock.options.CompressionThreshold,
+		); err != nil {
+			sock.conn.Close()
+			return NewDisconnectedErr(fmt.Errorf("Dial failure: %s", err))
+		}
+	}
 	sock.connected = true
 	return nil
 }
 
+// enableCompression configures the negotiated compression
+func (sock *socket) enableCompression(level, threshold int) error {
+	if level != 0 {
+		if err := sock.conn.SetCompressionLevel(level); err != nil {
+			return err
+		}
+	}
+	sock.compressed = true
+	sock.compressionThreshold = threshold
+	return nil
+}
+
+// isCompressed implements the compressedSocket interface
+func (sock *socket) isCompressed() bool {
+	sock.lock.RLock()
+	defer sock.lock.RUnlock()
+	return sock.compressed
+}
+
 // Write implements the webwire.Socket interface
 func (sock *socket) Write(data []byte) error {
 	sock.lock.Lock()
@@ -117,9 +216,31 @@ func (sock *socket) Write(data []byte) error {
 			Cause: fmt.Errorf("Can't write to a socket"),
 		}
 	}
+	if sock.compressed {
+		sock.conn.EnableWriteCompression(
+			len(data) >= sock.compressionThreshold,
+		)
+	}
 	return sock.conn.WriteMessage(websocket.BinaryMessage, data)
 }
 
+// writePrepared implements the preparedWriter interface
+func (sock *socket) writePrepared(message *preparedMessage) error {
+	sock.lock.Lock()
+	defer sock.lock.Unlock()
+	if !sock.connected {
+		return DisconnectedErr{
+			Cause: fmt.Errorf("Can't write to a socket"),
+		}
+	}
+	if sock.compressed {
+		sock.conn.EnableWriteCompression(
+			len(message.data) >= sock.compressionThreshold,
+		)
+	}
+	return sock.conn.WritePreparedMessage(message.prepared)
+}
+
 // Read implements the webwire.Socket interface
 func (sock *socket) Read() ([]byte, SockReadErr) {
 	_, message, err := sock.conn.ReadMessage()
@@ -173,3 +294,43 @@ func (sock *socket) OnPing(handler func(string) error) {
 func (sock *socket) WritePing(data []byte, deadline time.Time) error {
 	return sock.conn.WriteControl(websocket.PingMessage, data, deadline)
 }
+
+// preparedMessage represents a message encoded only once
+// to be written to multiple sockets
+type preparedMessage struct {
+	data     []byte
+	prepared *websocket.PreparedMessage
+}
+
+// newPreparedMessage prepares the given encoded message.
+// If the preparation fails the message is written to the sockets
+// as a regular message
+func newPreparedMessage(data []byte) *preparedMessage {
+	prepared, err := websocket.NewPreparedMessage(
+		websocket.BinaryMessage,
+		data,
+	)
+	if err != nil {
+		prepared = nil
+	}
+	return &preparedMessage{
+		data:     data,
+		prepared: prepared,
+	}
+}
+
+// preparedWriter defines the interface of sockets
+// capable of writing prepared messages
+type preparedWriter interface {
+	writePrepared(message *preparedMessage) error
+}
+
+// writePreparedMessage writes the given prepared message to the socket.
+// Falls back to writing the raw message data if the socket implementation
+// doesn't support prepared messages
+func writePreparedMessage(sock Socket, message *preparedMessage) error {
+	if writer, ok := sock.(preparedWriter); ok && message.prepared != nil {
+		return writer.writePrepared(message)
+	}
+	return sock.Write(message.data)
+}
@@ -0,0 +1,165 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tmdwg "github.com/qbeon/tmdwg-go"
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// TestSignalSession tests signaling all connections of a session
+// while connections of other sessions don't receive the signal
+func TestSignalSession(t *testing.T) {
+	expectedPayload := wwr.NewPayload(
+		wwr.EncodingUtf8,
+		[]byte("webwire_test_SIGNAL_SESSION_payload"),
+	)
+	sessionSignaled := tmdwg.NewTimedWaitGroup(2, 1*time.Second)
+
+	// Initialize webwire server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onRequest: func(
+				_ context.Context,
+				conn wwr.Connection,
+				_ wwr.Message,
+			) (wwr.Payload, error) {
+				return nil, conn.CreateSession(nil)
+			},
+		},
+		wwr.ServerOptions{},
+	)
+
+	newClient := func(onSignal func(wwr.Payload)) *callbackPoweredClient {
+		client := newCallbackPoweredClient(
+			server.Addr().String(),
+			wwrclt.Options{
+				DefaultRequestTimeout: 2 * time.Second,
+			},
+			callbackPoweredClientHooks{
+				OnSignal: onSignal,
+			},
+		)
+		if err := client.connection.Connect(); err != nil {
+			t.Fatalf("Couldn't connect: %s", err)
+		}
+		return client
+	}
+	onSessionSignal := func(payload wwr.Payload) {
+		comparePayload(t, "signal", expectedPayload, payload)
+		sessionSignaled.Progress(1)
+	}
+
+	// Initialize two clients of the same session
+	clientA1 := newClient(onSessionSignal)
+	defer clientA1.connection.Close()
+	if _, err := clientA1.connection.Request(
+		context.Background(),
+		"login",
+		nil,
+	); err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	sessionKey := clientA1.connection.Session().Key
+
+	clientA2 := newClient(onSessionSignal)
+	defer clientA2.connection.Close()
+	if err := clientA2.connection.RestoreSession(
+		[]byte(sessionKey),
+	); err != nil {
+		t.Fatalf("Couldn't restore session: %s", err)
+	}
+
+	// Initialize a client of another session
+	clientB := newClient(func(wwr.Payload) {
+		t.Errorf("Unexpected signal on a connection of another session")
+	})
+	defer clientB.connection.Close()
+	if _, err := clientB.connection.Request(
+		context.Background(),
+		"login",
+		nil,
+	); err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+
+	if failures := server.SignalSession(
+		sessionKey,
+		"",
+		expectedPayload,
+	); failures != nil {
+		t.Fatalf("Unexpected delivery failures: %v", failures)
+	}
+
+	if err := sessionSignaled.Wait(); err != nil {
+		t.Fatal("Signal wasn't received by all connections of the session")
+	}
+}
+
+// TestBroadcast tests broadcasting a signal to all connections
+// and to the connections matching a filter
+func TestBroadcast(t *testing.T) {
+	expectedPayload := wwr.NewPayload(
+		wwr.EncodingUtf8,
+		[]byte("webwire_test_BROADCAST_payload"),
+	)
+	broadcastReceived := tmdwg.NewTimedWaitGroup(3, 1*time.Second)
+	connected := make(chan wwr.Connection, 2)
+
+	// Initialize webwire server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onClientConnected: func(conn wwr.Connection) {
+				connected <- conn
+			},
+		},
+		wwr.ServerOptions{},
+	)
+
+	// Initialize clients
+	for i := 0; i < 2; i++ {
+		client := newCallbackPoweredClient(
+			server.Addr().String(),
+			wwrclt.Options{
+				DefaultRequestTimeout: 2 * time.Second,
+			},
+			callbackPoweredClientHooks{
+				OnSignal: func(payload wwr.Payload) {
+					comparePayload(t, "signal", expectedPayload, payload)
+					broadcastReceived.Progress(1)
+				},
+			},
+		)
+		defer client.connection.Close()
+		if err := client.connection.Connect(); err != nil {
+			t.Fatalf("Couldn't connect: %s", err)
+		}
+	}
+	first := <-connected
+	<-connected
+
+	// Broadcast to all connections
+	if failures := server.Broadcast("", expectedPayload, nil); failures != nil {
+		t.Fatalf("Unexpected delivery failures: %v", failures)
+	}
+
+	// Broadcast to the first connection only
+	if failures := server.Broadcast(
+		"",
+		expectedPayload,
+		func(conn wwr.Connection) bool {
+			return conn.ID() == first.ID()
+		},
+	); failures != nil {
+		t.Fatalf("Unexpected delivery failures: %v", failures)
+	}
+
+	if err := broadcastReceived.Wait(); err != nil {
+		t.Fatal("Broadcast wasn't received by all connections")
+	}
+}
@@ -1,15 +1,19 @@
 package test
 
 import (
+	"context"
+
 	wwr "github.com/qbeon/webwire-go"
 	wwrclt "github.com/qbeon/webwire-go/client"
 )
 
 type callbackPoweredClientHooks struct {
 	OnSessionCreated func(*wwr.Session)
-	OnSessionClosed  func()
+	OnSessionUpdated func(*wwr.Session)
+	OnSessionClosed  func(wwr.SessionCloseReason)
 	OnDisconnected   func()
 	OnSignal         func(wwr.Payload)
+	OnRequest        func(context.Context, wwr.Message) (wwr.Payload, error)
 }
 
 // callbackPoweredClient implements the wwrclt.Implementation interface
@@ -42,10 +46,19 @@ func (clt *callbackPoweredClient) OnSessionCreated(newSession *wwr.Session) {
 	}
 }
 
+// OnSessionUpdated implements the wwrclt.Implementation interface
+func (clt *callbackPoweredClient) OnSessionUpdated(session *wwr.Session) {
+	if clt.hooks.OnSessionUpdated != nil {
+		clt.hooks.OnSessionUpdated(session)
+	}
+}
+
 // OnSessionClosed implements the wwrclt.Implementation interface
-func (clt *callbackPoweredClient) OnSessionClosed() {
+func (clt *callbackPoweredClient) OnSessionClosed(
+	reason wwr.SessionCloseReason,
+) {
 	if clt.hooks.OnSessionClosed != nil {
-		clt.hooks.OnSessionClosed()
+		clt.hooks.OnSessionClosed(reason)
 	}
 }
 
@@ -62,3 +75,14 @@ func (clt *callbackPoweredClient) OnSignal(message wwr.Payload) {
 		clt.hooks.OnSignal(message)
 	}
 }
+
+// OnRequest implements the wwrclt.Implementation interface
+func (clt *callbackPoweredClient) OnRequest(
+	ctx context.Context,
+	message wwr.Message,
+) (wwr.Payload, error) {
+	if clt.hooks.OnRequest != nil {
+		return clt.hooks.OnRequest(ctx, message)
+	}
+	return nil, nil
+}
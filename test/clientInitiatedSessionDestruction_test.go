@@ -88,7 +88,10 @@ func TestClientInitiatedSessionDestruction(t *testing.T) {
 				// Mark the client-side session creation callback as executed
 				sessionCreationCallbackCalled.Progress(1)
 			},
-			OnSessionClosed: func() {
+			OnSessionClosed: func(reason webwire.SessionCloseReason) {
+				if reason != webwire.SessionCloseClient {
+					t.Errorf("Unexpected session closure reason: %s", reason)
+				}
 				// Ensure this callback is called during the
 				if currentStep != 3 {
 					t.Errorf(
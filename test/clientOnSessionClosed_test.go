@@ -55,7 +55,10 @@ func TestClientOnSessionClosed(t *testing.T) {
 			DefaultRequestTimeout: 2 * time.Second,
 		},
 		callbackPoweredClientHooks{
-			OnSessionClosed: func() {
+			OnSessionClosed: func(reason webwire.SessionCloseReason) {
+				if reason != webwire.SessionCloseServer {
+					t.Errorf("Unexpected session closure reason: %s", reason)
+				}
 				hookCalled.Progress(1)
 			},
 		},
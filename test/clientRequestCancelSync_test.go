@@ -0,0 +1,76 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tmdwg "github.com/qbeon/tmdwg-go"
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// TestClientRequestCancelSync tests whether canceling a request on the
+// client cancels the context of the according request handler on the server
+// while the connection remains open
+func TestClientRequestCancelSync(t *testing.T) {
+	handlerStarted := tmdwg.NewTimedWaitGroup(1, 1*time.Second)
+	handlerCanceled := tmdwg.NewTimedWaitGroup(1, 1*time.Second)
+
+	// Initialize webwire server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onRequest: func(
+				ctx context.Context,
+				_ wwr.Connection,
+				_ wwr.Message,
+			) (wwr.Payload, error) {
+				handlerStarted.Progress(1)
+				select {
+				case <-ctx.Done():
+					handlerCanceled.Progress(1)
+				case <-time.After(2 * time.Second):
+					t.Errorf("Handler context wasn't canceled")
+				}
+				return nil, nil
+			},
+		},
+		wwr.ServerOptions{},
+	)
+
+	// Initialize client
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 5 * time.Second,
+		},
+		callbackPoweredClientHooks{},
+	)
+	defer client.connection.Close()
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+
+	cancelableCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Send request and cancel it as soon as it's being handled
+	go func() {
+		if err := handlerStarted.Wait(); err != nil {
+			t.Errorf("Handler wasn't executed")
+			return
+		}
+		cancel()
+	}()
+
+	_, err := client.connection.Request(cancelableCtx, "test", nil)
+	if !wwr.IsCanceledErr(err) {
+		t.Fatalf("Expected a canceled-error, got: %v", err)
+	}
+
+	if err := handlerCanceled.Wait(); err != nil {
+		t.Fatal("Handler context wasn't canceled by the client")
+	}
+}
@@ -0,0 +1,106 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// TestCompression tests negotiating permessage-deflate compression
+// and exchanging messages above and below the compression threshold
+func TestCompression(t *testing.T) {
+	compressed := make(chan bool, 1)
+	largePayload := bytes.Repeat([]byte(`{"key":"value"},`), 1024)
+
+	server := setupServer(
+		t,
+		&serverImpl{
+			onClientConnected: func(conn wwr.Connection) {
+				compressed <- conn.Info().Compressed
+			},
+			onRequest: func(
+				_ context.Context,
+				_ wwr.Connection,
+				msg wwr.Message,
+			) (wwr.Payload, error) {
+				return msg.Payload(), nil
+			},
+		},
+		wwr.ServerOptions{
+			Compression:          wwr.Enabled,
+			CompressionThreshold: 512,
+		},
+	)
+
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+			Autoconnect:           wwr.Disabled,
+			Compression:           wwr.Enabled,
+			CompressionLevel:      9,
+			CompressionThreshold:  512,
+		},
+		callbackPoweredClientHooks{},
+	)
+	defer client.connection.Close()
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+	if !<-compressed {
+		t.Fatal("Expected compression to be negotiated")
+	}
+
+	for _, data := range [][]byte{[]byte("small"), largePayload} {
+		reply, err := client.connection.Request(
+			context.Background(),
+			"",
+			wwr.NewPayload(wwr.EncodingUtf8, data),
+		)
+		if err != nil {
+			t.Fatalf("Request failed: %s", err)
+		}
+		if !bytes.Equal(reply.Data(), data) {
+			t.Errorf("Unexpected reply of %d bytes", len(reply.Data()))
+		}
+	}
+}
+
+// TestCompressionDisabledClient tests connecting without compression
+// when only the server enables it
+func TestCompressionDisabledClient(t *testing.T) {
+	compressed := make(chan bool, 1)
+
+	server := setupServer(
+		t,
+		&serverImpl{
+			onClientConnected: func(conn wwr.Connection) {
+				compressed <- conn.Info().Compressed
+			},
+		},
+		wwr.ServerOptions{
+			Compression: wwr.Enabled,
+		},
+	)
+
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			Autoconnect: wwr.Disabled,
+		},
+		callbackPoweredClientHooks{},
+	)
+	defer client.connection.Close()
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+	if <-compressed {
+		t.Error("Expected compression not to be negotiated")
+	}
+}
@@ -0,0 +1,79 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// TestConnectionRegistry tests the lookup of connected clients
+// and their removal after disconnection
+func TestConnectionRegistry(t *testing.T) {
+	connected := make(chan wwr.Connection, 1)
+
+	// Initialize webwire server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onClientConnected: func(conn wwr.Connection) {
+				connected <- conn
+			},
+		},
+		wwr.ServerOptions{},
+	)
+
+	// Initialize client
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+			Autoconnect:           wwr.Disabled,
+		},
+		callbackPoweredClientHooks{},
+	)
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+	conn := <-connected
+
+	// Expect the connection to be registered
+	if num := server.ConnectionsNum(); num != 1 {
+		t.Fatalf("Expected 1 connection, got: %d", num)
+	}
+	if server.Connection(conn.ID()) != conn {
+		t.Fatal("Expected the connection to be found by its identifier")
+	}
+	if list := server.Connections(); len(list) != 1 || list[0] != conn {
+		t.Fatalf("Unexpected list of connections: %v", list)
+	}
+
+	// Expect the connection to be found by its remote address
+	found := 0
+	server.ForEachConnection(
+		wwr.ByRemoteAddr(conn.Info().RemoteAddr.String()),
+		func(wwr.Connection) bool {
+			found++
+			return true
+		},
+	)
+	if found != 1 {
+		t.Fatalf("Expected 1 connection matching the address, got: %d", found)
+	}
+
+	client.connection.Close()
+
+	// Wait for the server to remove the connection
+	deadline := time.Now().Add(1 * time.Second)
+	for server.ConnectionsNum() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Connection wasn't removed after disconnection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if server.Connection(conn.ID()) != nil {
+		t.Fatal("Expected the connection to be removed")
+	}
+}
@@ -10,27 +10,22 @@ import (
 	webwire "github.com/qbeon/webwire-go"
 )
 
-// TestEndpointMetadata tests server endpoint metadata
-func TestEndpointMetadata(t *testing.T) {
-	expectedVersion := "1.4"
-
-	// Initialize webwire server
-	server := setupServer(t, &serverImpl{}, webwire.ServerOptions{})
-
+// requestMetadata requests the endpoint metadata announcing
+// the given client protocol version if any
+func requestMetadata(t *testing.T, addr string, clientVersion string) string {
 	// Initialize HTTP client
 	var httpClient = &http.Client{
 		Timeout: time.Second * 10,
 	}
 
 	// Request metadata
-	request, err := http.NewRequest(
-		"WEBWIRE",
-		"http://"+server.Addr().String()+"/",
-		nil,
-	)
+	request, err := http.NewRequest("WEBWIRE", "http://"+addr+"/", nil)
 	if err != nil {
 		t.Fatalf("Couldn't create HTTP request: %s", err)
 	}
+	if clientVersion != "" {
+		request.Header.Set(webwire.ProtocolVersionHeader, clientVersion)
+	}
 	response, err := httpClient.Do(request)
 	if err != nil {
 		t.Fatalf("HTTP request failed: %s", err)
@@ -54,9 +49,29 @@ func TestEndpointMetadata(t *testing.T) {
 			err,
 		)
 	}
+	return metadata.ProtocolVersion
+}
+
+// TestEndpointMetadata tests server endpoint metadata
+func TestEndpointMetadata(t *testing.T) {
+	// Initialize webwire server
+	server := setupServer(t, &serverImpl{}, webwire.ServerOptions{})
+
+	// Expect the current version to be announced to current clients
+	if version := requestMetadata(
+		t,
+		server.Addr().String(),
+		"1.5",
+	); version != "1.5" {
+		t.Errorf("Unexpected protocol version: %s", version)
+	}
 
-	// Verify metadata
-	if metadata.ProtocolVersion != expectedVersion {
-		t.Fatalf("Unexpected protocol version: %s", metadata.ProtocolVersion)
+	// Expect the legacy version to be announced to legacy clients
+	if version := requestMetadata(
+		t,
+		server.Addr().String(),
+		"",
+	); version != "1.4" {
+		t.Errorf("Unexpected legacy protocol version: %s", version)
 	}
 }
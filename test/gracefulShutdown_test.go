@@ -153,7 +153,7 @@ func TestGracefulShutdown(t *testing.T) {
 
 		// (SRV SHUTDWN)
 		serverShuttingDown.Progress(1)
-		server.Shutdown()
+		server.Shutdown(context.Background())
 		serverShutDown.Progress(1)
 	}()
 
@@ -0,0 +1,198 @@
+package test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tmdwg "github.com/qbeon/tmdwg-go"
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// TestHandlerContextCancel tests whether the context of a running request
+// handler is canceled when the client disconnects
+// and whether it carries the connection and the request identifier
+func TestHandlerContextCancel(t *testing.T) {
+	handlerStarted := tmdwg.NewTimedWaitGroup(1, 1*time.Second)
+	handlerCanceled := tmdwg.NewTimedWaitGroup(1, 1*time.Second)
+
+	// Initialize webwire server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onRequest: func(
+				ctx context.Context,
+				conn wwr.Connection,
+				_ wwr.Message,
+			) (wwr.Payload, error) {
+				if ctxConn, ok := wwr.ConnectionFromContext(ctx); !ok ||
+					ctxConn != conn {
+					t.Errorf("Expected the connection to be attached to the context")
+				}
+				if _, ok := wwr.RequestIdentifierFromContext(ctx); !ok {
+					t.Errorf("Expected the request identifier " +
+						"to be attached to the context",
+					)
+				}
+
+				handlerStarted.Progress(1)
+
+				select {
+				case <-ctx.Done():
+					handlerCanceled.Progress(1)
+				case <-time.After(2 * time.Second):
+					t.Errorf("Handler context wasn't canceled")
+				}
+				return nil, nil
+			},
+		},
+		wwr.ServerOptions{},
+	)
+
+	// Initialize client
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+			Autoconnect:           wwr.Disabled,
+		},
+		callbackPoweredClientHooks{},
+	)
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+
+	// Send request and abandon it as soon as the handler is running
+	requestCtx, cancelRequest := context.WithCancel(context.Background())
+	requestAbandoned := make(chan struct{})
+	go func() {
+		client.connection.Request(requestCtx, "test", nil)
+		close(requestAbandoned)
+	}()
+
+	if err := handlerStarted.Wait(); err != nil {
+		t.Fatal("Handler wasn't executed")
+	}
+	cancelRequest()
+	<-requestAbandoned
+
+	// Disconnect the client while the handler is still running
+	client.connection.Close()
+
+	if err := handlerCanceled.Wait(); err != nil {
+		t.Fatal("Handler context wasn't canceled after disconnection")
+	}
+}
+
+// TestHandlerContextCancelQueued tests whether requests canceled by the client
+// while queued behind a full handler scheduler are dropped
+// without ever being handled
+func TestHandlerContextCancelQueued(t *testing.T) {
+	handlerStarted := tmdwg.NewTimedWaitGroup(1, 1*time.Second)
+	releaseHandler := make(chan struct{})
+	var canceledHandled int32
+
+	// Initialize webwire server allowing only a single handler at a time
+	server := setupServer(
+		t,
+		&serverImpl{
+			onRequest: func(
+				_ context.Context,
+				_ wwr.Connection,
+				msg wwr.Message,
+			) (wwr.Payload, error) {
+				if msg.Name() == "canceled" {
+					atomic.StoreInt32(&canceledHandled, 1)
+					return nil, nil
+				}
+				handlerStarted.Progress(1)
+				<-releaseHandler
+				return nil, nil
+			},
+		},
+		wwr.ServerOptions{
+			MaxConcurrentHandlers: 1,
+		},
+	)
+
+	// Initialize client
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+			Autoconnect:           wwr.Disabled,
+		},
+		callbackPoweredClientHooks{},
+	)
+	defer client.connection.Close()
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+
+	// Occupy the only handler slot
+	blockingDone := make(chan error, 1)
+	go func() {
+		_, err := client.connection.Request(
+			context.Background(),
+			"blocking",
+			nil,
+		)
+		blockingDone <- err
+	}()
+	if err := handlerStarted.Wait(); err != nil {
+		t.Fatal("Handler wasn't executed")
+	}
+
+	// Send a request and wait for it to be queued
+	requestCtx, cancelRequest := context.WithCancel(context.Background())
+	requestAbandoned := make(chan struct{})
+	go func() {
+		client.connection.Request(requestCtx, "canceled", nil)
+		close(requestAbandoned)
+	}()
+	deadline := time.Now().Add(1 * time.Second)
+	for server.HandlerStats().Queued < 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the request to be queued")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Cancel the queued request and expect it to leave the queue
+	// before the handler slot is freed
+	cancelRequest()
+	<-requestAbandoned
+	deadline = time.Now().Add(1 * time.Second)
+	for server.HandlerStats().Queued > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the canceled request to leave the queue")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(releaseHandler)
+
+	if err := <-blockingDone; err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+
+	// Wait for the scheduler to drain
+	deadline = time.Now().Add(1 * time.Second)
+	for {
+		stats := server.HandlerStats()
+		if stats.Queued < 1 && stats.Running < 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the handler scheduler to drain")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if atomic.LoadInt32(&canceledHandled) != 0 {
+		t.Fatal("Expected the canceled request not to be handled")
+	}
+}
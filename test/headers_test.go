@@ -0,0 +1,138 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	tmdwg "github.com/qbeon/tmdwg-go"
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// TestHeaders tests transmitting request and signal headers
+// and propagating the trace context into the handler contexts
+func TestHeaders(t *testing.T) {
+	traceContext := wwr.TraceContext{
+		TraceID: [16]byte{0x4b, 0xf9, 0x2f},
+		SpanID:  [8]byte{0x00, 0xf0, 0x67},
+		Flags:   1,
+	}
+	expectedHeaders := map[string]string{
+		"x-tenant":            "tenant-1",
+		wwr.HeaderTraceparent: traceContext.Traceparent(),
+	}
+	signalHandled := tmdwg.NewTimedWaitGroup(1, 1*time.Second)
+
+	verify := func(ctx context.Context, msg wwr.Message) {
+		if !reflect.DeepEqual(msg.Headers(), expectedHeaders) {
+			t.Errorf("Unexpected headers: %v", msg.Headers())
+		}
+		actual, ok := wwr.TraceContextFromContext(ctx)
+		if !ok {
+			t.Error("Missing trace context in the handler context")
+		} else if actual != traceContext {
+			t.Errorf("Unexpected trace context: %v", actual)
+		}
+	}
+
+	// Initialize webwire server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onRequest: func(
+				ctx context.Context,
+				_ wwr.Connection,
+				msg wwr.Message,
+			) (wwr.Payload, error) {
+				verify(ctx, msg)
+				return nil, nil
+			},
+			onSignal: func(
+				ctx context.Context,
+				_ wwr.Connection,
+				msg wwr.Message,
+			) {
+				verify(ctx, msg)
+				signalHandled.Progress(1)
+			},
+		},
+		wwr.ServerOptions{
+			Propagator: wwr.TraceContextPropagator{},
+		},
+	)
+
+	// Initialize client
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+			Propagator:            wwr.TraceContextPropagator{},
+		},
+		callbackPoweredClientHooks{},
+	)
+	defer client.connection.Close()
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+
+	ctx := wwr.ContextWithTraceContext(context.Background(), traceContext)
+	headers := map[string]string{"x-tenant": "tenant-1"}
+	payload := wwr.NewPayload(wwr.EncodingUtf16, []byte{'h', 0, 'i', 0})
+
+	if _, err := client.connection.RequestWithHeaders(
+		ctx,
+		"req",
+		headers,
+		payload,
+	); err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+
+	if err := client.connection.SignalWithHeaders(
+		ctx,
+		"sig",
+		headers,
+		payload,
+	); err != nil {
+		t.Fatalf("Couldn't send signal: %s", err)
+	}
+	if err := signalHandled.Wait(); err != nil {
+		t.Fatal("Signal wasn't handled")
+	}
+
+	// Expect the headers of the caller not to be modified
+	if len(headers) != 1 {
+		t.Errorf("Expected the given headers not to be modified: %v", headers)
+	}
+}
+
+// TestHeadersInvalid tests whether sending invalid headers is rejected
+func TestHeadersInvalid(t *testing.T) {
+	server := setupServer(t, &serverImpl{}, wwr.ServerOptions{})
+
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+		},
+		callbackPoweredClientHooks{},
+	)
+	defer client.connection.Close()
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+
+	_, err := client.connection.RequestWithHeaders(
+		context.Background(),
+		"req",
+		map[string]string{"invalid key": "value"},
+		nil,
+	)
+	if _, isProtoErr := err.(wwr.ProtocolErr); !isProtoErr {
+		t.Fatalf("Expected a protocol error, got: %v", err)
+	}
+}
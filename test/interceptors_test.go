@@ -0,0 +1,176 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tmdwg "github.com/qbeon/tmdwg-go"
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// TestRequestInterceptors tests the request interceptor chain
+// verifying the order of execution, short-circuiting
+// and the rewriting of reply payloads
+func TestRequestInterceptors(t *testing.T) {
+	var order []string
+
+	// Initialize webwire server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onRequest: func(
+				_ context.Context,
+				_ wwr.Connection,
+				_ wwr.Message,
+			) (wwr.Payload, error) {
+				order = append(order, "handler")
+				return wwr.NewPayload(wwr.EncodingUtf8, []byte("reply")), nil
+			},
+		},
+		wwr.ServerOptions{
+			RequestInterceptors: []wwr.RequestInterceptor{
+				// Reject unauthorized requests
+				func(
+					ctx context.Context,
+					conn wwr.Connection,
+					msg wwr.Message,
+					next wwr.RequestHandler,
+				) (wwr.Payload, error) {
+					order = append(order, "auth")
+					if msg.Name() == "forbidden" {
+						return nil, wwr.ReqErr{
+							Code:    "FORBIDDEN",
+							Message: "not allowed",
+						}
+					}
+					return next(ctx, conn, msg)
+				},
+				// Rewrite the reply
+				func(
+					ctx context.Context,
+					conn wwr.Connection,
+					msg wwr.Message,
+					next wwr.RequestHandler,
+				) (wwr.Payload, error) {
+					order = append(order, "rewrite")
+					reply, err := next(ctx, conn, msg)
+					if err != nil {
+						return nil, err
+					}
+					return wwr.NewPayload(
+						wwr.EncodingUtf8,
+						append([]byte("rewritten "), reply.Data()...),
+					), nil
+				},
+			},
+		},
+	)
+
+	// Initialize client
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+		},
+		callbackPoweredClientHooks{},
+	)
+	defer client.connection.Close()
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+
+	// Send an allowed request and expect a rewritten reply
+	reply, err := client.connection.Request(context.Background(), "allowed", nil)
+	if err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	if string(reply.Data()) != "rewritten reply" {
+		t.Errorf("Unexpected reply: '%s'", string(reply.Data()))
+	}
+	if len(order) != 3 ||
+		order[0] != "auth" ||
+		order[1] != "rewrite" ||
+		order[2] != "handler" {
+		t.Errorf("Unexpected order of execution: %v", order)
+	}
+
+	// Send a forbidden request and expect it to be short-circuited
+	order = nil
+	_, err = client.connection.Request(context.Background(), "forbidden", nil)
+	reqErr, isReqErr := err.(wwr.ReqErr)
+	if !isReqErr {
+		t.Fatalf("Expected a request error, got: %v", err)
+	}
+	if reqErr.Code != "FORBIDDEN" {
+		t.Errorf("Unexpected error code: %s", reqErr.Code)
+	}
+	if len(order) != 1 || order[0] != "auth" {
+		t.Errorf("Expected the chain to be short-circuited, got: %v", order)
+	}
+}
+
+// TestSignalInterceptors tests the signal interceptor chain
+func TestSignalInterceptors(t *testing.T) {
+	signalHandled := tmdwg.NewTimedWaitGroup(1, 1*time.Second)
+
+	// Initialize webwire server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onSignal: func(
+				_ context.Context,
+				_ wwr.Connection,
+				msg wwr.Message,
+			) {
+				if msg.Name() == "dropped" {
+					t.Errorf("Expected signal to be dropped by the interceptor")
+				}
+				signalHandled.Progress(1)
+			},
+		},
+		wwr.ServerOptions{
+			SignalInterceptors: []wwr.SignalInterceptor{
+				func(
+					ctx context.Context,
+					conn wwr.Connection,
+					msg wwr.Message,
+					next wwr.SignalHandler,
+				) {
+					if msg.Name() == "dropped" {
+						return
+					}
+					next(ctx, conn, msg)
+				},
+			},
+		},
+	)
+
+	// Initialize client
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+		},
+		callbackPoweredClientHooks{},
+	)
+	defer client.connection.Close()
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+
+	payload := wwr.NewPayload(wwr.EncodingBinary, []byte("test"))
+	if err := client.connection.Signal("dropped", payload); err != nil {
+		t.Fatalf("Couldn't send signal: %s", err)
+	}
+	if err := client.connection.Signal("passed", payload); err != nil {
+		t.Fatalf("Couldn't send signal: %s", err)
+	}
+
+	if err := signalHandled.Wait(); err != nil {
+		t.Fatal("Signal wasn't handled")
+	}
+}
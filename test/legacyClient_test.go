@@ -0,0 +1,82 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	wwr "github.com/qbeon/webwire-go"
+	msg "github.com/qbeon/webwire-go/message"
+)
+
+// TestLegacyClient tests whether clients running the legacy protocol
+// version are served without the messages they don't support
+func TestLegacyClient(t *testing.T) {
+	connected := make(chan wwr.Connection, 1)
+
+	// Initialize webwire server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onClientConnected: func(conn wwr.Connection) {
+				connected <- conn
+			},
+		},
+		wwr.ServerOptions{
+			SessionManager: newInMemSessManager(),
+		},
+	)
+
+	// Connect without announcing the protocol version
+	// like a legacy client does
+	sock, _, err := websocket.DefaultDialer.Dial(
+		"ws://"+server.Addr().String()+"/",
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+	defer sock.Close()
+	sock.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	var conn wwr.Connection
+	select {
+	case conn = <-connected:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Client wasn't connected")
+	}
+
+	// Expect requests to legacy clients to be refused
+	_, err = conn.Request(
+		context.Background(),
+		"test",
+		wwr.NewPayload(wwr.EncodingBinary, []byte("test")),
+	)
+	if _, isProtocolErr := err.(wwr.ProtocolErr); !isProtocolErr {
+		t.Errorf("Expected a protocol error, got: %v", err)
+	}
+
+	// Create a session and close it
+	if err := conn.CreateSession(nil); err != nil {
+		t.Fatalf("Couldn't create session: %s", err)
+	}
+	if _, message, err := sock.ReadMessage(); err != nil {
+		t.Fatalf("Couldn't read message: %s", err)
+	} else if message[0] != msg.MsgSessionCreated {
+		t.Fatalf("Unexpected message type: %d", message[0])
+	}
+
+	if closed := server.CloseSession(conn.SessionKey()); closed != 1 {
+		t.Fatalf("Expected the session to be closed on 1 connection, got: %d", closed)
+	}
+
+	// Expect the session closure notification not to carry a reason
+	_, message, err := sock.ReadMessage()
+	if err != nil {
+		t.Fatalf("Couldn't read message: %s", err)
+	}
+	if len(message) != 1 || message[0] != msg.MsgSessionClosed {
+		t.Fatalf("Unexpected session closure notification: %v", message)
+	}
+}
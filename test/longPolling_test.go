@@ -0,0 +1,306 @@
+package test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	tmdwg "github.com/qbeon/tmdwg-go"
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// rejectWebsocketUpgrades simulates a proxy blocking WebSocket upgrades
+func rejectWebsocketUpgrades(resp http.ResponseWriter, req *http.Request) bool {
+	if strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+		http.Error(resp, "Upgrade blocked", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// TestLongPolling tests the client falling back to long polling
+// when the WebSocket upgrade fails
+func TestLongPolling(t *testing.T) {
+	connected := make(chan wwr.Connection, 1)
+	disconnected := make(chan struct{}, 1)
+	signals := make(chan string, 1)
+
+	server := setupServer(
+		t,
+		&serverImpl{
+			beforeUpgrade: rejectWebsocketUpgrades,
+			onClientConnected: func(conn wwr.Connection) {
+				connected <- conn
+			},
+			onClientDisconnected: func(_ wwr.Connection) {
+				disconnected <- struct{}{}
+			},
+			onRequest: func(
+				_ context.Context,
+				conn wwr.Connection,
+				msg wwr.Message,
+			) (wwr.Payload, error) {
+				if err := conn.CreateSession(nil); err != nil {
+					return nil, err
+				}
+				return msg.Payload(), nil
+			},
+		},
+		wwr.ServerOptions{
+			LongPolling:       wwr.Enabled,
+			Heartbeat:         wwr.Enabled,
+			HeartbeatInterval: 1 * time.Second,
+			HeartbeatTimeout:  2 * time.Second,
+		},
+	)
+
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+			Autoconnect:           wwr.Disabled,
+		},
+		callbackPoweredClientHooks{
+			OnSignal: func(payload wwr.Payload) {
+				signals <- string(payload.Data())
+			},
+		},
+	)
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+	conn := <-connected
+
+	// Send a request creating a session
+	reply, err := client.connection.Request(
+		context.Background(),
+		"",
+		wwr.NewPayload(wwr.EncodingUtf8, []byte("ping")),
+	)
+	if err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	if string(reply.Data()) != "ping" {
+		t.Errorf("Unexpected reply: %q", string(reply.Data()))
+	}
+	if client.connection.Session() == nil {
+		t.Error("Expected a session to be created")
+	}
+
+	// Outlive the heartbeat timeout
+	time.Sleep(3 * time.Second)
+
+	// Send a signal to the client
+	if err := conn.Signal("", wwr.NewPayload(
+		wwr.EncodingUtf8,
+		[]byte("pong"),
+	)); err != nil {
+		t.Fatalf("Couldn't send signal: %s", err)
+	}
+	select {
+	case signal := <-signals:
+		if signal != "pong" {
+			t.Errorf("Unexpected signal: %q", signal)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Signal wasn't received in time")
+	}
+
+	// Close the client
+	client.connection.Close()
+	select {
+	case <-disconnected:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Server didn't notice the disconnection in time")
+	}
+}
+
+// TestLongPollingDisabled tests the fallback failing
+// if long polling is disabled on the server
+func TestLongPollingDisabled(t *testing.T) {
+	server := setupServer(
+		t,
+		&serverImpl{
+			beforeUpgrade: rejectWebsocketUpgrades,
+		},
+		wwr.ServerOptions{},
+	)
+
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			Autoconnect: wwr.Disabled,
+		},
+		callbackPoweredClientHooks{},
+	)
+
+	err := client.connection.Connect()
+	if _, isDisconnErr := err.(wwr.DisconnectedErr); !isDisconnErr {
+		t.Fatalf("Expected a disconnected error, got: %v", err)
+	}
+}
+
+// TestLongPollingShutdown tests whether long polling clients receive
+// the replies of running handlers and the going away notice
+// while the server is shutting down
+func TestLongPollingShutdown(t *testing.T) {
+	handlerStarted := tmdwg.NewTimedWaitGroup(1, 1*time.Second)
+	reconnected := tmdwg.NewTimedWaitGroup(1, 2*time.Second)
+
+	// Initialize the alternative webwire server
+	alternative := setupServer(
+		t,
+		&serverImpl{
+			beforeUpgrade: rejectWebsocketUpgrades,
+			onClientConnected: func(wwr.Connection) {
+				reconnected.Progress(1)
+			},
+		},
+		wwr.ServerOptions{
+			LongPolling: wwr.Enabled,
+		},
+	)
+	defer alternative.Shutdown(context.Background())
+
+	// Initialize the webwire server going away
+	server := setupServer(
+		t,
+		&serverImpl{
+			beforeUpgrade: rejectWebsocketUpgrades,
+			onRequest: func(
+				_ context.Context,
+				_ wwr.Connection,
+				msg wwr.Message,
+			) (wwr.Payload, error) {
+				handlerStarted.Progress(1)
+				time.Sleep(200 * time.Millisecond)
+				return msg.Payload(), nil
+			},
+		},
+		wwr.ServerOptions{
+			LongPolling:      wwr.Enabled,
+			ReconnectAddress: alternative.Addr().String(),
+			ReconnectDelay:   10 * time.Millisecond,
+		},
+	)
+
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+			ReconnectionInterval:  10 * time.Millisecond,
+			PollingFallback:       wwr.Enabled,
+		},
+		callbackPoweredClientHooks{},
+	)
+	defer client.connection.Close()
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+
+	// Send a request and shut the server down while it's being handled
+	replied := make(chan error, 1)
+	go func() {
+		reply, err := client.connection.Request(
+			context.Background(),
+			"",
+			wwr.NewPayload(wwr.EncodingUtf8, []byte("ping")),
+		)
+		if err == nil && string(reply.Data()) != "ping" {
+			t.Errorf("Unexpected reply: %q", string(reply.Data()))
+		}
+		replied <- err
+	}()
+	if err := handlerStarted.Wait(); err != nil {
+		t.Fatal("Request handler wasn't executed")
+	}
+
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %s", err)
+	}
+
+	// Expect the reply of the running handler to be received
+	if err := <-replied; err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+
+	// Expect the client to reconnect to the alternative server
+	if err := reconnected.Wait(); err != nil {
+		t.Fatal("Client didn't reconnect to the alternative server")
+	}
+}
+
+// TestLongPollingResend tests whether frames of a lost long polling
+// response are sent again until a subsequent poll acknowledges them
+func TestLongPollingResend(t *testing.T) {
+	connected := make(chan wwr.Connection, 1)
+
+	server := setupServer(
+		t,
+		&serverImpl{
+			onClientConnected: func(conn wwr.Connection) {
+				connected <- conn
+			},
+		},
+		wwr.ServerOptions{
+			LongPolling: wwr.Enabled,
+		},
+	)
+	baseURL := "http://" + server.Addr().String() + "/?wwr-poll="
+
+	// Open a long polling connection
+	resp, err := http.Post(baseURL, "text/plain", nil)
+	if err != nil {
+		t.Fatalf("Couldn't open long polling connection: %s", err)
+	}
+	token, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	conn := <-connected
+	defer conn.Close()
+
+	signal := func(data string) {
+		if err := conn.Signal("", wwr.NewPayload(
+			wwr.EncodingUtf8,
+			[]byte(data),
+		)); err != nil {
+			t.Fatalf("Couldn't send signal: %s", err)
+		}
+	}
+	poll := func(ack string) (string, string) {
+		resp, err := http.Get(baseURL + string(token) + "&wwr-ack=" + ack)
+		if err != nil {
+			t.Fatalf("Poll failed: %s", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Unexpected poll response: %d", resp.StatusCode)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp.Header.Get("Webwire-Poll-Sequence"), string(body)
+	}
+
+	// Expect the frame to be sent again if it's not acknowledged
+	signal("first")
+	seq, body := poll("0")
+	if seq != "1" || !strings.Contains(body, "first") {
+		t.Fatalf("Unexpected poll response: %s %q", seq, body)
+	}
+	seq, body = poll("0")
+	if seq != "1" || !strings.Contains(body, "first") {
+		t.Fatalf("Expected the frame to be sent again, got: %s %q", seq, body)
+	}
+
+	// Expect acknowledged frames to be discarded
+	signal("second")
+	seq, body = poll("1")
+	if seq != "2" || strings.Contains(body, "first") ||
+		!strings.Contains(body, "second") {
+		t.Fatalf("Unexpected poll response: %s %q", seq, body)
+	}
+}
@@ -0,0 +1,176 @@
+package test
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	tmdwg "github.com/qbeon/tmdwg-go"
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// TestMessageOrderPerConnection tests whether the signals of a connection
+// are processed in the order of their arrival
+// when the concurrency of handlers is limited
+func TestMessageOrderPerConnection(t *testing.T) {
+	signalsNum := 50
+	signalsHandled := tmdwg.NewTimedWaitGroup(signalsNum, 2*time.Second)
+	var lock sync.Mutex
+	var order []int
+
+	// Initialize webwire server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onSignal: func(
+				_ context.Context,
+				_ wwr.Connection,
+				msg wwr.Message,
+			) {
+				index, err := strconv.Atoi(string(msg.Payload().Data()))
+				if err != nil {
+					t.Errorf("Unexpected payload: %s", err)
+				}
+
+				// Delay early signals to provoke reordering
+				if index%2 == 0 {
+					time.Sleep(2 * time.Millisecond)
+				}
+
+				lock.Lock()
+				order = append(order, index)
+				lock.Unlock()
+				signalsHandled.Progress(1)
+			},
+		},
+		wwr.ServerOptions{
+			MessageOrder:          wwr.OrderedPerConnection,
+			MaxConcurrentHandlers: 2,
+		},
+	)
+
+	// Initialize client
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+		},
+		callbackPoweredClientHooks{},
+	)
+	defer client.connection.Close()
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+
+	for i := 0; i < signalsNum; i++ {
+		if err := client.connection.Signal("", wwr.NewPayload(
+			wwr.EncodingUtf8,
+			[]byte(strconv.Itoa(i)),
+		)); err != nil {
+			t.Fatalf("Couldn't send signal: %s", err)
+		}
+	}
+
+	if err := signalsHandled.Wait(); err != nil {
+		t.Fatal("Not all signals were handled")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	for i, index := range order {
+		if i != index {
+			t.Fatalf("Signals processed out of order: %v", order)
+		}
+	}
+}
+
+// TestMessageOrderPerName tests whether messages of different names
+// are processed concurrently while messages of the same name are ordered
+func TestMessageOrderPerName(t *testing.T) {
+	fastHandled := tmdwg.NewTimedWaitGroup(1, 1*time.Second)
+	slowReleased := make(chan struct{})
+	slowHandled := tmdwg.NewTimedWaitGroup(2, 2*time.Second)
+	var lock sync.Mutex
+	var slowOrder []string
+
+	// Initialize webwire server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onSignal: func(
+				_ context.Context,
+				_ wwr.Connection,
+				msg wwr.Message,
+			) {
+				switch msg.Name() {
+				case "slow":
+					data := string(msg.Payload().Data())
+					if data == "first" {
+						<-slowReleased
+					}
+					lock.Lock()
+					slowOrder = append(slowOrder, data)
+					lock.Unlock()
+					slowHandled.Progress(1)
+				case "fast":
+					fastHandled.Progress(1)
+				}
+			},
+		},
+		wwr.ServerOptions{
+			MessageOrder: wwr.OrderedPerName,
+		},
+	)
+
+	// Initialize client
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+		},
+		callbackPoweredClientHooks{},
+	)
+	defer client.connection.Close()
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+
+	for _, signal := range []struct {
+		name string
+		data string
+	}{
+		{"slow", "first"},
+		{"slow", "second"},
+		{"fast", "fast"},
+	} {
+		if err := client.connection.Signal(signal.name, wwr.NewPayload(
+			wwr.EncodingUtf8,
+			[]byte(signal.data),
+		)); err != nil {
+			t.Fatalf("Couldn't send signal: %s", err)
+		}
+	}
+
+	// Expect the fast signal not to be blocked by the slow ones
+	if err := fastHandled.Wait(); err != nil {
+		t.Fatal("Signal was blocked by signals of another name")
+	}
+	close(slowReleased)
+
+	if err := slowHandled.Wait(); err != nil {
+		t.Fatal("Not all signals were handled")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(slowOrder) != 2 ||
+		slowOrder[0] != "first" ||
+		slowOrder[1] != "second" {
+		t.Fatalf("Signals processed out of order: %v", slowOrder)
+	}
+}
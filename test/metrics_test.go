@@ -0,0 +1,170 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// countingMetrics counts the observations of the server metrics
+type countingMetrics struct {
+	wwr.NopMetrics
+	lock     sync.Mutex
+	counters map[string]int
+	failures map[string]int
+}
+
+func (cm *countingMetrics) count(key string) {
+	cm.lock.Lock()
+	cm.counters[key]++
+	cm.lock.Unlock()
+}
+
+func (cm *countingMetrics) get(key string) int {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+	return cm.counters[key]
+}
+
+func (cm *countingMetrics) ConnectionOpened()                    { cm.count("opened") }
+func (cm *countingMetrics) ConnectionClosed(time.Duration)       { cm.count("closed") }
+func (cm *countingMetrics) RequestHandled(string, time.Duration) { cm.count("handled") }
+func (cm *countingMetrics) RequestFulfilled()                    { cm.count("fulfilled") }
+func (cm *countingMetrics) SessionCreated()                      { cm.count("created") }
+func (cm *countingMetrics) MessageReceived(byte, int)            { cm.count("received") }
+func (cm *countingMetrics) SignalHandled(string, time.Duration)  { cm.count("signal") }
+func (cm *countingMetrics) RequestFailed(code string) {
+	cm.lock.Lock()
+	cm.failures[code]++
+	cm.lock.Unlock()
+}
+
+// countingClientMetrics counts the observations of the client metrics
+type countingClientMetrics struct {
+	wwrclt.NopMetrics
+	lock      sync.Mutex
+	connected int
+	completed map[string]int
+}
+
+func (cm *countingClientMetrics) Connected() {
+	cm.lock.Lock()
+	cm.connected++
+	cm.lock.Unlock()
+}
+
+func (cm *countingClientMetrics) RequestCompleted(
+	name string,
+	_ time.Duration,
+	_ error,
+) {
+	cm.lock.Lock()
+	cm.completed[name]++
+	cm.lock.Unlock()
+}
+
+// TestMetrics tests whether the server and the client
+// report their metrics to the configured observers
+func TestMetrics(t *testing.T) {
+	metrics := &countingMetrics{
+		counters: make(map[string]int),
+		failures: make(map[string]int),
+	}
+	clientMetrics := &countingClientMetrics{
+		completed: make(map[string]int),
+	}
+
+	// Initialize webwire server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onRequest: func(
+				_ context.Context,
+				conn wwr.Connection,
+				msg wwr.Message,
+			) (wwr.Payload, error) {
+				if msg.Name() == "fail" {
+					return nil, wwr.ReqErr{Code: "FAILED", Message: "failed"}
+				}
+				return nil, conn.CreateSession(nil)
+			},
+		},
+		wwr.ServerOptions{
+			Metrics: metrics,
+		},
+	)
+
+	// Initialize client
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+			Autoconnect:           wwr.Disabled,
+			Metrics:               clientMetrics,
+		},
+		callbackPoweredClientHooks{},
+	)
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+	if _, err := client.connection.Request(
+		context.Background(),
+		"login",
+		nil,
+	); err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	if _, err := client.connection.Request(
+		context.Background(),
+		"fail",
+		nil,
+	); err == nil {
+		t.Fatal("Expected request to fail")
+	}
+	client.connection.Close()
+
+	// Wait for the server to observe the disconnection
+	deadline := time.Now().Add(1 * time.Second)
+	for metrics.get("closed") != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("Connection closure wasn't observed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	expected := map[string]int{
+		"opened":    1,
+		"received":  2,
+		"handled":   2,
+		"fulfilled": 1,
+		"created":   1,
+	}
+	for key, num := range expected {
+		if actual := metrics.get(key); actual != num {
+			t.Errorf("Expected %d %s observations, got: %d", num, key, actual)
+		}
+	}
+	metrics.lock.Lock()
+	if metrics.failures["FAILED"] != 1 {
+		t.Errorf("Expected 1 failure observation, got: %v", metrics.failures)
+	}
+	metrics.lock.Unlock()
+
+	clientMetrics.lock.Lock()
+	defer clientMetrics.lock.Unlock()
+	if clientMetrics.connected != 1 {
+		t.Errorf("Expected 1 client connection, got: %d", clientMetrics.connected)
+	}
+	if clientMetrics.completed["login"] != 1 ||
+		clientMetrics.completed["fail"] != 1 {
+		t.Errorf(
+			"Unexpected client request observations: %v",
+			clientMetrics.completed,
+		)
+	}
+}
@@ -0,0 +1,73 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	wwr "github.com/qbeon/webwire-go"
+)
+
+// TestOriginCheck tests rejecting requests from disallowed origins
+// and responding with CORS headers consistent with the allowed origins
+func TestOriginCheck(t *testing.T) {
+	server := setupServer(
+		t,
+		&serverImpl{},
+		wwr.ServerOptions{
+			AllowedOrigins: []string{"https://*.example.com"},
+		},
+	)
+
+	httpClient := &http.Client{Timeout: 2 * time.Second}
+	do := func(method, origin string) *http.Response {
+		req, err := http.NewRequest(
+			method,
+			"http://"+server.Addr().String()+"/",
+			nil,
+		)
+		if err != nil {
+			t.Fatalf("Couldn't create HTTP request: %s", err)
+		}
+		req.Header.Set("Origin", origin)
+		if method == "GET" {
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", "websocket")
+			req.Header.Set("Sec-WebSocket-Version", "13")
+			req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			t.Fatalf("HTTP request failed: %s", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	// Disallowed origins are rejected for all methods
+	for _, method := range []string{"GET", "OPTIONS", "WEBWIRE"} {
+		resp := do(method, "https://evil.com")
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected %s to be forbidden, got: %s", method, resp.Status)
+		}
+	}
+
+	// Allowed origins are echoed in the CORS headers
+	// overriding the wildcard set by OnOptions
+	for _, method := range []string{"OPTIONS", "WEBWIRE"} {
+		resp := do(method, "https://app.example.com")
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected %s to succeed, got: %s", method, resp.Status)
+		}
+		origin := resp.Header.Get("Access-Control-Allow-Origin")
+		if origin != "https://app.example.com" {
+			t.Errorf("Unexpected %s CORS origin: %q", method, origin)
+		}
+	}
+
+	// Allowed origins are upgraded
+	resp := do("GET", "https://app.example.com")
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("Expected the upgrade to succeed, got: %s", resp.Status)
+	}
+}
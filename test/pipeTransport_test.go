@@ -0,0 +1,125 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// TestPipeTransport tests a client and a server
+// communicating through an in-memory pipe transport
+func TestPipeTransport(t *testing.T) {
+	connected := make(chan wwr.Connection, 1)
+	disconnected := make(chan struct{}, 1)
+	signals := make(chan string, 1)
+
+	_, transport := setupPipeServer(
+		t,
+		&serverImpl{
+			onClientConnected: func(conn wwr.Connection) {
+				connected <- conn
+			},
+			onClientDisconnected: func(_ wwr.Connection) {
+				disconnected <- struct{}{}
+			},
+			onRequest: func(
+				_ context.Context,
+				conn wwr.Connection,
+				msg wwr.Message,
+			) (wwr.Payload, error) {
+				if err := conn.CreateSession(nil); err != nil {
+					return nil, err
+				}
+				return msg.Payload(), nil
+			},
+		},
+		wwr.ServerOptions{
+			Heartbeat: wwr.Enabled,
+		},
+	)
+
+	client := newCallbackPoweredClient(
+		"pipe",
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+			Autoconnect:           wwr.Disabled,
+			SocketFactory:         transport.NewSocket,
+		},
+		callbackPoweredClientHooks{
+			OnSignal: func(payload wwr.Payload) {
+				signals <- string(payload.Data())
+			},
+		},
+	)
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+	conn := <-connected
+
+	// Send a request creating a session
+	reply, err := client.connection.Request(
+		context.Background(),
+		"",
+		wwr.NewPayload(wwr.EncodingUtf8, []byte("ping")),
+	)
+	if err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	if string(reply.Data()) != "ping" {
+		t.Errorf("Unexpected reply: %q", string(reply.Data()))
+	}
+	if client.connection.Session() == nil {
+		t.Error("Expected a session to be created")
+	}
+
+	// Send a signal to the client
+	if err := conn.Signal("", wwr.NewPayload(
+		wwr.EncodingUtf8,
+		[]byte("pong"),
+	)); err != nil {
+		t.Fatalf("Couldn't send signal: %s", err)
+	}
+	select {
+	case signal := <-signals:
+		if signal != "pong" {
+			t.Errorf("Unexpected signal: %q", signal)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Signal wasn't received in time")
+	}
+
+	// Close the client
+	client.connection.Close()
+	select {
+	case <-disconnected:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Server didn't notice the disconnection in time")
+	}
+}
+
+// TestPipeTransportRejected tests connection requests
+// rejected by the server through a pipe transport
+func TestPipeTransportRejected(t *testing.T) {
+	server, transport := setupPipeServer(t, &serverImpl{}, wwr.ServerOptions{})
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Couldn't shut down the server: %s", err)
+	}
+
+	client := newCallbackPoweredClient(
+		"pipe",
+		wwrclt.Options{
+			Autoconnect:   wwr.Disabled,
+			SocketFactory: transport.NewSocket,
+		},
+		callbackPoweredClientHooks{},
+	)
+
+	err := client.connection.Connect()
+	if _, isDisconnErr := err.(wwr.DisconnectedErr); !isDisconnErr {
+		t.Fatalf("Expected a disconnected error, got: %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// testRawTransport tests a client and a server communicating
+// through a raw transport over the given listener
+func testRawTransport(
+	t *testing.T,
+	listener net.Listener,
+	serverAddr string,
+) {
+	connected := make(chan wwr.Connection, 1)
+	signals := make(chan string, 1)
+
+	impl := &serverImpl{
+		onClientConnected: func(conn wwr.Connection) {
+			connected <- conn
+		},
+		onRequest: func(
+			_ context.Context,
+			conn wwr.Connection,
+			msg wwr.Message,
+		) (wwr.Payload, error) {
+			if err := conn.CreateSession(nil); err != nil {
+				return nil, err
+			}
+			return msg.Payload(), nil
+		},
+	}
+	setServerImplDefaults(impl)
+
+	server, err := wwr.NewHeadlessServer(impl, wwr.ServerOptions{
+		SessionManager: newInMemSessManager(),
+		Heartbeat:      wwr.Enabled,
+	})
+	if err != nil {
+		t.Fatalf("Failed setting up server instance: %s", err)
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- server.ServeRaw(listener)
+	}()
+
+	client := newCallbackPoweredClient(
+		serverAddr,
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+			Autoconnect:           wwr.Disabled,
+			SocketFactory:         wwr.NewRawSocket,
+		},
+		callbackPoweredClientHooks{
+			OnSignal: func(payload wwr.Payload) {
+				signals <- string(payload.Data())
+			},
+		},
+	)
+	defer client.connection.Close()
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+	conn := <-connected
+	if conn.Info().UserAgent == "" {
+		t.Error("Expected the client agent string to be set")
+	}
+
+	// Send a request creating a session
+	reply, err := client.connection.Request(
+		context.Background(),
+		"",
+		wwr.NewPayload(wwr.EncodingUtf8, []byte("ping")),
+	)
+	if err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	if string(reply.Data()) != "ping" {
+		t.Errorf("Unexpected reply: %q", string(reply.Data()))
+	}
+	if client.connection.Session() == nil {
+		t.Error("Expected a session to be created")
+	}
+
+	// Send a signal to the client
+	if err := conn.Signal("", wwr.NewPayload(
+		wwr.EncodingUtf8,
+		[]byte("pong"),
+	)); err != nil {
+		t.Fatalf("Couldn't send signal: %s", err)
+	}
+	select {
+	case signal := <-signals:
+		if signal != "pong" {
+			t.Errorf("Unexpected signal: %q", signal)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Signal wasn't received in time")
+	}
+
+	// Shut the server down stopping the listener
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Couldn't shut down the server: %s", err)
+	}
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("Unexpected ServeRaw error: %s", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("ServeRaw didn't return after shutdown")
+	}
+}
+
+// TestRawTransportTCP tests the raw transport over TCP
+func TestRawTransportTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Couldn't listen: %s", err)
+	}
+	testRawTransport(t, listener, listener.Addr().String())
+}
+
+// TestRawTransportUnix tests the raw transport over a Unix domain socket
+func TestRawTransportUnix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webwire")
+	if err != nil {
+		t.Fatalf("Couldn't create temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "webwire.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Couldn't listen: %s", err)
+	}
+	testRawTransport(t, listener, "unix:"+path)
+}
+
+// TestRawTransportAdmission tests whether raw connections are admitted
+// by the BeforeUpgrade hook of the server implementation
+func TestRawTransportAdmission(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Couldn't listen: %s", err)
+	}
+	requests := make(chan *http.Request, 1)
+
+	impl := &serverImpl{
+		beforeUpgrade: func(resp http.ResponseWriter, req *http.Request) bool {
+			requests <- req
+			http.Error(resp, "Not welcome", http.StatusForbidden)
+			return false
+		},
+		onClientConnected: func(_ wwr.Connection) {
+			t.Error("Expected the connection to be rejected")
+		},
+	}
+	setServerImplDefaults(impl)
+
+	server, err := wwr.NewHeadlessServer(impl, wwr.ServerOptions{})
+	if err != nil {
+		t.Fatalf("Failed setting up server instance: %s", err)
+	}
+	go server.ServeRaw(listener)
+	defer server.Shutdown(context.Background())
+
+	client := newCallbackPoweredClient(
+		listener.Addr().String(),
+		wwrclt.Options{
+			Autoconnect:   wwr.Disabled,
+			SocketFactory: wwr.NewRawSocket,
+		},
+		callbackPoweredClientHooks{},
+	)
+	defer client.connection.Close()
+
+	err = client.connection.Connect()
+	if err == nil || !strings.Contains(err.Error(), "Not welcome") {
+		t.Fatalf("Expected the rejection reason, got: %v", err)
+	}
+
+	req := <-requests
+	if req.UserAgent() == "" {
+		t.Error("Expected the client agent string to be set")
+	}
+	if req.RemoteAddr == "" {
+		t.Error("Expected the remote address to be set")
+	}
+}
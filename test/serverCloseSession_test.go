@@ -0,0 +1,184 @@
+package test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tmdwg "github.com/qbeon/tmdwg-go"
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// TestServerCloseSession tests closing a session on all of its connections
+// keeping the connections open
+func TestServerCloseSession(t *testing.T) {
+	sessionClosed := tmdwg.NewTimedWaitGroup(2, 2*time.Second)
+	sessionManager := newInMemSessManager()
+	var hookCalls int32
+
+	// Initialize server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onRequest: func(
+				_ context.Context,
+				conn wwr.Connection,
+				_ wwr.Message,
+			) (wwr.Payload, error) {
+				return nil, conn.CreateSession(nil)
+			},
+		},
+		wwr.ServerOptions{
+			SessionManager: &callbackPoweredSessionManager{
+				SessionCreated: sessionManager.OnSessionCreated,
+				SessionLookup:  sessionManager.OnSessionLookup,
+				SessionClosed: func(sessionKey string) error {
+					atomic.AddInt32(&hookCalls, 1)
+					return sessionManager.OnSessionClosed(sessionKey)
+				},
+			},
+		},
+	)
+
+	newClient := func() *callbackPoweredClient {
+		client := newCallbackPoweredClient(
+			server.Addr().String(),
+			wwrclt.Options{
+				DefaultRequestTimeout: 2 * time.Second,
+			},
+			callbackPoweredClientHooks{
+				OnSessionClosed: func(reason wwr.SessionCloseReason) {
+					if reason != wwr.SessionCloseServer {
+						t.Errorf(
+							"Unexpected session closure reason: %s",
+							reason,
+						)
+					}
+					sessionClosed.Progress(1)
+				},
+			},
+		)
+		if err := client.connection.Connect(); err != nil {
+			t.Fatalf("Couldn't connect client: %s", err)
+		}
+		return client
+	}
+
+	// Create a session on the first client and restore it on the second
+	clientA := newClient()
+	defer clientA.connection.Close()
+	if _, err := clientA.connection.Request(
+		context.Background(),
+		"login",
+		nil,
+	); err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	sessionKey := clientA.connection.Session().Key
+
+	clientB := newClient()
+	defer clientB.connection.Close()
+	if err := clientB.connection.RestoreSession(
+		[]byte(sessionKey),
+	); err != nil {
+		t.Fatalf("Session restoration failed: %s", err)
+	}
+
+	// Close the session on all connections
+	if closed := server.CloseSession(sessionKey); closed != 2 {
+		t.Fatalf("Expected the session to be closed on 2 connections, got: %d", closed)
+	}
+
+	if err := sessionClosed.Wait(); err != nil {
+		t.Fatal("Expected the session to be closed on both clients")
+	}
+	if calls := atomic.LoadInt32(&hookCalls); calls != 1 {
+		t.Errorf("Expected OnSessionClosed to be called once, got: %d", calls)
+	}
+
+	// Expect the connections to remain open without a session
+	for _, client := range []*callbackPoweredClient{clientA, clientB} {
+		if client.connection.Status() != wwrclt.Connected {
+			t.Error("Expected the client to remain connected")
+		}
+		if client.connection.Session() != nil {
+			t.Error("Expected the client to have no session")
+		}
+	}
+	if num := server.SessionConnectionsNum(sessionKey); num != -1 {
+		t.Errorf("Expected the session to be inactive, got: %d", num)
+	}
+
+	// Expect closing the inactive session to do nothing
+	if closed := server.CloseSession(sessionKey); closed != -1 {
+		t.Errorf("Expected -1 for an inactive session, got: %d", closed)
+	}
+	if calls := atomic.LoadInt32(&hookCalls); calls != 1 {
+		t.Errorf("Expected OnSessionClosed not to be called again, got: %d", calls)
+	}
+
+	// Expect the session to be destroyed
+	err := clientA.connection.RestoreSession([]byte(sessionKey))
+	if _, isSessNotFoundErr := err.(wwr.SessNotFoundErr); !isSessNotFoundErr {
+		t.Errorf("Expected a SessNotFound error, got: %v", err)
+	}
+}
+
+// TestServerCloseSessionConnections tests closing all connections
+// of a session
+func TestServerCloseSessionConnections(t *testing.T) {
+	disconnected := tmdwg.NewTimedWaitGroup(1, 2*time.Second)
+
+	// Initialize server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onRequest: func(
+				_ context.Context,
+				conn wwr.Connection,
+				_ wwr.Message,
+			) (wwr.Payload, error) {
+				return nil, conn.CreateSession(nil)
+			},
+		},
+		wwr.ServerOptions{
+			SessionManager: newInMemSessManager(),
+		},
+	)
+
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+			Autoconnect:           wwr.Disabled,
+		},
+		callbackPoweredClientHooks{
+			OnDisconnected: func() {
+				disconnected.Progress(1)
+			},
+		},
+	)
+	defer client.connection.Close()
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect client: %s", err)
+	}
+	if _, err := client.connection.Request(
+		context.Background(),
+		"login",
+		nil,
+	); err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+
+	closed := server.CloseSessionConnections(client.connection.Session().Key)
+	if closed != 1 {
+		t.Fatalf("Expected 1 closed connection, got: %d", closed)
+	}
+
+	if err := disconnected.Wait(); err != nil {
+		t.Fatal("Expected the client to be disconnected")
+	}
+}
@@ -117,7 +117,10 @@ func TestServerInitiatedSessionDestruction(t *testing.T) {
 				// Mark the client-side session creation callback executed
 				sessionCreationCallbackCalled.Progress(1)
 			},
-			OnSessionClosed: func() {
+			OnSessionClosed: func(reason webwire.SessionCloseReason) {
+				if reason != webwire.SessionCloseServer {
+					t.Errorf("Unexpected session closure reason: %s", reason)
+				}
 				// Ensure this callback is called during the
 				if currentStep != 3 {
 					t.Errorf(
@@ -0,0 +1,91 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// TestServerRequest tests server-side requests
+// replied to by the client
+func TestServerRequest(t *testing.T) {
+	expectedReply := wwr.NewPayload(
+		wwr.EncodingUtf8,
+		[]byte("webwire_test_SERVER_REQUEST_reply"),
+	)
+	connected := make(chan wwr.Connection, 1)
+
+	// Initialize webwire server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onClientConnected: func(conn wwr.Connection) {
+				connected <- conn
+			},
+		},
+		wwr.ServerOptions{
+			DefaultRequestTimeout: 2 * time.Second,
+		},
+	)
+
+	// Initialize client
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+		},
+		callbackPoweredClientHooks{
+			OnRequest: func(
+				_ context.Context,
+				msg wwr.Message,
+			) (wwr.Payload, error) {
+				switch msg.Name() {
+				case "confirm":
+					return expectedReply, nil
+				case "fail":
+					return nil, wwr.ReqErr{
+						Code:    "SAMPLE_ERROR",
+						Message: "sample error message",
+					}
+				}
+				return nil, nil
+			},
+		},
+	)
+	defer client.connection.Close()
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+
+	var conn wwr.Connection
+	select {
+	case conn = <-connected:
+	case <-time.After(1 * time.Second):
+		t.Fatal("Client didn't connect")
+	}
+
+	// Send request to the client and expect a reply
+	reply, err := conn.Request(
+		context.Background(),
+		"confirm",
+		wwr.NewPayload(wwr.EncodingBinary, []byte("question")),
+	)
+	if err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	comparePayload(t, "server request reply", expectedReply, reply)
+
+	// Send request to the client and expect an error reply
+	_, err = conn.Request(context.Background(), "fail", nil)
+	reqErr, isReqErr := err.(wwr.ReqErr)
+	if !isReqErr {
+		t.Fatalf("Expected a request error, got: %v", err)
+	}
+	if reqErr.Code != "SAMPLE_ERROR" {
+		t.Errorf("Unexpected error code: %s", reqErr.Code)
+	}
+}
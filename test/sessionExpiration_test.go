@@ -0,0 +1,228 @@
+package test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// TestSessionExpiration tests the restoration of expired and
+// not yet expired sessions of different expiration policies
+func TestSessionExpiration(t *testing.T) {
+	sessDir, err := ioutil.TempDir("", "wwrsess")
+	if err != nil {
+		t.Fatalf("Couldn't create session directory: %s", err)
+	}
+	defer os.RemoveAll(sessDir)
+
+	sessionManager := wwr.NewDefaultSessionManagerWithOptions(
+		wwr.DefaultSessionManagerOptions{Path: sessDir},
+	)
+	defer sessionManager.Close()
+
+	// Initialize server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onRequest: func(
+				_ context.Context,
+				conn wwr.Connection,
+				msg wwr.Message,
+			) (wwr.Payload, error) {
+				if msg.Name() == "remember" {
+					// Create a long-lived "remember me" session
+					return nil, conn.CreateSessionWithExpiration(
+						nil,
+						wwr.SessionExpiration{MaxAge: time.Hour},
+					)
+				}
+				return nil, conn.CreateSession(nil)
+			},
+		},
+		wwr.ServerOptions{
+			SessionManager: sessionManager,
+			SessionExpiration: wwr.SessionExpiration{
+				IdleTimeout: 300 * time.Millisecond,
+			},
+		},
+	)
+
+	// createSession creates a session using the given request
+	// returning its key after disconnecting the client
+	createSession := func(name string) *wwr.Session {
+		client := newCallbackPoweredClient(
+			server.Addr().String(),
+			wwrclt.Options{
+				DefaultRequestTimeout: 2 * time.Second,
+			},
+			callbackPoweredClientHooks{},
+		)
+		defer client.connection.Close()
+
+		if _, err := client.connection.Request(
+			context.Background(),
+			name,
+			nil,
+		); err != nil {
+			t.Fatalf("Request failed: %s", err)
+		}
+		return client.connection.Session()
+	}
+
+	shortSession := createSession("login")
+	if shortSession.Expiration.IdleTimeout != 300*time.Millisecond {
+		t.Fatalf(
+			"Expected the default expiration policy, got: %v",
+			shortSession.Expiration,
+		)
+	}
+	rememberedSession := createSession("remember")
+	if rememberedSession.Expiration.MaxAge != time.Hour {
+		t.Fatalf(
+			"Expected the custom expiration policy, got: %v",
+			rememberedSession.Expiration,
+		)
+	}
+
+	// Wait for the short-lived session to expire
+	time.Sleep(500 * time.Millisecond)
+
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+		},
+		callbackPoweredClientHooks{},
+	)
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect client: %s", err)
+	}
+
+	// Expect the expired session to be reported as not found
+	sessRestErr := client.connection.RestoreSession(
+		[]byte(shortSession.Key),
+	)
+	if _, isSessNotFoundErr := sessRestErr.(wwr.SessNotFoundErr); !isSessNotFoundErr {
+		t.Fatalf(
+			"Expected a SessNotFound error, got: %s | %s",
+			reflect.TypeOf(sessRestErr),
+			sessRestErr,
+		)
+	}
+
+	// Expect the remembered session to be restored
+	if err := client.connection.RestoreSession(
+		[]byte(rememberedSession.Key),
+	); err != nil {
+		t.Fatalf("Session restoration failed: %s", err)
+	}
+	if client.connection.Session().Expiration != rememberedSession.Expiration {
+		t.Fatalf(
+			"Expected the restored session to keep its expiration policy, "+
+				"got: %v",
+			client.connection.Session().Expiration,
+		)
+	}
+}
+
+// TestSessionExpirationActive tests whether sessions in use
+// outlive their idle timeout and begin idling once they're inactive
+func TestSessionExpirationActive(t *testing.T) {
+	sessDir, err := ioutil.TempDir("", "wwrsess")
+	if err != nil {
+		t.Fatalf("Couldn't create session directory: %s", err)
+	}
+	defer os.RemoveAll(sessDir)
+
+	sessionManager := wwr.NewDefaultSessionManagerWithOptions(
+		wwr.DefaultSessionManagerOptions{
+			Path:          sessDir,
+			SweepInterval: 20 * time.Millisecond,
+		},
+	)
+	defer sessionManager.Close()
+
+	// Initialize server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onRequest: func(
+				_ context.Context,
+				conn wwr.Connection,
+				_ wwr.Message,
+			) (wwr.Payload, error) {
+				return nil, conn.CreateSession(nil)
+			},
+		},
+		wwr.ServerOptions{
+			SessionManager: sessionManager,
+			SessionExpiration: wwr.SessionExpiration{
+				IdleTimeout: 300 * time.Millisecond,
+			},
+		},
+	)
+
+	newClient := func() *callbackPoweredClient {
+		client := newCallbackPoweredClient(
+			server.Addr().String(),
+			wwrclt.Options{
+				DefaultRequestTimeout: 2 * time.Second,
+			},
+			callbackPoweredClientHooks{},
+		)
+		if err := client.connection.Connect(); err != nil {
+			t.Fatalf("Couldn't connect client: %s", err)
+		}
+		return client
+	}
+
+	// Create a session and keep it in use past its idle timeout
+	clientA := newClient()
+	if _, err := clientA.connection.Request(
+		context.Background(),
+		"login",
+		nil,
+	); err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	sessionKey := clientA.connection.Session().Key
+	time.Sleep(500 * time.Millisecond)
+
+	// Expect the session in use to be restorable
+	clientB := newClient()
+	if err := clientB.connection.RestoreSession(
+		[]byte(sessionKey),
+	); err != nil {
+		t.Fatalf("Session restoration failed: %s", err)
+	}
+	time.Sleep(500 * time.Millisecond)
+
+	// Expect the session to be restorable right after it became inactive
+	clientA.connection.Close()
+	clientB.connection.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	clientC := newClient()
+	if err := clientC.connection.RestoreSession(
+		[]byte(sessionKey),
+	); err != nil {
+		t.Fatalf("Session restoration failed: %s", err)
+	}
+
+	// Expect the session to expire once it idled for too long
+	clientC.connection.Close()
+	time.Sleep(500 * time.Millisecond)
+
+	clientD := newClient()
+	defer clientD.connection.Close()
+	sessRestErr := clientD.connection.RestoreSession([]byte(sessionKey))
+	if _, isSessNotFoundErr := sessRestErr.(wwr.SessNotFoundErr); !isSessNotFoundErr {
+		t.Fatalf("Expected a SessNotFound error, got: %v", sessRestErr)
+	}
+}
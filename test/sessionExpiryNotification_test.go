@@ -0,0 +1,146 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	tmdwg "github.com/qbeon/tmdwg-go"
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// TestSessionExpiryNotification tests the closure of an active session
+// on all of its connections once its lifetime is over
+func TestSessionExpiryNotification(t *testing.T) {
+	sessionClosed := tmdwg.NewTimedWaitGroup(2, 3*time.Second)
+	managerNotified := tmdwg.NewTimedWaitGroup(1, 3*time.Second)
+
+	var lock sync.Mutex
+	var createdSession *wwr.Session
+	closedKeys := []string{}
+
+	// Initialize server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onRequest: func(
+				_ context.Context,
+				conn wwr.Connection,
+				_ wwr.Message,
+			) (wwr.Payload, error) {
+				return nil, conn.CreateSession(nil)
+			},
+		},
+		wwr.ServerOptions{
+			SessionExpiration: wwr.SessionExpiration{
+				MaxAge: 500 * time.Millisecond,
+			},
+			SessionManager: &callbackPoweredSessionManager{
+				SessionCreated: func(conn wwr.Connection) error {
+					lock.Lock()
+					createdSession = conn.Session()
+					lock.Unlock()
+					return nil
+				},
+				SessionLookup: func(key string) (
+					wwr.SessionLookupResult,
+					error,
+				) {
+					lock.Lock()
+					defer lock.Unlock()
+					if createdSession == nil || key != createdSession.Key {
+						return wwr.SessionLookupResult{}, wwr.SessNotFoundErr{}
+					}
+					return wwr.SessionLookupResult{
+						Creation:   createdSession.Creation,
+						LastLookup: time.Now(),
+						Expiration: createdSession.Expiration,
+					}, nil
+				},
+				SessionClosed: func(sessionKey string) error {
+					lock.Lock()
+					closedKeys = append(closedKeys, sessionKey)
+					lock.Unlock()
+					managerNotified.Progress(1)
+					return nil
+				},
+			},
+		},
+	)
+
+	newClient := func() *callbackPoweredClient {
+		client := newCallbackPoweredClient(
+			server.Addr().String(),
+			wwrclt.Options{
+				DefaultRequestTimeout: 2 * time.Second,
+			},
+			callbackPoweredClientHooks{
+				OnSessionClosed: func(reason wwr.SessionCloseReason) {
+					if reason != wwr.SessionCloseExpired {
+						t.Errorf(
+							"Unexpected session closure reason: %s",
+							reason,
+						)
+					}
+					sessionClosed.Progress(1)
+				},
+			},
+		)
+		if err := client.connection.Connect(); err != nil {
+			t.Fatalf("Couldn't connect client: %s", err)
+		}
+		return client
+	}
+
+	// Create a session on the first client
+	clientA := newClient()
+	defer clientA.connection.Close()
+	if _, err := clientA.connection.Request(
+		context.Background(),
+		"login",
+		nil,
+	); err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+
+	// Restore the session on the second client
+	clientB := newClient()
+	defer clientB.connection.Close()
+	if err := clientB.connection.RestoreSession(
+		[]byte(clientA.connection.Session().Key),
+	); err != nil {
+		t.Fatalf("Session restoration failed: %s", err)
+	}
+
+	// Wait for the session to expire on both clients
+	if err := sessionClosed.Wait(); err != nil {
+		t.Fatal("Expected the session to be closed on both clients")
+	}
+	if err := managerNotified.Wait(); err != nil {
+		t.Fatal("Expected OnSessionClosed to be called")
+	}
+
+	lock.Lock()
+	if len(closedKeys) != 1 || closedKeys[0] != createdSession.Key {
+		t.Errorf("Unexpected closed sessions: %v", closedKeys)
+	}
+	lock.Unlock()
+
+	// Expect the connections to remain open without a session
+	for _, client := range []*callbackPoweredClient{clientA, clientB} {
+		if client.connection.Status() != wwrclt.Connected {
+			t.Error("Expected the client to remain connected")
+		}
+		if client.connection.Session() != nil {
+			t.Error("Expected the client to have no session")
+		}
+	}
+	if server.ActiveSessionsNum() != 0 {
+		t.Errorf(
+			"Expected no active sessions, got: %d",
+			server.ActiveSessionsNum(),
+		)
+	}
+}
@@ -0,0 +1,131 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tmdwg "github.com/qbeon/tmdwg-go"
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// TestSessionInfoUpdate tests the synchronization of session info updates
+// to all connections of the session
+func TestSessionInfoUpdate(t *testing.T) {
+	sessionUpdated := tmdwg.NewTimedWaitGroup(2, 2*time.Second)
+	sessionManager := newInMemSessManager()
+
+	// Initialize server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onRequest: func(
+				_ context.Context,
+				conn wwr.Connection,
+				msg wwr.Message,
+			) (wwr.Payload, error) {
+				if msg.Name() == "promote" {
+					return nil, conn.UpdateSessionInfo(
+						wwr.GenericSessionInfoParser(map[string]interface{}{
+							"role": "admin",
+						}),
+					)
+				}
+				return nil, conn.CreateSession(
+					wwr.GenericSessionInfoParser(map[string]interface{}{
+						"role": "user",
+					}),
+				)
+			},
+		},
+		wwr.ServerOptions{
+			SessionManager: sessionManager,
+		},
+	)
+
+	newClient := func() *callbackPoweredClient {
+		client := newCallbackPoweredClient(
+			server.Addr().String(),
+			wwrclt.Options{
+				DefaultRequestTimeout: 2 * time.Second,
+			},
+			callbackPoweredClientHooks{
+				OnSessionUpdated: func(session *wwr.Session) {
+					if role := session.Info.Value("role"); role != "admin" {
+						t.Errorf("Unexpected updated role: %v", role)
+					}
+					sessionUpdated.Progress(1)
+				},
+			},
+		)
+		if err := client.connection.Connect(); err != nil {
+			t.Fatalf("Couldn't connect client: %s", err)
+		}
+		return client
+	}
+
+	// Create a session on the first client and restore it on the second
+	clientA := newClient()
+	defer clientA.connection.Close()
+	if _, err := clientA.connection.Request(
+		context.Background(),
+		"login",
+		nil,
+	); err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	sessionKey := clientA.connection.Session().Key
+
+	clientB := newClient()
+	defer clientB.connection.Close()
+	if err := clientB.connection.RestoreSession(
+		[]byte(sessionKey),
+	); err != nil {
+		t.Fatalf("Session restoration failed: %s", err)
+	}
+
+	// Update the session info from the first client's connection
+	if _, err := clientA.connection.Request(
+		context.Background(),
+		"promote",
+		nil,
+	); err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+
+	if err := sessionUpdated.Wait(); err != nil {
+		t.Fatal("Expected the session update to be synchronized to both clients")
+	}
+
+	// Expect the session to remain active with the updated info
+	for _, client := range []*callbackPoweredClient{clientA, clientB} {
+		session := client.connection.Session()
+		if session == nil || session.Key != sessionKey {
+			t.Fatalf("Expected the session to remain active, got: %v", session)
+		}
+		if role := client.connection.SessionInfo("role"); role != "admin" {
+			t.Errorf("Unexpected client session role: %v", role)
+		}
+	}
+	for _, conn := range server.SessionConnections(sessionKey) {
+		if role := conn.SessionInfo("role"); role != "admin" {
+			t.Errorf("Unexpected server session role: %v", role)
+		}
+	}
+
+	// Expect the update to be persisted
+	result, err := sessionManager.OnSessionLookup(sessionKey)
+	if err != nil {
+		t.Fatalf("Session lookup failed: %s", err)
+	}
+	if role := result.Info["role"]; role != "admin" {
+		t.Errorf("Unexpected persisted role: %v", role)
+	}
+
+	// Expect updates of inexistent sessions to fail
+	err = server.UpdateSessionInfo("inexistent", nil)
+	if _, isSessNotFoundErr := err.(wwr.SessNotFoundErr); !isSessNotFoundErr {
+		t.Errorf("Expected a SessNotFound error, got: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tmdwg "github.com/qbeon/tmdwg-go"
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// TestSessionKeyRotation tests the rotation of the key of a session
+// attached to multiple connections
+func TestSessionKeyRotation(t *testing.T) {
+	keyRotated := tmdwg.NewTimedWaitGroup(2, 2*time.Second)
+	sessionManager := newInMemSessManager()
+	var rotatedKey string
+
+	// Initialize server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onRequest: func(
+				_ context.Context,
+				conn wwr.Connection,
+				msg wwr.Message,
+			) (wwr.Payload, error) {
+				if msg.Name() == "escalate" {
+					if err := conn.RotateSessionKey(); err != nil {
+						return nil, err
+					}
+					rotatedKey = conn.SessionKey()
+					return nil, nil
+				}
+				return nil, conn.CreateSession(nil)
+			},
+		},
+		wwr.ServerOptions{
+			SessionManager: sessionManager,
+		},
+	)
+
+	newClient := func(hooks callbackPoweredClientHooks) *callbackPoweredClient {
+		client := newCallbackPoweredClient(
+			server.Addr().String(),
+			wwrclt.Options{
+				DefaultRequestTimeout: 2 * time.Second,
+			},
+			hooks,
+		)
+		if err := client.connection.Connect(); err != nil {
+			t.Fatalf("Couldn't connect client: %s", err)
+		}
+		return client
+	}
+	hooks := callbackPoweredClientHooks{
+		OnSessionUpdated: func(_ *wwr.Session) {
+			keyRotated.Progress(1)
+		},
+	}
+
+	// Create a session on the first client and restore it on the second
+	clientA := newClient(hooks)
+	defer clientA.connection.Close()
+	if _, err := clientA.connection.Request(
+		context.Background(),
+		"login",
+		nil,
+	); err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	oldKey := clientA.connection.Session().Key
+
+	clientB := newClient(hooks)
+	defer clientB.connection.Close()
+	if err := clientB.connection.RestoreSession([]byte(oldKey)); err != nil {
+		t.Fatalf("Session restoration failed: %s", err)
+	}
+
+	// Rotate the session key
+	if _, err := clientA.connection.Request(
+		context.Background(),
+		"escalate",
+		nil,
+	); err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	if rotatedKey == "" || rotatedKey == oldKey {
+		t.Fatalf("Expected a new session key, got: %q", rotatedKey)
+	}
+
+	if err := keyRotated.Wait(); err != nil {
+		t.Fatal("Expected both clients to be notified about the new key")
+	}
+
+	// Expect both clients to know the new key
+	for _, client := range []*callbackPoweredClient{clientA, clientB} {
+		if key := client.connection.Session().Key; key != rotatedKey {
+			t.Errorf("Expected the client to know the new key, got: %s", key)
+		}
+	}
+
+	// Expect the registry to track the session by the new key
+	if num := server.SessionConnectionsNum(oldKey); num != -1 {
+		t.Errorf("Expected the old key to be unregistered, got: %d", num)
+	}
+	if num := server.SessionConnectionsNum(rotatedKey); num != 2 {
+		t.Errorf("Expected 2 connections on the new key, got: %d", num)
+	}
+
+	// Expect the old key to be invalid and the new key to be restorable
+	clientC := newClient(callbackPoweredClientHooks{})
+	defer clientC.connection.Close()
+	err := clientC.connection.RestoreSession([]byte(oldKey))
+	if _, isSessNotFoundErr := err.(wwr.SessNotFoundErr); !isSessNotFoundErr {
+		t.Errorf("Expected a SessNotFound error, got: %v", err)
+	}
+	if err := clientC.connection.RestoreSession(
+		[]byte(rotatedKey),
+	); err != nil {
+		t.Fatalf("Session restoration failed: %s", err)
+	}
+}
@@ -72,6 +72,64 @@ func (mng *inMemSessManager) OnSessionLookup(key string) (
 	return wwr.SessionLookupResult{}, wwr.SessNotFoundErr{}
 }
 
+// OnSessionUpdated implements the session manager interface.
+// It replaces the info of the session
+func (mng *inMemSessManager) OnSessionUpdated(
+	sessionKey string,
+	info wwr.SessionInfo,
+) error {
+	mng.lock.Lock()
+	defer mng.lock.Unlock()
+	session, exists := mng.sessions[sessionKey]
+	if !exists {
+		return wwr.SessNotFoundErr{}
+	}
+	session.Info = nil
+	if info != nil {
+		session.Info = info.Copy()
+	}
+	mng.sessions[sessionKey] = session
+	return nil
+}
+
+// OnSessionKeyRotated implements the session manager interface.
+// It renames the session
+func (mng *inMemSessManager) OnSessionKeyRotated(
+	oldKey string,
+	newKey string,
+) error {
+	mng.lock.Lock()
+	defer mng.lock.Unlock()
+	session, exists := mng.sessions[oldKey]
+	if !exists {
+		return wwr.SessNotFoundErr{}
+	}
+	delete(mng.sessions, oldKey)
+	session.Key = newKey
+	mng.sessions[newKey] = session
+	return nil
+}
+
+// OnSessionActivityChanged implements the SessionActivityObserver interface.
+// It updates the last lookup field of sessions becoming inactive
+func (mng *inMemSessManager) OnSessionActivityChanged(
+	sessionKey string,
+	active bool,
+) error {
+	if active {
+		return nil
+	}
+	mng.lock.Lock()
+	defer mng.lock.Unlock()
+	session, exists := mng.sessions[sessionKey]
+	if !exists {
+		return wwr.SessNotFoundErr{}
+	}
+	session.LastLookup = time.Now().UTC()
+	mng.sessions[sessionKey] = session
+	return nil
+}
+
 // OnSessionClosed implements the session manager interface.
 // It closes the session by deleting the according session file
 func (mng *inMemSessManager) OnSessionClosed(sessionKey string) error {
@@ -89,7 +147,10 @@ type callbackPoweredSessionManager struct {
 		wwr.SessionLookupResult,
 		error,
 	)
-	SessionClosed func(sessionKey string) error
+	SessionUpdated         func(sessionKey string, info wwr.SessionInfo) error
+	SessionKeyRotated      func(oldKey, newKey string) error
+	SessionActivityChanged func(sessionKey string, active bool) error
+	SessionClosed          func(sessionKey string) error
 }
 
 // OnSessionCreated implements the session manager interface
@@ -114,6 +175,42 @@ func (mng *callbackPoweredSessionManager) OnSessionLookup(
 	return mng.SessionLookup(key)
 }
 
+// OnSessionUpdated implements the session manager interface
+// calling the configured callback
+func (mng *callbackPoweredSessionManager) OnSessionUpdated(
+	sessionKey string,
+	info wwr.SessionInfo,
+) error {
+	if mng.SessionUpdated == nil {
+		return nil
+	}
+	return mng.SessionUpdated(sessionKey, info)
+}
+
+// OnSessionKeyRotated implements the session manager interface
+// calling the configured callback
+func (mng *callbackPoweredSessionManager) OnSessionKeyRotated(
+	oldKey string,
+	newKey string,
+) error {
+	if mng.SessionKeyRotated == nil {
+		return nil
+	}
+	return mng.SessionKeyRotated(oldKey, newKey)
+}
+
+// OnSessionActivityChanged implements the SessionActivityObserver interface
+// calling the configured callback
+func (mng *callbackPoweredSessionManager) OnSessionActivityChanged(
+	sessionKey string,
+	active bool,
+) error {
+	if mng.SessionActivityChanged == nil {
+		return nil
+	}
+	return mng.SessionActivityChanged(sessionKey, active)
+}
+
 // OnSessionClosed implements the session manager interface
 // calling the configured callback
 func (mng *callbackPoweredSessionManager) OnSessionClosed(
@@ -0,0 +1,122 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// TestSessionOpsRace tests whether concurrent session restorations,
+// closures and key rotations never leave a session registered
+// by a key the session manager no longer knows
+func TestSessionOpsRace(t *testing.T) {
+	sessionManager := newInMemSessManager()
+
+	// Initialize server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onRequest: func(
+				_ context.Context,
+				conn wwr.Connection,
+				msg wwr.Message,
+			) (wwr.Payload, error) {
+				if msg.Name() == "rotate" {
+					return nil, conn.RotateSessionKey()
+				}
+				return nil, conn.CreateSession(nil)
+			},
+		},
+		wwr.ServerOptions{
+			SessionManager: &callbackPoweredSessionManager{
+				SessionCreated: sessionManager.OnSessionCreated,
+				SessionLookup: func(key string) (
+					wwr.SessionLookupResult,
+					error,
+				) {
+					// Widen the window between the lookup
+					// and the registration of the connection
+					result, err := sessionManager.OnSessionLookup(key)
+					time.Sleep(5 * time.Millisecond)
+					return result, err
+				},
+				SessionKeyRotated: sessionManager.OnSessionKeyRotated,
+				SessionClosed:     sessionManager.OnSessionClosed,
+			},
+		},
+	)
+
+	newClient := func() *callbackPoweredClient {
+		client := newCallbackPoweredClient(
+			server.Addr().String(),
+			wwrclt.Options{
+				DefaultRequestTimeout: 2 * time.Second,
+			},
+			callbackPoweredClientHooks{},
+		)
+		if err := client.connection.Connect(); err != nil {
+			t.Fatalf("Couldn't connect client: %s", err)
+		}
+		return client
+	}
+
+	owner := newClient()
+	defer owner.connection.Close()
+	restorer := newClient()
+	defer restorer.connection.Close()
+
+	for i := 0; i < 50; i++ {
+		// Reset both clients and create a new session on the owner
+		if err := restorer.connection.CloseSession(); err != nil {
+			t.Fatalf("Couldn't close session: %s", err)
+		}
+		if err := owner.connection.CloseSession(); err != nil {
+			t.Fatalf("Couldn't close session: %s", err)
+		}
+		if _, err := owner.connection.Request(
+			context.Background(),
+			"login",
+			nil,
+		); err != nil {
+			t.Fatalf("Request failed: %s", err)
+		}
+		key := owner.connection.Session().Key
+
+		// Restore, close and rotate the session concurrently varying
+		// the delay of the closure to cover different interleavings,
+		// each operation may legitimately fail depending on the order
+		closureDelay := time.Duration(i%5) * 2 * time.Millisecond
+		var wg sync.WaitGroup
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			restorer.connection.RestoreSession([]byte(key))
+		}()
+		go func() {
+			defer wg.Done()
+			time.Sleep(closureDelay)
+			server.CloseSession(key)
+		}()
+		go func() {
+			defer wg.Done()
+			owner.connection.Request(context.Background(), "rotate", nil)
+		}()
+		wg.Wait()
+
+		// Expect the old key to be registered only if it's still valid
+		sessionManager.lock.Lock()
+		_, known := sessionManager.sessions[key]
+		sessionManager.lock.Unlock()
+		if num := server.SessionConnectionsNum(key); num > 0 && !known {
+			t.Fatalf(
+				"Expected the invalidated key to be unregistered, "+
+					"got %d connections",
+				num,
+			)
+		}
+	}
+}
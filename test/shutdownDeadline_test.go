@@ -0,0 +1,145 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tmdwg "github.com/qbeon/tmdwg-go"
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// TestShutdownDeadline tests whether the server abandons remaining handlers
+// and closes all connections when the shutdown deadline is exceeded
+func TestShutdownDeadline(t *testing.T) {
+	handlerStarted := tmdwg.NewTimedWaitGroup(1, 1*time.Second)
+	clientDisconnected := tmdwg.NewTimedWaitGroup(1, 1*time.Second)
+	releaseHandler := make(chan struct{})
+	defer close(releaseHandler)
+
+	// Initialize webwire server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onSignal: func(
+				_ context.Context,
+				_ wwr.Connection,
+				_ wwr.Message,
+			) {
+				// Ignore the context cancelation
+				handlerStarted.Progress(1)
+				<-releaseHandler
+			},
+		},
+		wwr.ServerOptions{},
+	)
+
+	// Initialize client
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+			Autoconnect:           wwr.Disabled,
+		},
+		callbackPoweredClientHooks{
+			OnDisconnected: func() {
+				clientDisconnected.Progress(1)
+			},
+		},
+	)
+	defer client.connection.Close()
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+	if err := client.connection.Signal(
+		"block",
+		wwr.NewPayload(wwr.EncodingBinary, []byte("test")),
+	); err != nil {
+		t.Fatalf("Couldn't send signal: %s", err)
+	}
+	if err := handlerStarted.Wait(); err != nil {
+		t.Fatal("Signal handler wasn't executed")
+	}
+
+	ctx, cancel := context.WithTimeout(
+		context.Background(),
+		100*time.Millisecond,
+	)
+	defer cancel()
+
+	start := time.Now()
+	err := server.Shutdown(ctx)
+	if _, isDeadlineErr := err.(wwr.DeadlineExceededErr); !isDeadlineErr {
+		t.Fatalf("Expected a deadline exceeded error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Shutdown took too long: %s", elapsed)
+	}
+
+	// Expect the client to be disconnected
+	if err := clientDisconnected.Wait(); err != nil {
+		t.Fatal("Client wasn't disconnected")
+	}
+}
+
+// TestShutdownGoingAway tests whether clients are advised
+// to reconnect to an alternative server when the server shuts down
+func TestShutdownGoingAway(t *testing.T) {
+	reconnected := tmdwg.NewTimedWaitGroup(1, 2*time.Second)
+	disconnected := tmdwg.NewTimedWaitGroup(1, 2*time.Second)
+
+	// Initialize the alternative webwire server
+	alternative := setupServer(
+		t,
+		&serverImpl{
+			onClientConnected: func(wwr.Connection) {
+				reconnected.Progress(1)
+			},
+		},
+		wwr.ServerOptions{},
+	)
+	defer alternative.Shutdown(context.Background())
+
+	// Initialize the webwire server going away
+	server := setupServer(
+		t,
+		&serverImpl{},
+		wwr.ServerOptions{
+			ReconnectAddress: alternative.Addr().String(),
+			ReconnectDelay:   10 * time.Millisecond,
+		},
+	)
+
+	// Initialize client
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+			ReconnectionInterval:  10 * time.Millisecond,
+		},
+		callbackPoweredClientHooks{
+			OnDisconnected: func() {
+				disconnected.Progress(1)
+			},
+		},
+	)
+	defer client.connection.Close()
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %s", err)
+	}
+
+	// Expect the client to reconnect to the alternative server
+	if err := disconnected.Wait(); err != nil {
+		t.Fatal("Client wasn't disconnected")
+	}
+	if err := reconnected.Wait(); err != nil {
+		t.Fatal("Client didn't reconnect to the alternative server")
+	}
+}
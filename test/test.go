@@ -19,7 +19,56 @@ func setupServer(
 	opts wwr.ServerOptions,
 ) wwr.Server {
 	// Setup headed server on arbitrary port
+	setServerImplDefaults(impl)
+	setServerOptionDefaults(&opts)
 
+	// Use default address
+	opts.Address = "127.0.0.1:0"
+
+	server, err := wwr.NewServer(
+		impl,
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("Failed setting up server instance: %s", err)
+	}
+
+	// Run server in a separate goroutine
+	go func() {
+		if err := server.Run(); err != nil {
+			panic(fmt.Errorf("Server failed: %s", err))
+		}
+	}()
+
+	// Return reference to the server and the address its bound to
+	return server
+}
+
+// setupPipeServer sets up a headless server connected to clients
+// through an in-memory pipe transport without any network
+func setupPipeServer(
+	t *testing.T,
+	impl *serverImpl,
+	opts wwr.ServerOptions,
+) (wwr.Server, *wwr.PipeTransport) {
+	setServerImplDefaults(impl)
+	setServerOptionDefaults(&opts)
+
+	transport := wwr.NewPipeTransport()
+	opts.ConnUpgrader = transport
+
+	server, err := wwr.NewHeadlessServer(impl, opts)
+	if err != nil {
+		t.Fatalf("Failed setting up server instance: %s", err)
+	}
+	transport.Bind(server)
+
+	return server, transport
+}
+
+// setServerImplDefaults sets the undefined hooks of the given
+// server implementation
+func setServerImplDefaults(impl *serverImpl) {
 	if impl.beforeUpgrade == nil {
 		impl.beforeUpgrade = func(_ http.ResponseWriter, _ *http.Request) bool {
 			return true
@@ -48,37 +97,20 @@ func setupServer(
 			return nil, nil
 		}
 	}
+}
 
+// setServerOptionDefaults sets the test defaults
+// of the given server options
+func setServerOptionDefaults(opts *wwr.ServerOptions) {
 	// Use default session manager if no specific one is defined
 	if opts.SessionManager == nil {
 		opts.SessionManager = newInMemSessManager()
 	}
 
-	// Use default address
-	opts.Address = "127.0.0.1:0"
-
 	// Use default heartbeat configuration if not set
 	if opts.Heartbeat == wwr.OptionUnset {
 		opts.Heartbeat = wwr.Disabled
 	}
-
-	server, err := wwr.NewServer(
-		impl,
-		opts,
-	)
-	if err != nil {
-		t.Fatalf("Failed setting up server instance: %s", err)
-	}
-
-	// Run server in a separate goroutine
-	go func() {
-		if err := server.Run(); err != nil {
-			panic(fmt.Errorf("Server failed: %s", err))
-		}
-	}()
-
-	// Return reference to the server and the address its bound to
-	return server
 }
 
 func comparePayload(t *testing.T, name string, expected, actual wwr.Payload) {
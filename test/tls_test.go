@@ -0,0 +1,161 @@
+package test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// TestTLS tests connecting to a TLS server over wss
+// using a custom certificate authority
+func TestTLS(t *testing.T) {
+	ca := newTestCertAuthority(t)
+
+	server := setupServer(
+		t,
+		&serverImpl{
+			onRequest: func(
+				_ context.Context,
+				conn wwr.Connection,
+				_ wwr.Message,
+			) (wwr.Payload, error) {
+				if conn.Info().TLS == nil {
+					t.Error("Expected the TLS connection state to be set")
+				}
+				if conn.Info().PeerCertificate != nil {
+					t.Error("Expected no peer certificate")
+				}
+				return wwr.NewPayload(wwr.EncodingBinary, []byte("ok")), nil
+			},
+		},
+		wwr.ServerOptions{
+			TLSConfig: &tls.Config{
+				Certificates: []tls.Certificate{
+					ca.issue(t, "server", x509.ExtKeyUsageServerAuth),
+				},
+			},
+		},
+	)
+
+	client := newCallbackPoweredClient(
+		"wss://"+server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+			Autoconnect:           wwr.Disabled,
+			TLSConfig:             &tls.Config{RootCAs: ca.pool},
+		},
+		callbackPoweredClientHooks{},
+	)
+	defer client.connection.Close()
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+
+	reply, err := client.connection.Request(
+		context.Background(),
+		"",
+		wwr.NewPayload(wwr.EncodingBinary, []byte("test")),
+	)
+	if err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	if string(reply.Data()) != "ok" {
+		t.Errorf("Unexpected reply: %q", string(reply.Data()))
+	}
+}
+
+// TestTLSUntrusted tests connecting to a TLS server
+// with an untrusted certificate
+func TestTLSUntrusted(t *testing.T) {
+	ca := newTestCertAuthority(t)
+
+	server := setupServer(
+		t,
+		&serverImpl{},
+		wwr.ServerOptions{
+			TLSConfig: &tls.Config{
+				Certificates: []tls.Certificate{
+					ca.issue(t, "server", x509.ExtKeyUsageServerAuth),
+				},
+			},
+		},
+	)
+
+	client := newCallbackPoweredClient(
+		"wss://"+server.Addr().String(),
+		wwrclt.Options{
+			Autoconnect: wwr.Disabled,
+		},
+		callbackPoweredClientHooks{},
+	)
+	defer client.connection.Close()
+
+	err := client.connection.Connect()
+	if _, isDisconnErr := err.(wwr.DisconnectedErr); !isDisconnErr {
+		t.Fatalf("Expected a disconnected error, got: %v", err)
+	}
+}
+
+// TestTLSClientCertificate tests mutual TLS authentication
+// exposing the client certificate through the connection info
+func TestTLSClientCertificate(t *testing.T) {
+	ca := newTestCertAuthority(t)
+	peerName := make(chan string, 1)
+
+	server := setupServer(
+		t,
+		&serverImpl{
+			onClientConnected: func(conn wwr.Connection) {
+				cert := conn.Info().PeerCertificate
+				if cert == nil {
+					peerName <- ""
+					return
+				}
+				peerName <- cert.Subject.CommonName
+			},
+		},
+		wwr.ServerOptions{
+			TLSConfig: &tls.Config{
+				Certificates: []tls.Certificate{
+					ca.issue(t, "server", x509.ExtKeyUsageServerAuth),
+				},
+				ClientAuth: tls.RequireAndVerifyClientCert,
+				ClientCAs:  ca.pool,
+			},
+		},
+	)
+
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			Autoconnect: wwr.Disabled,
+			TLSConfig: &tls.Config{
+				RootCAs: ca.pool,
+				Certificates: []tls.Certificate{
+					ca.issue(t, "alice", x509.ExtKeyUsageClientAuth),
+				},
+			},
+		},
+		callbackPoweredClientHooks{},
+	)
+	defer client.connection.Close()
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+
+	select {
+	case name := <-peerName:
+		if name != "alice" {
+			t.Errorf("Unexpected peer certificate common name: %q", name)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Client connection wasn't established in time")
+	}
+}
@@ -0,0 +1,144 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	wwr "github.com/qbeon/webwire-go"
+	wwrclt "github.com/qbeon/webwire-go/client"
+)
+
+// TestTopics tests subscribing to topics, publishing to them
+// and the filtering of subscriptions
+func TestTopics(t *testing.T) {
+	expectedPayload := wwr.NewPayload(
+		wwr.EncodingUtf8,
+		[]byte("webwire_test_TOPICS_publication"),
+	)
+	published := make(chan wwr.Payload, 1)
+
+	// Initialize webwire server
+	server := setupServer(
+		t,
+		&serverImpl{},
+		wwr.ServerOptions{
+			SubscriptionFilter: func(_ wwr.Connection, topic string) error {
+				if topic == "forbidden" {
+					return wwr.ReqErr{
+						Code:    "FORBIDDEN",
+						Message: "not allowed",
+					}
+				}
+				return nil
+			},
+		},
+	)
+
+	// Initialize client
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+		},
+		callbackPoweredClientHooks{},
+	)
+	defer client.connection.Close()
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+
+	// Subscribe to an allowed topic
+	if err := client.connection.Subscribe(
+		"news",
+		func(payload wwr.Payload) {
+			published <- payload
+		},
+	); err != nil {
+		t.Fatalf("Couldn't subscribe: %s", err)
+	}
+	if num := server.TopicSubscribersNum("news"); num != 1 {
+		t.Fatalf("Expected 1 subscriber, got: %d", num)
+	}
+
+	// Subscribe to a forbidden topic
+	err := client.connection.Subscribe("forbidden", func(wwr.Payload) {
+		t.Errorf("Unexpected publication on forbidden topic")
+	})
+	if reqErr, isReqErr := err.(wwr.ReqErr); !isReqErr ||
+		reqErr.Code != "FORBIDDEN" {
+		t.Fatalf("Expected a FORBIDDEN request error, got: %v", err)
+	}
+	if num := server.TopicSubscribersNum("forbidden"); num != 0 {
+		t.Fatalf("Expected no subscribers, got: %d", num)
+	}
+
+	// Publish to the subscribed topic
+	if err := server.Publish("news", expectedPayload); err != nil {
+		t.Fatalf("Couldn't publish: %s", err)
+	}
+	select {
+	case payload := <-published:
+		comparePayload(t, "publication", expectedPayload, payload)
+	case <-time.After(1 * time.Second):
+		t.Fatal("Publication wasn't received")
+	}
+
+	// Unsubscribe
+	if err := client.connection.Unsubscribe("news"); err != nil {
+		t.Fatalf("Couldn't unsubscribe: %s", err)
+	}
+	if num := server.TopicSubscribersNum("news"); num != 0 {
+		t.Fatalf("Expected no subscribers after unsubscription, got: %d", num)
+	}
+}
+
+// TestTopicsCleanup tests whether the subscriptions of a connection
+// are removed when the connection is closed
+func TestTopicsCleanup(t *testing.T) {
+	subscribed := make(chan struct{}, 1)
+
+	// Initialize webwire server
+	server := setupServer(
+		t,
+		&serverImpl{
+			onClientConnected: func(conn wwr.Connection) {
+				if err := conn.Subscribe("cleanup"); err != nil {
+					t.Errorf("Couldn't subscribe: %s", err)
+				}
+				subscribed <- struct{}{}
+			},
+		},
+		wwr.ServerOptions{},
+	)
+
+	// Initialize client
+	client := newCallbackPoweredClient(
+		server.Addr().String(),
+		wwrclt.Options{
+			DefaultRequestTimeout: 2 * time.Second,
+			Autoconnect:           wwr.Disabled,
+		},
+		callbackPoweredClientHooks{},
+	)
+
+	if err := client.connection.Connect(); err != nil {
+		t.Fatalf("Couldn't connect: %s", err)
+	}
+	<-subscribed
+
+	if num := server.TopicSubscribersNum("cleanup"); num != 1 {
+		t.Fatalf("Expected 1 subscriber, got: %d", num)
+	}
+
+	client.connection.Close()
+
+	// Wait for the server to remove the subscription
+	deadline := time.Now().Add(1 * time.Second)
+	for server.TopicSubscribersNum("cleanup") != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Subscription wasn't removed after disconnection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,151 @@
+package webwire
+
+import (
+	"fmt"
+	"sync"
+)
+
+// topicRegistry represents a thread safe registry of all topic subscriptions
+type topicRegistry struct {
+	lock sync.RWMutex
+
+	// topics maps the topics to the connections subscribed to them
+	topics map[string]map[*connection]struct{}
+
+	// subscriptions maps the connections to the topics they're subscribed to
+	subscriptions map[*connection]map[string]struct{}
+}
+
+// newTopicRegistry returns a new instance of a topic registry
+func newTopicRegistry() *topicRegistry {
+	return &topicRegistry{
+		lock:          sync.RWMutex{},
+		topics:        make(map[string]map[*connection]struct{}),
+		subscriptions: make(map[*connection]map[string]struct{}),
+	}
+}
+
+// verifyTopic returns an error if the given topic name is invalid.
+// A topic must consist of 1 to 255 printable 7-bit ASCII characters
+func verifyTopic(topic string) error {
+	if len(topic) < 1 || len(topic) > 255 {
+		return fmt.Errorf("Invalid topic name length: %d", len(topic))
+	}
+	for i := 0; i < len(topic); i++ {
+		if topic[i] < 32 || topic[i] > 126 {
+			return fmt.Errorf(
+				"Unsupported character in topic name: %q",
+				topic[i],
+			)
+		}
+	}
+	return nil
+}
+
+// subscribe subscribes the given connection to the given topic.
+// Returns false if the connection was already subscribed
+func (reg *topicRegistry) subscribe(con *connection, topic string) bool {
+	reg.lock.Lock()
+	defer reg.lock.Unlock()
+
+	topics, exists := reg.subscriptions[con]
+	if !exists {
+		topics = make(map[string]struct{})
+		reg.subscriptions[con] = topics
+	} else if _, subscribed := topics[topic]; subscribed {
+		return false
+	}
+	topics[topic] = struct{}{}
+
+	subscribers, exists := reg.topics[topic]
+	if !exists {
+		subscribers = make(map[*connection]struct{})
+		reg.topics[topic] = subscribers
+	}
+	subscribers[con] = struct{}{}
+	return true
+}
+
+// unsubscribe removes the subscription of the given connection
+// to the given topic. Returns false if the connection wasn't subscribed
+func (reg *topicRegistry) unsubscribe(con *connection, topic string) bool {
+	reg.lock.Lock()
+	defer reg.lock.Unlock()
+
+	topics, exists := reg.subscriptions[con]
+	if !exists {
+		return false
+	}
+	if _, subscribed := topics[topic]; !subscribed {
+		return false
+	}
+	delete(topics, topic)
+	if len(topics) < 1 {
+		delete(reg.subscriptions, con)
+	}
+	reg.removeSubscriber(con, topic)
+	return true
+}
+
+// unsubscribeAll removes all subscriptions of the given connection
+func (reg *topicRegistry) unsubscribeAll(con *connection) {
+	reg.lock.Lock()
+	defer reg.lock.Unlock()
+
+	for topic := range reg.subscriptions[con] {
+		reg.removeSubscriber(con, topic)
+	}
+	delete(reg.subscriptions, con)
+}
+
+// removeSubscriber removes the given connection from the subscribers
+// of the given topic removing the topic entirely if no subscribers are left.
+// The lock must be held by the caller
+func (reg *topicRegistry) removeSubscriber(con *connection, topic string) {
+	subscribers := reg.topics[topic]
+	delete(subscribers, con)
+	if len(subscribers) < 1 {
+		delete(reg.topics, topic)
+	}
+}
+
+// subscribers returns the list of connections subscribed to the given topic
+func (reg *topicRegistry) subscribers(topic string) []*connection {
+	reg.lock.RLock()
+	defer reg.lock.RUnlock()
+
+	subscribers := reg.topics[topic]
+	if len(subscribers) < 1 {
+		return nil
+	}
+	list := make([]*connection, 0, len(subscribers))
+	for con := range subscribers {
+		list = append(list, con)
+	}
+	return list
+}
+
+// subscribersNum returns the number of connections
+// subscribed to the given topic
+func (reg *topicRegistry) subscribersNum(topic string) int {
+	reg.lock.RLock()
+	defer reg.lock.RUnlock()
+	return len(reg.topics[topic])
+}
+
+// connectionTopics returns the list of topics
+// the given connection is subscribed to
+func (reg *topicRegistry) connectionTopics(con *connection) []string {
+	reg.lock.RLock()
+	defer reg.lock.RUnlock()
+
+	topics := reg.subscriptions[con]
+	if len(topics) < 1 {
+		return nil
+	}
+	list := make([]string, 0, len(topics))
+	for topic := range topics {
+		list = append(list, topic)
+	}
+	return list
+}
@@ -0,0 +1,65 @@
+package webwire
+
+import "testing"
+
+// TestTopicRegSubscription tests subscribing and unsubscribing
+func TestTopicRegSubscription(t *testing.T) {
+	reg := newTopicRegistry()
+	clt := newConnection(nil, "", nil)
+
+	if !reg.subscribe(clt, "topic_A") {
+		t.Fatal("Expected the first subscription to succeed")
+	}
+	if reg.subscribe(clt, "topic_A") {
+		t.Fatal("Expected the repeated subscription to be ignored")
+	}
+
+	// Expect 1 subscriber on topic A
+	if reg.subscribersNum("topic_A") != 1 {
+		t.Fatal("Expected subscribersNum to return 1")
+	}
+
+	if !reg.unsubscribe(clt, "topic_A") {
+		t.Fatal("Expected the unsubscription to succeed")
+	}
+	if reg.unsubscribe(clt, "topic_A") {
+		t.Fatal("Expected the repeated unsubscription to be ignored")
+	}
+
+	// Expect no subscribers and no topics left
+	if reg.subscribersNum("topic_A") != 0 {
+		t.Fatal("Expected subscribersNum to return 0")
+	}
+	if len(reg.topics) != 0 || len(reg.subscriptions) != 0 {
+		t.Fatal("Expected the registry to be empty")
+	}
+}
+
+// TestTopicRegUnsubscribeAll tests removing all subscriptions of a connection
+func TestTopicRegUnsubscribeAll(t *testing.T) {
+	reg := newTopicRegistry()
+	cltA := newConnection(nil, "", nil)
+	cltB := newConnection(nil, "", nil)
+
+	reg.subscribe(cltA, "topic_A")
+	reg.subscribe(cltA, "topic_B")
+	reg.subscribe(cltB, "topic_B")
+
+	if len(reg.connectionTopics(cltA)) != 2 {
+		t.Fatal("Expected connection A to be subscribed to 2 topics")
+	}
+
+	reg.unsubscribeAll(cltA)
+
+	// Expect topic A to be removed and B to remain for connection B
+	if reg.subscribersNum("topic_A") != 0 {
+		t.Fatal("Expected topic A to have no subscribers")
+	}
+	subscribers := reg.subscribers("topic_B")
+	if len(subscribers) != 1 || subscribers[0] != cltB {
+		t.Fatal("Expected connection B to remain the only subscriber of topic B")
+	}
+	if reg.connectionTopics(cltA) != nil {
+		t.Fatal("Expected connection A to have no subscriptions")
+	}
+}
@@ -0,0 +1,157 @@
+package webwire
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	// HeaderTraceparent is the header carrying the W3C trace context
+	HeaderTraceparent = "traceparent"
+
+	// HeaderTracestate is the header carrying
+	// the vendor specific W3C trace state
+	HeaderTracestate = "tracestate"
+)
+
+// TraceContext represents a W3C trace context
+type TraceContext struct {
+	// TraceID identifies the whole trace
+	TraceID [16]byte
+
+	// SpanID identifies the parent span
+	SpanID [8]byte
+
+	// Flags defines the trace flags such as the sampled flag
+	Flags byte
+
+	// State carries the optional vendor specific trace state
+	State string
+}
+
+// IsValid returns true if neither the trace nor the span identifier
+// consist of zeros only
+func (tc TraceContext) IsValid() bool {
+	return tc.TraceID != [16]byte{} && tc.SpanID != [8]byte{}
+}
+
+// Sampled returns true if the sampled flag is set
+func (tc TraceContext) Sampled() bool {
+	return tc.Flags&0x01 != 0
+}
+
+// Traceparent returns the traceparent header value
+// representing the trace context
+func (tc TraceContext) Traceparent() string {
+	return fmt.Sprintf(
+		"00-%s-%s-%02x",
+		hex.EncodeToString(tc.TraceID[:]),
+		hex.EncodeToString(tc.SpanID[:]),
+		tc.Flags,
+	)
+}
+
+// ParseTraceparent parses the given traceparent header value
+func ParseTraceparent(value string) (TraceContext, error) {
+	var tc TraceContext
+	parts := strings.Split(value, "-")
+	if len(parts) < 4 {
+		return tc, fmt.Errorf("Invalid traceparent: %q", value)
+	}
+
+	version, err := hex.DecodeString(parts[0])
+	if err != nil || len(version) != 1 || version[0] == 0xff {
+		return tc, fmt.Errorf("Invalid traceparent version: %q", parts[0])
+	}
+
+	// Version 00 defines exactly 4 fields,
+	// future versions may append additional fields
+	if version[0] == 0 && len(parts) != 4 {
+		return tc, fmt.Errorf("Invalid traceparent: %q", value)
+	}
+
+	if err := decodeHexField(tc.TraceID[:], parts[1]); err != nil {
+		return tc, fmt.Errorf("Invalid traceparent trace-id: %s", err)
+	}
+	if err := decodeHexField(tc.SpanID[:], parts[2]); err != nil {
+		return tc, fmt.Errorf("Invalid traceparent parent-id: %s", err)
+	}
+	var flags [1]byte
+	if err := decodeHexField(flags[:], parts[3]); err != nil {
+		return tc, fmt.Errorf("Invalid traceparent trace-flags: %s", err)
+	}
+	tc.Flags = flags[0]
+
+	if !tc.IsValid() {
+		return tc, fmt.Errorf("Invalid all-zero traceparent identifier")
+	}
+	return tc, nil
+}
+
+// decodeHexField decodes the given lowercase hex encoded field
+// into the given buffer requiring the field to fill the buffer exactly
+func decodeHexField(buf []byte, field string) error {
+	if len(field) != len(buf)*2 || strings.ToLower(field) != field {
+		return fmt.Errorf("%q", field)
+	}
+	if _, err := hex.Decode(buf, []byte(field)); err != nil {
+		return fmt.Errorf("%q", field)
+	}
+	return nil
+}
+
+// ContextWithTraceContext returns a new context derived from the given one
+// carrying the given trace context
+func ContextWithTraceContext(
+	ctx context.Context,
+	tc TraceContext,
+) context.Context {
+	return context.WithValue(ctx, ctxKeyTraceContext, tc)
+}
+
+// TraceContextFromContext returns the trace context attached to the given
+// context. Returns false if there's no trace context attached
+func TraceContextFromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(ctxKeyTraceContext).(TraceContext)
+	return tc, ok
+}
+
+// TraceContextPropagator implements the Propagator interface
+// propagating W3C trace contexts through the traceparent
+// and tracestate headers
+type TraceContextPropagator struct{}
+
+// Inject implements the Propagator interface
+func (TraceContextPropagator) Inject(
+	ctx context.Context,
+	headers map[string]string,
+) {
+	tc, ok := TraceContextFromContext(ctx)
+	if !ok || !tc.IsValid() {
+		return
+	}
+	headers[HeaderTraceparent] = tc.Traceparent()
+	if tc.State != "" {
+		headers[HeaderTracestate] = tc.State
+	}
+}
+
+// Extract implements the Propagator interface.
+// Invalid traceparent headers are ignored
+func (TraceContextPropagator) Extract(
+	ctx context.Context,
+	headers map[string]string,
+) context.Context {
+	value, exists := headers[HeaderTraceparent]
+	if !exists {
+		return ctx
+	}
+	tc, err := ParseTraceparent(value)
+	if err != nil {
+		return ctx
+	}
+	tc.State = headers[HeaderTracestate]
+	return ContextWithTraceContext(ctx, tc)
+}
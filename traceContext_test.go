@@ -0,0 +1,92 @@
+package webwire
+
+import (
+	"context"
+	"testing"
+)
+
+// TestParseTraceparent tests parsing and formatting of traceparent headers
+func TestParseTraceparent(t *testing.T) {
+	value := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tc, err := ParseTraceparent(value)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !tc.Sampled() {
+		t.Error("Expected the sampled flag to be set")
+	}
+	if tc.TraceID[0] != 0x4b || tc.SpanID[7] != 0xb7 {
+		t.Errorf("Unexpected trace context: %v", tc)
+	}
+	if formatted := tc.Traceparent(); formatted != value {
+		t.Errorf("Expected %q, got: %q", value, formatted)
+	}
+
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0x",
+	}
+	for _, value := range invalid {
+		if _, err := ParseTraceparent(value); err == nil {
+			t.Errorf("Expected error for invalid traceparent %q", value)
+		}
+	}
+
+	// Expect future versions to allow additional fields
+	if _, err := ParseTraceparent(
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	); err != nil {
+		t.Errorf("Unexpected error for a future version: %s", err)
+	}
+}
+
+// TestTraceContextPropagator tests injecting a trace context into headers
+// and extracting it from them
+func TestTraceContextPropagator(t *testing.T) {
+	propagator := TraceContextPropagator{}
+	expected := TraceContext{
+		TraceID: [16]byte{1, 2, 3},
+		SpanID:  [8]byte{4, 5, 6},
+		Flags:   1,
+		State:   "vendor=value",
+	}
+
+	headers := make(map[string]string)
+	propagator.Inject(context.Background(), headers)
+	if len(headers) != 0 {
+		t.Fatalf("Expected no headers without trace context, got: %v", headers)
+	}
+
+	propagator.Inject(
+		ContextWithTraceContext(context.Background(), expected),
+		headers,
+	)
+	if headers[HeaderTracestate] != expected.State {
+		t.Errorf("Unexpected tracestate header: %q", headers[HeaderTracestate])
+	}
+
+	ctx := propagator.Extract(context.Background(), headers)
+	actual, ok := TraceContextFromContext(ctx)
+	if !ok {
+		t.Fatal("Expected a trace context to be extracted")
+	}
+	if actual != expected {
+		t.Errorf("Expected %v, got: %v", expected, actual)
+	}
+
+	// Expect invalid headers to be ignored
+	ctx = propagator.Extract(
+		context.Background(),
+		map[string]string{HeaderTraceparent: "invalid"},
+	)
+	if _, ok := TraceContextFromContext(ctx); ok {
+		t.Error("Expected no trace context to be extracted")
+	}
+}
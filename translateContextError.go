@@ -3,6 +3,9 @@ package webwire
 import (
 	"context"
 	"fmt"
+
+	pld "github.com/qbeon/webwire-go/payload"
+	reqman "github.com/qbeon/webwire-go/requestManager"
 )
 
 // TranslateContextError translates context errors to webwire error types
@@ -14,3 +17,23 @@ func TranslateContextError(err error) error {
 	}
 	return fmt.Errorf("Unexpected context error: %s", err)
 }
+
+// TranslateReply translates the results of a request awaited through
+// the request manager to a webwire payload and webwire error types
+func TranslateReply(reply pld.Payload, err error) (Payload, error) {
+	switch err {
+	case nil:
+		// Don't return nil even if the reply is empty
+		// to prevent invalid memory access attempts
+		// caused by forgetting to check for != nil
+		return &EncodedPayload{Payload: reply}, nil
+	case context.DeadlineExceeded:
+		return nil, TranslateContextError(err)
+	case context.Canceled:
+		return nil, TranslateContextError(err)
+	}
+	if _, isTimeoutErr := err.(reqman.TimeoutErr); isTimeoutErr {
+		return &EncodedPayload{}, NewTimeoutErr(err)
+	}
+	return nil, err
+}